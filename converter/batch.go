@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"log"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/metrics"
+	behavior "utwente.nl/topology-to-dynetkat-coverter/convert/network_behavior"
+	"utwente.nl/topology-to-dynetkat-coverter/dynetkat"
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+const BATCH_MANIFEST = "manifest.csv"
+
+var BATCH_COLUMNS = slices.Concat([]string{
+	"topology",
+	"difficulty",
+	"nodes",
+	"edges",
+	"hosts",
+	"outsideHosts",
+	"controllers",
+	"updateRounds",
+	"headers",
+	"updatedSwitches",
+}, metrics.METRICS_COLUMNS, []string{
+	"encoding",
+	"network",
+	"error",
+})
+
+// characters that are replaced in the directory names of the topologies
+var UNSAFE_PATH_CHARS = regexp.MustCompile(`[^A-Za-z0-9._#-]`)
+
+// A variant of a topology generated by the batch command, a row of its manifest
+type batchEntry struct {
+	topology     string
+	profile      behavior.Profile
+	topo         util.Topology
+	network      *dynetkat.Network
+	encodingPath string // relative to the output directory of the batch
+	networkPath  string
+	metrics      metrics.Metrics
+	err          error
+}
+
+func (e batchEntry) row() []string {
+	row := []string{
+		e.topology,
+		e.profile.Difficulty.String(),
+		strconv.Itoa(e.topo.Graph.Nodes().Len()),
+		strconv.Itoa(e.topo.Graph.Edges().Len()),
+	}
+
+	if e.err != nil {
+		row = append(row, slices.Repeat([]string{""}, len(BATCH_COLUMNS)-len(row)-1)...)
+		return append(row, e.err.Error())
+	}
+
+	summary := e.network.Summary()
+	row = append(
+		row,
+		strconv.Itoa(summary.Hosts),
+		strconv.Itoa(summary.OutsideHosts),
+		strconv.Itoa(summary.Controllers),
+		strconv.FormatUint(uint64(e.profile.UpdateRounds), 10),
+		summary.HeaderFields,
+		strconv.Itoa(summary.UpdatedSwitches),
+	)
+	row = append(row, e.metrics.Row()...)
+	return append(row, e.encodingPath, e.networkPath, "")
+}
+
+/*
+Generates a variant of every loaded topology for every difficulty profile, and writes their
+encodings and networks to <out>/<topology>/<difficulty>.* together with a manifest that lists
+the variants and their parameters. Variants that cannot be generated are listed with their error.
+*/
+func runBatch(args []string) {
+	fs := flag.NewFlagSet(BATCH_CMD, flag.ExitOnError)
+	tf := addTopologyFlags(fs)
+	placement := addPlacementFlag(fs)
+	profiles := fs.String(
+		"profiles",
+		"easy,medium,hard,extreme",
+		"comma-separated difficulty profiles generated for every topology (names or levels from 1)",
+	)
+	outDir := fs.String("out", filepath.Join(OUTPUT_DIR, "batch"), "directory to write the variants and manifest to")
+	links := fs.Bool("links", false, "add the topology as NetKAT link terms to the encodings")
+	fs.Parse(args)
+
+	difficulties := []behavior.Difficulty{}
+	for _, str := range strings.Split(*profiles, ",") {
+		difficulty, err := behavior.ParseDifficulty(strings.TrimSpace(str))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		difficulties = append(difficulties, difficulty)
+	}
+	_, err := convert.ParseHostPlacement(*placement)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	validTopos, _ := tf.load()
+	entries := []batchEntry{}
+	for _, name := range slices.Sorted(maps.Keys(validTopos)) {
+		log.Printf("Generating %d variants of %s...\n", len(difficulties), name)
+		for _, difficulty := range difficulties {
+			entry := generateVariant(name, validTopos[name], difficulty.Profile(), *placement, *outDir, *links)
+			if entry.err != nil {
+				log.Printf("%s (%s): %s\n", name, difficulty, entry.err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	err = writeBatchManifest(filepath.Join(*outDir, BATCH_MANIFEST), entries)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	log.Printf("Done! Wrote %d variants to %s\n", len(entries), *outDir)
+}
+
+func generateVariant(
+	name string,
+	topo util.Topology,
+	profile behavior.Profile,
+	placement, outDir string,
+	links bool,
+) batchEntry {
+	entry := batchEntry{topology: name, profile: profile, topo: topo}
+	entry.network, entry.err = dynetkat.Generate(
+		context.Background(),
+		dynetkat.FromTopology(name, topo),
+		dynetkat.Scenario{Difficulty: profile.Difficulty},
+		dynetkat.Options{Placement: placement},
+	)
+	if entry.err != nil {
+		return entry
+	}
+
+	dir := UNSAFE_PATH_CHARS.ReplaceAllString(strings.TrimSuffix(name, filepath.Ext(name)), "_")
+	entry.encodingPath = filepath.Join(dir, profile.Difficulty.String()+".txt")
+	entry.networkPath = filepath.Join(dir, profile.Difficulty.String()+".network.json")
+
+	opts := dynetkat.EncodeOptions{Links: links}
+	entry.err = encodeToFile(entry.network, dynetkat.LATEX, opts, filepath.Join(outDir, entry.encodingPath))
+	if entry.err != nil {
+		return entry
+	}
+	entry.metrics, entry.err = entry.network.Metrics(opts)
+	if entry.err != nil {
+		return entry
+	}
+	entry.err = encodeToFile(
+		entry.network,
+		dynetkat.NETWORK_JSON,
+		dynetkat.EncodeOptions{},
+		filepath.Join(outDir, entry.networkPath),
+	)
+	return entry
+}
+
+func writeBatchManifest(path string, entries []batchEntry) error {
+	f, err := util.CreateNewFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write(BATCH_COLUMNS)
+	for _, entry := range entries {
+		w.Write(entry.row())
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -6,12 +6,6 @@ import (
 	"utwente.nl/topology-to-dynetkat-coverter/util"
 )
 
-var id int64
-
-func init() {
-	id = 0
-}
-
 type Controller struct {
 	id            int64
 	switches      []*Switch
@@ -30,13 +24,12 @@ func (c *Controller) NewFlowTables() map[int64]*FlowTable {
 	return c.newFlowTables
 }
 
-func NewController(switches []*Switch) *Controller {
+func NewController(id int64, switches []*Switch) *Controller {
 	c := &Controller{
 		id:            id,
 		switches:      switches,
 		newFlowTables: make(map[int64]*FlowTable),
 	}
-	id++
 
 	for _, s := range switches {
 		s.SetController(c)
@@ -58,7 +51,11 @@ func (c *Controller) findSwitch(nodeId int64) *Switch {
 Adds flow rules to the new flow table of the switch with the given node id, creating the
 new flow table if it doesn't exist. The flow table is created only if new flow rules exist.
 */
-func (c *Controller) AddNewFlowRules(nodeId, destHostId int64, portTups []util.I64Tup) error {
+func (c *Controller) AddNewFlowRules(
+	nodeId, destHostId int64,
+	headers HeaderValues,
+	portTups []util.I64Tup,
+) error {
 	sw := c.findSwitch(nodeId)
 	if sw == nil {
 		return errors.New("No switch matches the given node id!")
@@ -66,7 +63,7 @@ func (c *Controller) AddNewFlowRules(nodeId, destHostId int64, portTups []util.I
 
 	ft, exists := c.newFlowTables[nodeId]
 	if !exists {
-		if !c.newEntriesExist(sw.FlowTable(), destHostId, portTups) {
+		if !c.newEntriesExist(sw.FlowTable(), destHostId, headers, portTups) {
 			return nil
 		}
 		c.newFlowTables[nodeId] = sw.FlowTable().Copy()
@@ -74,7 +71,7 @@ func (c *Controller) AddNewFlowRules(nodeId, destHostId int64, portTups []util.I
 	}
 
 	for _, inPortOutPort := range portTups {
-		ft.AddEntry(destHostId, inPortOutPort.Fst, inPortOutPort.Snd)
+		ft.AddEntry(NewFlowMatch(destHostId, inPortOutPort.Fst, headers), inPortOutPort.Snd)
 	}
 
 	return nil
@@ -83,6 +80,7 @@ func (c *Controller) AddNewFlowRules(nodeId, destHostId int64, portTups []util.I
 func (c *Controller) newEntriesExist(
 	swFt *FlowTable,
 	destHostId int64,
+	headers HeaderValues,
 	portTups []util.I64Tup,
 ) bool {
 	if swFt == nil {
@@ -90,7 +88,8 @@ func (c *Controller) newEntriesExist(
 	}
 
 	for _, inPortOutPort := range portTups {
-		hasEntry := swFt.hasEntry(util.NewI64Tup(destHostId, inPortOutPort.Fst), inPortOutPort.Snd)
+		match := NewFlowMatch(destHostId, inPortOutPort.Fst, headers)
+		hasEntry := swFt.hasEntry(match, inPortOutPort.Snd)
 		if !hasEntry {
 			return true
 		}
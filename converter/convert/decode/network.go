@@ -0,0 +1,589 @@
+package decode
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/encode"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/netkat"
+)
+
+// a flow rule read from a NetKAT policy, before its priority is known
+type parsedRule struct {
+	match    convert.FlowMatch
+	higher   []convert.FlowMatch // the negated matches, which belong to rules with higher priorities
+	outPorts []int64
+}
+
+// the switch, controller and link terms read from a program
+type programNetwork struct {
+	swOrder     []int64
+	tables      map[int64][]parsedRule
+	newTables   map[int64][]parsedRule
+	controllers []convert.ControllerJSON
+	links       []convert.LinkJSON
+}
+
+// Rebuilds the network described by the program, see ToNetworkJSON
+func (p *Program) ToNetwork() (*convert.Network, error) {
+	netJSON, err := p.ToNetworkJSON("")
+	if err != nil {
+		return &convert.Network{}, err
+	}
+	return convert.NewNetworkFromJSON(netJSON)
+}
+
+/*
+Returns the JSON representation of the network described by the program, named after the
+definitions of the encoders: the flow tables of switch i are read from the terms SW<i> and
+SW<i>', the controllers from the terms C<j> and the switches they update from their channels
+Up<i>. The links are read from the topology term (Topo) if there is one, otherwise they are
+inferred from the flow rules that forward packets to a port of another switch. The hosts are
+placed at the ports their packets leave the network at. Priorities, wildcards and drop rules
+are recovered from the negated guards of the prioritised encodings, so the flow tables forward
+packets in the same way, although the rules may differ from the encoded ones. Other definitions,
+such as SDN and Net, are ignored.
+*/
+func (p *Program) ToNetworkJSON(name string) (convert.NetworkJSON, error) {
+	pn, err := p.readTerms()
+	if err != nil {
+		return convert.NetworkJSON{}, err
+	}
+
+	if len(pn.links) == 0 {
+		pn.links = pn.inferLinks()
+	}
+	for _, link := range pn.links {
+		pn.addSwitch(link.From)
+		pn.addSwitch(link.To)
+	}
+
+	netJSON := convert.NetworkJSON{
+		Version:     convert.NETWORK_JSON_VERSION,
+		Metadata:    convert.MetadataJSON{Name: name},
+		Switches:    []convert.SwitchJSON{},
+		Links:       pn.links,
+		Hosts:       pn.hosts(),
+		Controllers: pn.controllers,
+	}
+
+	schema := convert.NewHeaderSchema()
+	for _, nodeId := range pn.swOrder {
+		ft, err := toFlowTable(pn.tables[nodeId], schema)
+		if err != nil {
+			return convert.NetworkJSON{}, errors.New(fmt.Sprintf("Switch %d: %s", nodeId, err))
+		}
+		swJSON := convert.SwitchJSON{NodeId: nodeId, FlowTable: ft.ToJSON()}
+
+		if newRules, exists := pn.newTables[nodeId]; exists {
+			newFt, err := toFlowTable(newRules, schema)
+			if err != nil {
+				return convert.NetworkJSON{}, errors.New(fmt.Sprintf(
+					"Switch %d (new flow table): %s",
+					nodeId,
+					err,
+				))
+			}
+			newFtJSON := newFt.ToJSON()
+			swJSON.NewFlowTable = &newFtJSON
+		}
+		netJSON.Switches = append(netJSON.Switches, swJSON)
+	}
+	netJSON.Metadata.HeaderSchema = schema.String()
+
+	return netJSON, nil
+}
+
+func (pn *programNetwork) addSwitch(nodeId int64) {
+	if !slices.Contains(pn.swOrder, nodeId) {
+		pn.swOrder = append(pn.swOrder, nodeId)
+	}
+}
+
+// returns the id in a name made of the given prefix and a number, e.g. 3 for 'SW3'
+func parseId(name, prefix string) (int64, bool) {
+	idStr, hasPrefix := strings.CutPrefix(name, prefix)
+	if !hasPrefix {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	return id, err == nil && id >= 0
+}
+
+func (p *Program) readTerms() (*programNetwork, error) {
+	pn := &programNetwork{
+		swOrder:     []int64{},
+		tables:      make(map[int64][]parsedRule),
+		newTables:   make(map[int64][]parsedRule),
+		controllers: []convert.ControllerJSON{},
+		links:       []convert.LinkJSON{},
+	}
+
+	for _, def := range p.Defs {
+		swName, isNew := strings.CutSuffix(def.Name, "'")
+		if nodeId, isSw := parseId(swName, encode.SW_BASE_NAME); isSw {
+			rules, err := readSwitch(def, nodeId)
+			if err != nil {
+				return pn, err
+			}
+			pn.addSwitch(nodeId)
+			if isNew {
+				pn.newTables[nodeId] = rules
+			} else {
+				pn.tables[nodeId] = rules
+			}
+			continue
+		}
+
+		if cId, isController := parseId(def.Name, encode.CONTROLLER_BASE_NAME); isController {
+			c, err := readController(def, cId)
+			if err != nil {
+				return pn, err
+			}
+			pn.controllers = append(pn.controllers, c)
+			continue
+		}
+
+		if def.Name == encode.TOPOLOGY_TERM_NAME {
+			links, err := readTopology(def)
+			if err != nil {
+				return pn, err
+			}
+			pn.links = links
+		}
+	}
+
+	return pn, nil
+}
+
+func choices(t Term) []Term {
+	if choice, isChoice := t.(Choice); isChoice {
+		return choice.Terms
+	}
+	return []Term{t}
+}
+
+// returns the rules of a switch term, made of 'policy ; SW' and 'Up<i> ! 1 ; SW” branches
+func readSwitch(def Definition, nodeId int64) ([]parsedRule, error) {
+	products := []netkat.Product{}
+	for _, branch := range choices(def.Body) {
+		switch branch := branch.(type) {
+		case Prefix:
+			if branch.Cont != (Var{Name: def.Name}) {
+				return []parsedRule{}, errors.New(fmt.Sprintf(
+					"%s: policies must be followed by %s!",
+					def.Name,
+					def.Name,
+				))
+			}
+			branchProducts, err := netkat.Products(branch.Policy)
+			if err != nil {
+				return []parsedRule{}, errors.New(fmt.Sprintf("%s: %s", def.Name, err))
+			}
+			products = append(products, branchProducts...)
+		case Comm:
+			// the communication with the controller that installs the new flow table
+		default:
+			return []parsedRule{}, errors.New(fmt.Sprintf("%s: unsupported term %T!", def.Name, branch))
+		}
+	}
+
+	rules, err := productsToRules(products, nodeId)
+	if err != nil {
+		return []parsedRule{}, errors.New(fmt.Sprintf("%s: %s", def.Name, err))
+	}
+	return rules, nil
+}
+
+// returns the controller with the switches of the 'Up<i>' channels it communicates over
+func readController(def Definition, cId int64) (convert.ControllerJSON, error) {
+	c := convert.ControllerJSON{Id: cId, Switches: []int64{}}
+	for _, branch := range choices(def.Body) {
+		for term := branch; term != nil; {
+			comm, isComm := term.(Comm)
+			if !isComm {
+				break
+			}
+			nodeId, isUp := parseId(comm.Channel, encode.UP_CHANNEL_NAME)
+			if isUp && !slices.Contains(c.Switches, nodeId) {
+				c.Switches = append(c.Switches, nodeId)
+			}
+			term = comm.Cont
+		}
+	}
+	if len(c.Switches) == 0 {
+		return c, errors.New(fmt.Sprintf("%s: no update channels found!", def.Name))
+	}
+	return c, nil
+}
+
+// returns the links of the topology term, made of (sw = a · port = p) · (sw <- b · port <- q) products
+func readTopology(def Definition) ([]convert.LinkJSON, error) {
+	prefix, isPrefix := def.Body.(Prefix)
+	if !isPrefix || prefix.Cont != nil {
+		return []convert.LinkJSON{}, errors.New(fmt.Sprintf("%s must be a NetKAT policy!", def.Name))
+	}
+	products, err := netkat.Products(prefix.Policy)
+	if err != nil {
+		return []convert.LinkJSON{}, errors.New(fmt.Sprintf("%s: %s", def.Name, err))
+	}
+
+	linksByPort := make(map[int64]convert.LinkJSON)
+	for _, product := range products {
+		fields := make(map[string]int64)
+		valid := len(product.Negs) == 0
+		for _, test := range product.Tests {
+			value, err := strconv.ParseInt(test.Value, 10, 64)
+			fields["test "+test.Field], valid = value, valid && err == nil
+		}
+		for _, assign := range product.Assigns {
+			value, err := strconv.ParseInt(assign.Value, 10, 64)
+			fields["assign "+assign.Field], valid = value, valid && err == nil
+		}
+		if !valid || len(fields) != 4 {
+			return []convert.LinkJSON{}, errors.New(fmt.Sprintf("%s: invalid link term!", def.Name))
+		}
+
+		link := convert.LinkJSON{
+			From:     fields["test "+convert.SW_FIELD_NAME],
+			FromPort: fields["test "+convert.PORT_FIELD_NAME],
+			To:       fields["assign "+convert.SW_FIELD_NAME],
+			ToPort:   fields["assign "+convert.PORT_FIELD_NAME],
+		}
+		// each link is encoded in both directions, starting from the end with the lower port
+		if link.FromPort > link.ToPort {
+			link.From, link.To, link.FromPort, link.ToPort = link.To, link.From, link.ToPort, link.FromPort
+		}
+		linksByPort[link.FromPort] = link
+	}
+
+	return sortedLinks(linksByPort), nil
+}
+
+func sortedLinks(linksByPort map[int64]convert.LinkJSON) []convert.LinkJSON {
+	links := []convert.LinkJSON{}
+	for _, link := range linksByPort {
+		links = append(links, link)
+	}
+	slices.SortFunc(links, func(a, b convert.LinkJSON) int {
+		return cmp.Compare(a.FromPort, b.FromPort)
+	})
+	return links
+}
+
+/*
+Converts the products of a switch policy into rules. The negated tests of a product are
+completed with the tests of the product itself, since the encoding leaves out the tests
+that the negated matches share with the rule.
+*/
+func productsToRules(products []netkat.Product, nodeId int64) ([]parsedRule, error) {
+	anyMatch := convert.NewFlowMatch(convert.ANY_HOST, convert.ANY_PORT, convert.NoHeaderValues())
+	rules := []parsedRule{}
+	for _, product := range products {
+		match, err := testsToMatch(product.Tests, nodeId, anyMatch)
+		if err != nil {
+			return []parsedRule{}, err
+		}
+
+		higher := []convert.FlowMatch{}
+		for _, neg := range product.Negs {
+			tests := []netkat.Test{}
+			preds := []netkat.Predicate{neg}
+			if and, isAnd := neg.(netkat.And); isAnd {
+				preds = and.Preds
+			}
+			for _, pred := range preds {
+				test, isTest := pred.(netkat.Test)
+				if !isTest {
+					return []parsedRule{}, errors.New("only negated tests and conjunctions of tests are supported!")
+				}
+				tests = append(tests, test)
+			}
+
+			higherMatch, err := testsToMatch(tests, nodeId, match)
+			if err != nil {
+				return []parsedRule{}, err
+			}
+			higher = append(higher, higherMatch)
+		}
+
+		outPorts := []int64{}
+		for _, assign := range product.Assigns {
+			outPort, err := strconv.ParseInt(assign.Value, 10, 64)
+			if assign.Field != convert.PORT_FIELD_NAME || err != nil {
+				return []parsedRule{}, errors.New(fmt.Sprintf("unsupported assignment '%s'!", assign))
+			}
+			outPorts = append(outPorts, outPort)
+		}
+		if len(outPorts) != 1 {
+			return []parsedRule{}, errors.New("every branch of a flow rule must assign one port!")
+		}
+
+		// the branches of a rule with several outgoing ports share their match
+		i := slices.IndexFunc(rules, func(r parsedRule) bool {
+			return r.match == match && slices.Equal(r.higher, higher)
+		})
+		if i == -1 {
+			rules = append(rules, parsedRule{match: match, higher: higher, outPorts: outPorts})
+		} else if !slices.Contains(rules[i].outPorts, outPorts[0]) {
+			rules[i].outPorts = append(rules[i].outPorts, outPorts[0])
+		}
+	}
+	return rules, nil
+}
+
+// returns the match of the tests, for the fields that are not tested the match of 'base'
+func testsToMatch(tests []netkat.Test, nodeId int64, base convert.FlowMatch) (convert.FlowMatch, error) {
+	match := base
+	for _, test := range tests {
+		value, err := strconv.ParseInt(test.Value, 10, 64)
+		if err != nil || value < 0 {
+			return match, errors.New(fmt.Sprintf("invalid value in test '%s'!", test))
+		}
+
+		switch test.Field {
+		case convert.SW_FIELD_NAME:
+			if value != nodeId {
+				return match, errors.New(fmt.Sprintf("test '%s' of another switch!", test))
+			}
+		case convert.DST_FIELD_NAME:
+			match.DestHostId = value
+		case convert.PORT_FIELD_NAME:
+			match.InPort = value
+		default:
+			field, err := convert.ParseHeaderField(test.Field)
+			if err != nil {
+				return match, err
+			}
+			match.Headers[field] = value
+		}
+	}
+	return match, nil
+}
+
+/*
+Builds the flow table of the rules, adding the header fields they match to the schema. Rules
+without priorities or wildcards become entries. Otherwise, each rule gets a lower priority than
+the rules it was guarded against, and the guards without a rule of their own become drop rules.
+*/
+func toFlowTable(rules []parsedRule, schema *convert.HeaderSchema) (*convert.FlowTable, error) {
+	ft := convert.NewFlowTable()
+	prioritised := false
+	for _, rule := range rules {
+		for field, value := range rule.match.Headers {
+			if value != convert.NO_VALUE {
+				schema.AddField(convert.HeaderField(field), value)
+			}
+		}
+		hasWildcard := rule.match.DestHostId == convert.ANY_HOST || rule.match.InPort == convert.ANY_PORT
+		if len(rule.higher) > 0 || hasWildcard {
+			prioritised = true
+		}
+	}
+
+	if !prioritised {
+		for _, rule := range rules {
+			for _, outPort := range rule.outPorts {
+				ft.AddEntry(rule.match, outPort)
+			}
+		}
+		return ft, nil
+	}
+
+	ruleOf := make(map[convert.FlowMatch]int)
+	for i, rule := range rules {
+		ruleOf[rule.match] = i
+	}
+	for _, rule := range rules {
+		for _, higher := range rule.higher {
+			if _, exists := ruleOf[higher]; !exists {
+				ruleOf[higher] = len(rules)
+				rules = append(rules, parsedRule{match: higher, higher: []convert.FlowMatch{}, outPorts: []int64{}})
+			}
+		}
+	}
+
+	// the rank of a rule is the length of the longest chain of rules with higher priorities
+	ranks := make([]int, len(rules))
+	visiting := make([]bool, len(rules))
+	var rankOf func(i int) (int, error)
+	rankOf = func(i int) (int, error) {
+		if visiting[i] {
+			return 0, errors.New("the priorities of the rules are cyclic!")
+		}
+		if ranks[i] > 0 || len(rules[i].higher) == 0 {
+			return ranks[i], nil
+		}
+
+		visiting[i] = true
+		for _, higher := range rules[i].higher {
+			rank, err := rankOf(ruleOf[higher])
+			if err != nil {
+				return 0, err
+			}
+			ranks[i] = max(ranks[i], rank+1)
+		}
+		visiting[i] = false
+		return ranks[i], nil
+	}
+
+	maxRank := 0
+	for i := range rules {
+		rank, err := rankOf(i)
+		if err != nil {
+			return ft, err
+		}
+		maxRank = max(maxRank, rank)
+	}
+
+	for i, rule := range rules {
+		priority := convert.DEFAULT_PRIORITY + maxRank - ranks[i]
+		if len(rule.outPorts) == 0 {
+			ft.AddRule(convert.NewDropRule(priority, rule.match))
+			continue
+		}
+		ft.AddRule(convert.NewPriorityRule(priority, rule.match, rule.outPorts...))
+	}
+	return ft, nil
+}
+
+// returns the links between the ports that rules of a switch forward to and rules of another switch match on
+func (pn *programNetwork) inferLinks() []convert.LinkJSON {
+	inPortSw := make(map[int64]int64)
+	for nodeId, rules := range pn.allRules() {
+		for _, rule := range rules {
+			if rule.match.InPort != convert.ANY_PORT {
+				inPortSw[rule.match.InPort] = nodeId
+			}
+		}
+	}
+
+	linksByPort := make(map[int64]convert.LinkJSON)
+	for nodeId, rules := range pn.allRules() {
+		for _, rule := range rules {
+			if rule.match.InPort == convert.ANY_PORT {
+				continue
+			}
+			for _, outPort := range rule.outPorts {
+				peerId, isInPort := inPortSw[outPort]
+				if !isInPort || peerId == nodeId {
+					continue
+				}
+
+				link := convert.LinkJSON{From: nodeId, To: peerId, FromPort: rule.match.InPort, ToPort: outPort}
+				if link.FromPort > link.ToPort {
+					link.From, link.To, link.FromPort, link.ToPort = link.To, link.From, link.ToPort, link.FromPort
+				}
+				linksByPort[link.FromPort] = link
+			}
+		}
+	}
+
+	return sortedLinks(linksByPort)
+}
+
+// returns the rules of the current and the new flow table of every switch
+func (pn *programNetwork) allRules() map[int64][]parsedRule {
+	rules := make(map[int64][]parsedRule)
+	for nodeId, tableRules := range pn.tables {
+		rules[nodeId] = append(rules[nodeId], tableRules...)
+	}
+	for nodeId, tableRules := range pn.newTables {
+		rules[nodeId] = append(rules[nodeId], tableRules...)
+	}
+	return rules
+}
+
+/*
+Returns the hosts at the ports that the rules forward their packets to and that are not link
+ends. The remaining ports that packets enter the network at belong to hosts that only send,
+identified by the 'src' field if it is matched and otherwise by new ids. Hosts that are only
+used by the new flow tables are not connected from the start.
+*/
+func (pn *programNetwork) hosts() []convert.HostJSON {
+	linkPorts := make(map[int64]bool)
+	for _, link := range pn.links {
+		linkPorts[link.FromPort], linkPorts[link.ToPort] = true, true
+	}
+
+	hostsById := make(map[int64]convert.HostJSON)
+	addHosts := func(tables map[int64][]parsedRule, connected bool) {
+		for _, nodeId := range pn.swOrder {
+			for _, rule := range tables[nodeId] {
+				destId := rule.match.DestHostId
+				if _, exists := hostsById[destId]; exists || destId == convert.ANY_HOST {
+					continue
+				}
+				for _, outPort := range rule.outPorts {
+					if !linkPorts[outPort] {
+						hostsById[destId] = convert.HostJSON{
+							Id:        destId,
+							Switch:    nodeId,
+							Port:      outPort,
+							Connected: connected,
+						}
+						break
+					}
+				}
+			}
+		}
+	}
+	addHosts(pn.tables, true)
+	addHosts(pn.newTables, false)
+
+	hostPorts := make(map[int64]bool)
+	for _, h := range hostsById {
+		hostPorts[h.Port] = true
+	}
+	nextId := int64(0)
+	addSenders := func(tables map[int64][]parsedRule, connected bool) {
+		for _, nodeId := range pn.swOrder {
+			for _, rule := range tables[nodeId] {
+				inPort := rule.match.InPort
+				if inPort == convert.ANY_PORT || linkPorts[inPort] || hostPorts[inPort] {
+					continue
+				}
+
+				hostId := rule.match.Headers[convert.SRC_FIELD]
+				if _, exists := hostsById[hostId]; exists || hostId == convert.NO_VALUE {
+					for _, exists := hostsById[nextId]; exists; _, exists = hostsById[nextId] {
+						nextId++
+					}
+					hostId = nextId
+				}
+				hostsById[hostId] = convert.HostJSON{
+					Id:        hostId,
+					Switch:    nodeId,
+					Port:      inPort,
+					Connected: connected,
+				}
+				hostPorts[inPort] = true
+			}
+		}
+	}
+	addSenders(pn.tables, true)
+	addSenders(pn.newTables, false)
+
+	hosts := []convert.HostJSON{}
+	for _, h := range hostsById {
+		hosts = append(hosts, h)
+	}
+	slices.SortFunc(hosts, func(a, b convert.HostJSON) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return hosts
+}
+
+// Parses a program written by the LatexEncoder and rebuilds its network
+func ParseLatexNetwork(content string) (*convert.Network, error) {
+	program, err := NewLatexParser().ParseString(content)
+	if err != nil {
+		return &convert.Network{}, err
+	}
+	return program.ToNetwork()
+}
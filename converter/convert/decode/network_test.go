@@ -0,0 +1,49 @@
+package decode
+
+import (
+	"testing"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+)
+
+func TestToFlowTableOrdersPriorities(t *testing.T) {
+	headers := convert.NoHeaderValues()
+	specific := convert.NewFlowMatch(1, 2, headers)
+	wildcard := convert.NewFlowMatch(1, convert.ANY_PORT, headers)
+	dropped := convert.NewFlowMatch(1, 3, headers)
+
+	rules := []parsedRule{
+		{match: specific, higher: []convert.FlowMatch{}, outPorts: []int64{4}},
+		{match: wildcard, higher: []convert.FlowMatch{specific, dropped}, outPorts: []int64{5}},
+	}
+	ft, err := toFlowTable(rules, convert.NewHeaderSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priorities := make(map[convert.FlowMatch]int)
+	for _, rule := range ft.PriorityRules() {
+		priorities[rule.Match] = rule.Priority
+	}
+	if len(priorities) != 3 {
+		t.Fatalf("got %d rules, want 3 (with a drop rule for the guard without a rule)", len(priorities))
+	}
+	if priorities[wildcard] >= priorities[specific] || priorities[wildcard] >= priorities[dropped] {
+		t.Errorf("the wildcard rule must have the lowest priority, got %v", priorities)
+	}
+}
+
+func TestToFlowTableRejectsCyclicPriorities(t *testing.T) {
+	headers := convert.NoHeaderValues()
+	first := convert.NewFlowMatch(1, 2, headers)
+	second := convert.NewFlowMatch(2, 2, headers)
+
+	rules := []parsedRule{
+		{match: first, higher: []convert.FlowMatch{second}, outPorts: []int64{3}},
+		{match: second, higher: []convert.FlowMatch{first}, outPorts: []int64{4}},
+	}
+	_, err := toFlowTable(rules, convert.NewHeaderSchema())
+	if err == nil {
+		t.Error("expected an error for rules that have higher priorities than each other")
+	}
+}
@@ -0,0 +1,539 @@
+/*
+Package decode parses DyNetKAT programs, such as those written by the encoders, and rebuilds
+the networks they describe, so encodings can be compared with each other and hand-written
+case studies can be encoded again in other formats.
+*/
+package decode
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert/encode"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/netkat"
+)
+
+var (
+	// LaTeX markup around the definitions that carries no meaning, e.g. the array environments
+	LATEX_MARKUP = regexp.MustCompile(`\\(begin|end)\{[a-z]+\}(\{[a-z]+\})?|\\newpage`)
+	// the definitions of macros without arguments, e.g. those of the operators in standalone documents
+	LATEX_MACRO_DEF = regexp.MustCompile(`(?m)^\\newcommand\{(\\[a-zA-Z]+)\}\{(.*)\}[ \t]*$`)
+	// the equations that contain the definitions in documents
+	LATEX_EQUATION = regexp.MustCompile(`(?s)\\begin\{equation\}(.*?)\\end\{equation\}`)
+)
+
+const (
+	LATEX_NEW_LN = `\\`
+	LATEX_SPACE  = `\,`
+	LATEX_COL    = "&"
+)
+
+// A DyNetKAT term
+type Term interface {
+	isTerm()
+}
+
+type (
+	// A reference to a defined term, e.g. a switch
+	Var struct {
+		Name string
+	}
+
+	// The term that does nothing
+	Bot struct{}
+
+	// A NetKAT policy followed by a term, 'policy ; Cont'. Cont is nil for a bare policy.
+	Prefix struct {
+		Policy netkat.Policy
+		Cont   Term
+	}
+
+	// Sending (or receiving) a policy over a channel, followed by a term
+	Comm struct {
+		Channel string
+		Send    bool
+		Msg     netkat.Policy
+		Cont    Term
+	}
+
+	// Non-deterministic choice between terms
+	Choice struct {
+		Terms []Term
+	}
+
+	// Parallel composition of terms
+	Par struct {
+		Terms []Term
+	}
+)
+
+func (Var) isTerm()    {}
+func (Bot) isTerm()    {}
+func (Prefix) isTerm() {}
+func (Comm) isTerm()   {}
+func (Choice) isTerm() {}
+func (Par) isTerm()    {}
+
+// Defines the term with the given name
+type Definition struct {
+	Name string
+	Body Term
+}
+
+type Program struct {
+	Defs []Definition
+}
+
+// Returns the body of the definition with the given name and whether it exists
+func (p *Program) Lookup(name string) (Term, bool) {
+	for _, def := range p.Defs {
+		if def.Name == name {
+			return def.Body, true
+		}
+	}
+	return nil, false
+}
+
+type tokenKind int
+
+const (
+	IDENT_TOKEN tokenKind = iota
+	NUM_TOKEN
+	SYM_TOKEN
+	LPAREN_TOKEN
+	RPAREN_TOKEN
+	EOF_TOKEN
+)
+
+type token struct {
+	kind tokenKind
+	text string // for symbols, the name of the symbol in the SymbolEncoding, e.g. "SEQ"
+}
+
+/*
+Parses DyNetKAT programs written with the given symbols. Each definition starts on a new line
+with 'Name DEF body', and the lines that do not start a definition continue the previous one.
+The LaTeX array form of the LatexEncoder is also accepted: its environments, page breaks,
+column separators and spacing are ignored, and its line breaks separate the lines. In complete
+documents, only the equations are read, after expanding the macros defined with \newcommand.
+*/
+type Parser struct {
+	symbols map[string]string // maps the symbol names to their (trimmed) text
+}
+
+func NewParser(sym encode.SymbolEncoding) *Parser {
+	symbols := map[string]string{
+		"ONE": sym.ONE, "ZERO": sym.ZERO, "EQ": sym.EQ, "OR": sym.OR, "AND": sym.AND,
+		"NEG": sym.NEG, "STAR": sym.STAR, "ASSIGN": sym.ASSIGN, "DUP": sym.DUP,
+		"BOT": sym.BOT, "SEQ": sym.SEQ, "RECV": sym.RECV, "SEND": sym.SEND,
+		"PAR": sym.PAR, "DEF": sym.DEF, "NONDET": sym.NONDET,
+	}
+	for name, text := range symbols {
+		symbols[name] = strings.TrimSpace(strings.ReplaceAll(text, LATEX_SPACE, ""))
+	}
+	return &Parser{symbols: symbols}
+}
+
+// Returns a parser of the programs written by the LatexEncoder
+func NewLatexParser() *Parser {
+	enc := encode.NewLatexEncoder(false)
+	return NewParser(enc.SymbolEncodings())
+}
+
+func (p *Parser) Parse(r io.Reader) (*Program, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return &Program{}, err
+	}
+	return p.ParseString(string(content))
+}
+
+func (p *Parser) ParseString(content string) (*Program, error) {
+	macros := LATEX_MACRO_DEF.FindAllStringSubmatch(content, -1)
+	if equations := LATEX_EQUATION.FindAllStringSubmatch(content, -1); len(equations) > 0 {
+		bodies := []string{}
+		for _, equation := range equations {
+			bodies = append(bodies, equation[1])
+		}
+		content = strings.Join(bodies, "\n")
+	}
+	for _, macro := range macros {
+		usage := regexp.MustCompile(regexp.QuoteMeta(macro[1]) + `([^a-zA-Z]|$)`)
+		content = usage.ReplaceAllString(content, strings.ReplaceAll(macro[2], "$", "$$")+"${1}")
+	}
+
+	content = LATEX_MARKUP.ReplaceAllString(content, "")
+	content = strings.ReplaceAll(content, LATEX_NEW_LN, "\n")
+	content = strings.ReplaceAll(content, LATEX_SPACE, " ")
+	content = strings.ReplaceAll(content, LATEX_COL, " ")
+
+	names := []string{}
+	defTokens := make(map[string][]token)
+	current := ""
+	for i, line := range strings.Split(content, "\n") {
+		tokens, err := p.tokenize(line)
+		if err != nil {
+			return &Program{}, errors.New(fmt.Sprintf("Line %d: %s", i+1, err))
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+
+		if len(tokens) >= 2 && tokens[0].kind == IDENT_TOKEN && tokens[1] == (token{SYM_TOKEN, "DEF"}) {
+			current = tokens[0].text
+			if _, exists := defTokens[current]; exists {
+				return &Program{}, errors.New(fmt.Sprintf("Line %d: '%s' is defined twice!", i+1, current))
+			}
+			names = append(names, current)
+			tokens = tokens[2:]
+		} else if current == "" {
+			return &Program{}, errors.New(fmt.Sprintf("Line %d: expected a definition!", i+1))
+		}
+		defTokens[current] = append(defTokens[current], tokens...)
+	}
+
+	program := &Program{Defs: []Definition{}}
+	for _, name := range names {
+		tp := &termParser{tokens: append(defTokens[name], token{kind: EOF_TOKEN})}
+		body, err := tp.parseTerm()
+		if err == nil && tp.peek().kind != EOF_TOKEN {
+			err = errors.New(fmt.Sprintf("unexpected '%s'", tp.peek().text))
+		}
+		if err != nil {
+			return &Program{}, errors.New(fmt.Sprintf("Definition of %s: %s", name, err))
+		}
+		program.Defs = append(program.Defs, Definition{Name: name, Body: body})
+	}
+
+	return program, nil
+}
+
+// splits a line into identifiers, numbers, parentheses and the symbols of the encoding
+func (p *Parser) tokenize(line string) ([]token, error) {
+	// the longest symbols are matched first, e.g. '<-' before '<'
+	symNames := []string{}
+	for name, text := range p.symbols {
+		if text != "" {
+			symNames = append(symNames, name)
+		}
+	}
+	slices.SortFunc(symNames, func(a, b string) int {
+		if res := len(p.symbols[b]) - len(p.symbols[a]); res != 0 {
+			return res
+		}
+		return strings.Compare(a, b)
+	})
+
+	tokens := []token{}
+	for i := 0; i < len(line); {
+		c := rune(line[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{LPAREN_TOKEN, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{RPAREN_TOKEN, ")"})
+			i++
+		case unicode.IsDigit(c):
+			j := i
+			for j < len(line) && unicode.IsDigit(rune(line[j])) {
+				j++
+			}
+			tokens = append(tokens, token{NUM_TOKEN, line[i:j]})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(line) && (isIdentChar(rune(line[j])) || line[j] == '\'') {
+				j++
+			}
+			tokens = append(tokens, p.identOrSymbol(line[i:j]))
+			i = j
+		default:
+			matched := false
+			for _, name := range symNames {
+				if strings.HasPrefix(line[i:], p.symbols[name]) {
+					tokens = append(tokens, token{SYM_TOKEN, name})
+					i += len(p.symbols[name])
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return []token{}, errors.New(fmt.Sprintf("unexpected '%s'", line[i:min(i+10, len(line))]))
+			}
+		}
+	}
+	return tokens, nil
+}
+
+func isIdentChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_'
+}
+
+// symbols made of letters, e.g. 'dup', are read as identifiers first
+func (p *Parser) identOrSymbol(word string) token {
+	for name, text := range p.symbols {
+		if text == word {
+			return token{SYM_TOKEN, name}
+		}
+	}
+	return token{IDENT_TOKEN, word}
+}
+
+// a recursive descent parser of the tokens of one definition
+type termParser struct {
+	tokens []token
+	pos    int
+}
+
+func (tp *termParser) peek() token {
+	return tp.tokens[tp.pos]
+}
+
+func (tp *termParser) peekAt(offset int) token {
+	return tp.tokens[min(tp.pos+offset, len(tp.tokens)-1)]
+}
+
+func (tp *termParser) next() token {
+	t := tp.tokens[tp.pos]
+	if t.kind != EOF_TOKEN {
+		tp.pos++
+	}
+	return t
+}
+
+func (tp *termParser) isSym(name string) bool {
+	return tp.peek() == token{SYM_TOKEN, name}
+}
+
+func (tp *termParser) expect(kind tokenKind, text string) error {
+	t := tp.next()
+	if t.kind != kind || t.text != text {
+		return errors.New(fmt.Sprintf("expected '%s' but found '%s'", text, t.text))
+	}
+	return nil
+}
+
+// term := choice (PAR choice)*
+func (tp *termParser) parseTerm() (Term, error) {
+	terms := []Term{}
+	for {
+		term, err := tp.parseChoice()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if !tp.isSym("PAR") {
+			break
+		}
+		tp.next()
+	}
+
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return Par{Terms: terms}, nil
+}
+
+// choice := seqTerm (NONDET seqTerm)*
+func (tp *termParser) parseChoice() (Term, error) {
+	terms := []Term{}
+	for {
+		term, err := tp.parseSeqTerm()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if !tp.isSym("NONDET") {
+			break
+		}
+		tp.next()
+	}
+
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return Choice{Terms: terms}, nil
+}
+
+// seqTerm := BOT | name | channel (SEND | RECV) policy SEQ seqTerm | policy [SEQ seqTerm]
+func (tp *termParser) parseSeqTerm() (Term, error) {
+	if tp.isSym("BOT") {
+		tp.next()
+		return Bot{}, nil
+	}
+
+	if tp.peek().kind == IDENT_TOKEN {
+		following := tp.peekAt(1)
+		switch following {
+		case token{SYM_TOKEN, "SEND"}, token{SYM_TOKEN, "RECV"}:
+			channel := tp.next().text
+			send := tp.next() == token{SYM_TOKEN, "SEND"}
+			msg, err := tp.parseNeg()
+			if err != nil {
+				return nil, err
+			}
+			if err := tp.expect(SYM_TOKEN, "SEQ"); err != nil {
+				return nil, err
+			}
+			cont, err := tp.parseSeqTerm()
+			if err != nil {
+				return nil, err
+			}
+			return Comm{Channel: channel, Send: send, Msg: msg, Cont: cont}, nil
+		case token{SYM_TOKEN, "EQ"}, token{SYM_TOKEN, "ASSIGN"}:
+			// a test or assignment without parentheses
+		default:
+			return Var{Name: tp.next().text}, nil
+		}
+	}
+
+	policy, err := tp.parseUnion()
+	if err != nil {
+		return nil, err
+	}
+	if !tp.isSym("SEQ") {
+		return Prefix{Policy: policy}, nil
+	}
+
+	tp.next()
+	cont, err := tp.parseSeqTerm()
+	if err != nil {
+		return nil, err
+	}
+	return Prefix{Policy: policy, Cont: cont}, nil
+}
+
+// union := seq (OR seq)*
+func (tp *termParser) parseUnion() (netkat.Policy, error) {
+	pols := []netkat.Policy{}
+	for {
+		pol, err := tp.parseSeq()
+		if err != nil {
+			return nil, err
+		}
+		pols = append(pols, pol)
+		if !tp.isSym("OR") {
+			break
+		}
+		tp.next()
+	}
+
+	if len(pols) == 1 {
+		return pols[0], nil
+	}
+	if preds, arePreds := asPredicates(pols); arePreds {
+		return netkat.Disj(preds...), nil
+	}
+	return netkat.NewUnion(pols...), nil
+}
+
+// seq := neg (AND neg)*
+func (tp *termParser) parseSeq() (netkat.Policy, error) {
+	pols := []netkat.Policy{}
+	for {
+		pol, err := tp.parseNeg()
+		if err != nil {
+			return nil, err
+		}
+		pols = append(pols, pol)
+		if !tp.isSym("AND") {
+			break
+		}
+		tp.next()
+	}
+
+	if len(pols) == 1 {
+		return pols[0], nil
+	}
+	if preds, arePreds := asPredicates(pols); arePreds {
+		return netkat.Conj(preds...), nil
+	}
+	return netkat.NewSeq(pols...), nil
+}
+
+// neg := NEG neg | star
+func (tp *termParser) parseNeg() (netkat.Policy, error) {
+	if !tp.isSym("NEG") {
+		return tp.parseStar()
+	}
+
+	tp.next()
+	pol, err := tp.parseNeg()
+	if err != nil {
+		return nil, err
+	}
+	pred, isPred := pol.(netkat.Predicate)
+	if !isPred {
+		return nil, errors.New("only predicates can be negated")
+	}
+	return netkat.Not(pred), nil
+}
+
+// star := atom STAR*
+func (tp *termParser) parseStar() (netkat.Policy, error) {
+	pol, err := tp.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	for tp.isSym("STAR") {
+		tp.next()
+		pol = netkat.NewStar(pol)
+	}
+	return pol, nil
+}
+
+// atom := ZERO | ONE | DUP | field (EQ | ASSIGN) value | '(' union ')'
+func (tp *termParser) parseAtom() (netkat.Policy, error) {
+	t := tp.next()
+	switch {
+	case t.kind == NUM_TOKEN && t.text == "0", t == token{SYM_TOKEN, "ZERO"}:
+		return netkat.Zero{}, nil
+	case t.kind == NUM_TOKEN && t.text == "1", t == token{SYM_TOKEN, "ONE"}:
+		return netkat.One{}, nil
+	case t == token{SYM_TOKEN, "DUP"}:
+		return netkat.Dup{}, nil
+	case t.kind == IDENT_TOKEN:
+		op, value := tp.next(), tp.next()
+		if value.kind != NUM_TOKEN && value.kind != IDENT_TOKEN {
+			return nil, errors.New(fmt.Sprintf("expected a value for field '%s'", t.text))
+		}
+		switch op {
+		case token{SYM_TOKEN, "EQ"}:
+			return netkat.NewTest(t.text, value.text), nil
+		case token{SYM_TOKEN, "ASSIGN"}:
+			return netkat.NewAssign(t.text, value.text), nil
+		}
+		return nil, errors.New(fmt.Sprintf("expected a test or assignment of field '%s'", t.text))
+	case t.kind == LPAREN_TOKEN:
+		pol, err := tp.parseUnion()
+		if err != nil {
+			return nil, err
+		}
+		if err := tp.expect(RPAREN_TOKEN, ")"); err != nil {
+			return nil, err
+		}
+		return pol, nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unexpected '%s'", t.text))
+	}
+}
+
+func asPredicates(pols []netkat.Policy) ([]netkat.Predicate, bool) {
+	preds := []netkat.Predicate{}
+	for _, pol := range pols {
+		pred, isPred := pol.(netkat.Predicate)
+		if !isPred {
+			return []netkat.Predicate{}, false
+		}
+		preds = append(preds, pred)
+	}
+	return preds, true
+}
@@ -0,0 +1,97 @@
+package decode_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/decode"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/encode"
+	behavior "utwente.nl/topology-to-dynetkat-coverter/convert/network_behavior"
+	"utwente.nl/topology-to-dynetkat-coverter/dynetkat"
+)
+
+func generate(t *testing.T, spec string, difficulty behavior.Difficulty, headers string) *convert.Network {
+	network, err := dynetkat.Generate(
+		context.Background(),
+		dynetkat.Synthetic(spec),
+		dynetkat.Scenario{Difficulty: difficulty},
+		dynetkat.Options{Headers: headers},
+	)
+	if err != nil {
+		t.Fatalf("%s: %s", spec, err)
+	}
+	return network.Unwrap()
+}
+
+func encodeNetwork(t *testing.T, n *convert.Network, opts encode.LatexEncoderOptions) string {
+	encoder := encode.NewLatexEncoderWithOptions(opts)
+	content, err := encoder.Encode(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func decodeNetwork(t *testing.T, content string) *convert.Network {
+	network, err := decode.ParseLatexNetwork(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return network
+}
+
+// encoding the network rebuilt from an encoding must give the same encoding
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	specs := []string{"ring:6", "grid:3x3", "fattree:4", "waxman:20:0.4:0.2"}
+	headers := []string{"", "src,vlan=10"}
+	options := []encode.LatexEncoderOptions{
+		{},
+		{LinkTerms: true},
+		{OptimizeFlowTables: true},
+		{CompactPolicies: true},
+		{LinkTerms: true, OptimizeFlowTables: true},
+		// the drawing of the topology needs the links that are not used by any flow rule
+		{Standalone: true, LinkTerms: true, Title: "Round trip"},
+	}
+
+	for _, spec := range specs {
+		for _, header := range headers {
+			network := generate(t, spec, behavior.MEDIUM, header)
+
+			for _, opts := range options {
+				t.Run(fmt.Sprintf("%s/%q/%+v", spec, header, opts), func(t *testing.T) {
+					content := encodeNetwork(t, network, opts)
+					if opts.Standalone {
+						/*
+							the tables of the document list the switches of the controllers that are
+							never updated, which the program does not record, so the document is
+							compared after they were dropped once
+						*/
+						content = encodeNetwork(t, decodeNetwork(t, content), opts)
+					}
+
+					reencoded := encodeNetwork(t, decodeNetwork(t, content), opts)
+					if reencoded != content {
+						t.Errorf("the encoding changed after decoding it:\n%s\nwant:\n%s", reencoded, content)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestParseRejectsMalformedPrograms(t *testing.T) {
+	programs := []string{
+		"SW1 & \\triangleq & (dst = 1 \\cdot port = 2",
+		"SW1 & \\triangleq & (port \\leftarrow 2 \\cdot dst = 1) \\, ;\\,  SW1",
+	}
+
+	for _, program := range programs {
+		_, err := decode.ParseLatexNetwork(program)
+		if err == nil {
+			t.Errorf("expected an error for %q", program)
+		}
+	}
+}
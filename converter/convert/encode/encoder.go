@@ -1,6 +1,11 @@
 package encode
 
-import "utwente.nl/topology-to-dynetkat-coverter/convert"
+import (
+	"io"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/netkat"
+)
 
 type SymbolEncoding struct {
 	// NetKAT symbols
@@ -12,6 +17,7 @@ type SymbolEncoding struct {
 	NEG    string // negation
 	STAR   string // recursive symbol
 	ASSIGN string // packet field assignment
+	DUP    string // packet history recording
 
 	// DyNetKAT symbols
 	BOT    string // Bot symbol (aka do nothing)
@@ -23,8 +29,28 @@ type SymbolEncoding struct {
 	NONDET string // non-deterministic choice symbol
 }
 
+func (s SymbolEncoding) NetKATSymbols() netkat.Symbols {
+	return netkat.Symbols{
+		ZERO:   s.ZERO,
+		ONE:    s.ONE,
+		EQ:     s.EQ,
+		ASSIGN: s.ASSIGN,
+		NEG:    s.NEG,
+		AND:    s.AND,
+		OR:     s.OR,
+		STAR:   s.STAR,
+		DUP:    s.DUP,
+	}
+}
+
+func (s SymbolEncoding) FormatPolicy(p netkat.Policy) string {
+	return netkat.Format(p, s.NetKATSymbols())
+}
+
 type NetworkEncoder interface {
 	SymbolEncodings() SymbolEncoding
-	Encode(n *convert.Network) string
+	Encode(n *convert.Network) (string, error)
+	// writes the encoding to w while it is produced, instead of returning it as a whole
+	EncodeTo(n *convert.Network, w io.Writer) error
 	ProactiveSwitch() bool
 }
@@ -0,0 +1,318 @@
+package encode
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+const (
+	FIGURE_WIDTH        = 14.0 // cm
+	FIGURE_HEIGHT       = 9.0  // cm
+	HOST_DISTANCE       = 0.9  // cm between a host and its switch in the drawing
+	TIKZ_MAX_SWITCHES   = 80   // larger topologies are not drawn, since the drawing would not be readable
+	DEFAULT_TITLE       = "DyNetKAT encoding"
+	LATEX_DOCUMENT_HEAD = `\documentclass{article}
+\usepackage[margin=2cm]{geometry}
+\usepackage{amsmath,amssymb}
+\usepackage{tikz}
+`
+	LATEX_DOCUMENT_TAIL = "\n\\end{document}\n"
+)
+
+// macros of the operators in standalone documents, so they can be restyled in one place
+var LATEX_MACROS = []struct{ name, definition string }{
+	{"\\nkand", "\\cdot"},
+	{"\\nkneg", "\\neg"},
+	{"\\nkassign", "\\leftarrow"},
+	{"\\nkdup", "\\mathit{dup}"},
+	{"\\dnkbot", "\\bot"},
+	{"\\dnkseq", "\\, ;\\, "},
+	{"\\dnkrecv", "\\, ?\\, "},
+	{"\\dnksend", "\\, !\\, "},
+	{"\\dnkpar", "\\, \\|\\, "},
+	{"\\dnkdef", "\\triangleq"},
+	{"\\dnkchoice", "\\, \\oplus\\,"},
+}
+
+// the symbols of the standalone documents, the macros above followed by a space
+var LATEX_MACRO_SYMBOLS = SymbolEncoding{
+	ONE:    "1",
+	ZERO:   "0",
+	EQ:     "=",
+	OR:     "+",
+	AND:    "\\nkand ",
+	NEG:    "\\nkneg ",
+	STAR:   "*",
+	ASSIGN: "\\nkassign ",
+	DUP:    "\\nkdup ",
+
+	BOT:    "\\dnkbot ",
+	SEQ:    "\\dnkseq ",
+	RECV:   "\\dnkrecv ",
+	SEND:   "\\dnksend ",
+	PAR:    "\\dnkpar ",
+	DEF:    "\\dnkdef ",
+	NONDET: "\\dnkchoice ",
+}
+
+// fill colours of the switches of each controller in the drawing, reused when there are more controllers
+var TIKZ_DOMAIN_COLORS = []string{
+	"blue!20", "red!20", "green!20", "orange!25", "violet!20", "cyan!20", "yellow!35", "brown!25",
+}
+
+/*
+Returns the start of a complete LaTeX document, up to the program: a preamble with the macros of
+the operators, a TikZ drawing of the topology with the ports at the ends of the links and the
+switches coloured by controller, and tables of the hosts and the controllers. The document
+ends with the program, followed by LATEX_DOCUMENT_TAIL.
+*/
+func (f *LatexEncoder) encodeDocumentHead(n *convert.Network) string {
+	var sb strings.Builder
+	sb.WriteString(LATEX_DOCUMENT_HEAD)
+	sb.WriteString("\n% DyNetKAT operators\n")
+	for _, macro := range LATEX_MACROS {
+		sb.WriteString(fmt.Sprintf("\\newcommand{%s}{%s}\n", macro.name, macro.definition))
+	}
+
+	title := f.title
+	if title == "" {
+		title = DEFAULT_TITLE
+	}
+	sb.WriteString("\n\\begin{document}\n\n")
+	sb.WriteString(fmt.Sprintf("\\section*{%s}\n\n", escapeLatex(title)))
+
+	sb.WriteString(encodeTopologyFigure(n))
+	sb.WriteString(encodeHostsTable(n))
+	sb.WriteString(encodeControllersTable(n))
+
+	sb.WriteString("\\newpage\n\\subsection*{Program}\n\n")
+	return sb.String()
+}
+
+func escapeLatex(text string) string {
+	replacer := strings.NewReplacer(
+		"\\", "\\textbackslash{}",
+		"&", "\\&", "%", "\\%", "$", "\\$", "#", "\\#", "_", "\\_", "{", "\\{", "}", "\\}",
+		"~", "\\textasciitilde{}", "^", "\\textasciicircum{}",
+	)
+	return replacer.Replace(text)
+}
+
+func switchName(nodeId int64) string {
+	return fmt.Sprintf("%s%d", SW_BASE_NAME, nodeId)
+}
+
+// returns the switches sorted by their topology node ids
+func sortedSwitches(n *convert.Network) []*convert.Switch {
+	switches := slices.Clone(n.Switches())
+	slices.SortFunc(switches, func(a, b *convert.Switch) int {
+		return cmp.Compare(a.TopoNode().ID(), b.TopoNode().ID())
+	})
+	return switches
+}
+
+/*
+Places the switches at their geographic coordinates if all of them have coordinates, and
+otherwise on a circle. The positions are scaled to the size of the figure, in cm.
+*/
+func switchPositions(switches []*convert.Switch, topo util.Topology) map[int64][2]float64 {
+	positions := make(map[int64][2]float64)
+	for _, sw := range switches {
+		lon, lat, exists := topo.Coordinates(sw.TopoNode().ID())
+		if !exists {
+			positions = make(map[int64][2]float64)
+			break
+		}
+		positions[sw.TopoNode().ID()] = [2]float64{lon, lat}
+	}
+
+	if len(positions) == 0 {
+		for i, sw := range switches {
+			angle := math.Pi/2 - 2*math.Pi*float64(i)/float64(len(switches))
+			positions[sw.TopoNode().ID()] = [2]float64{math.Cos(angle), math.Sin(angle)}
+		}
+	}
+
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, pos := range positions {
+		minX, minY = min(minX, pos[0]), min(minY, pos[1])
+		maxX, maxY = max(maxX, pos[0]), max(maxY, pos[1])
+	}
+	scale := math.Min(FIGURE_WIDTH/math.Max(maxX-minX, 1e-9), FIGURE_HEIGHT/math.Max(maxY-minY, 1e-9))
+	for nodeId, pos := range positions {
+		positions[nodeId] = [2]float64{(pos[0] - minX) * scale, (pos[1] - minY) * scale}
+	}
+	return positions
+}
+
+// returns a TikZ figure of the switches, links and hosts, with the ports at the ends of the links
+func encodeTopologyFigure(n *convert.Network) string {
+	switches := sortedSwitches(n)
+	if len(switches) > TIKZ_MAX_SWITCHES {
+		return fmt.Sprintf(
+			"The topology has %d switches and is too large to be drawn.\n\n",
+			len(switches),
+		)
+	}
+
+	positions := switchPositions(switches, n.Topology())
+	center := [2]float64{}
+	for _, pos := range positions {
+		center[0] += pos[0] / float64(len(positions))
+		center[1] += pos[1] / float64(len(positions))
+	}
+
+	colors := make(map[int64]string)
+	for i, c := range n.Controllers() {
+		for _, sw := range c.Switches() {
+			colors[sw.TopoNode().ID()] = TIKZ_DOMAIN_COLORS[i%len(TIKZ_DOMAIN_COLORS)]
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\\begin{figure}[h]\n\\centering\n")
+	sb.WriteString("\\begin{tikzpicture}[\n")
+	sb.WriteString("\tswitch/.style={circle, draw, minimum size=7mm, inner sep=1pt, font=\\scriptsize},\n")
+	sb.WriteString("\thost/.style={rectangle, draw, inner sep=2pt, font=\\scriptsize},\n")
+	sb.WriteString("\tport/.style={fill=white, inner sep=0.5pt, font=\\tiny},\n]\n")
+
+	for _, sw := range switches {
+		nodeId := sw.TopoNode().ID()
+		fill := ""
+		if color, exists := colors[nodeId]; exists {
+			fill = ", fill=" + color
+		}
+		sb.WriteString(fmt.Sprintf(
+			"\\node[switch%s] (%s) at (%.2f, %.2f) {$%s$};\n",
+			fill,
+			switchName(nodeId),
+			positions[nodeId][0],
+			positions[nodeId][1],
+			switchName(nodeId),
+		))
+	}
+
+	for _, link := range n.Links() {
+		sb.WriteString(fmt.Sprintf(
+			"\\draw (%s) -- node[port, pos=0.2] {%d} node[port, pos=0.8] {%d} (%s);\n",
+			switchName(link.TopoEdge().From().ID()),
+			link.FromPort(),
+			link.ToPort(),
+			switchName(link.TopoEdge().To().ID()),
+		))
+	}
+
+	connected := make(map[int64]bool)
+	for _, h := range n.Hosts() {
+		connected[h.ID()] = true
+	}
+	swHosts := make(map[int64][]*convert.Host)
+	for _, h := range n.CreatedHosts() {
+		nodeId := h.Switch().TopoNode().ID()
+		swHosts[nodeId] = append(swHosts[nodeId], h)
+	}
+
+	for _, sw := range switches {
+		nodeId := sw.TopoNode().ID()
+		pos := positions[nodeId]
+		// the hosts are placed outwards, away from the center of the drawing
+		baseAngle := math.Atan2(pos[1]-center[1], pos[0]-center[0])
+		for i, h := range swHosts[nodeId] {
+			angle := baseAngle + 0.5*(float64(i)-float64(len(swHosts[nodeId])-1)/2)
+			style := ""
+			if !connected[h.ID()] {
+				// only reachable after a controller update
+				style = "[dashed]"
+			}
+			sb.WriteString(fmt.Sprintf(
+				"\\node[host] (H%d) at (%.2f, %.2f) {$H_{%d}$};\n",
+				h.ID(),
+				pos[0]+HOST_DISTANCE*math.Cos(angle),
+				pos[1]+HOST_DISTANCE*math.Sin(angle),
+				h.ID(),
+			))
+			sb.WriteString(fmt.Sprintf(
+				"\\draw%s (H%d) -- node[port, pos=0.8] {%d} (%s);\n",
+				style,
+				h.ID(),
+				h.SwitchPort(),
+				switchName(nodeId),
+			))
+		}
+	}
+
+	sb.WriteString("\\end{tikzpicture}\n")
+	sb.WriteString("\\caption{Topology with the port numbers at the ends of the links. ")
+	sb.WriteString("The switches are coloured by controller and the dashed hosts are only ")
+	sb.WriteString("reachable after an update.}\n")
+	sb.WriteString("\\end{figure}\n\n")
+	return sb.String()
+}
+
+func encodeHostsTable(n *convert.Network) string {
+	connected := make(map[int64]bool)
+	for _, h := range n.Hosts() {
+		connected[h.ID()] = true
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\\subsection*{Hosts}\n\n")
+	sb.WriteString("\\begin{tabular}{rllrl}\n\\hline\n")
+	sb.WriteString("Host & IP address & Switch & Port & Connected \\\\\n\\hline\n")
+	for _, h := range n.CreatedHosts() {
+		connectedStr := "from the start"
+		if !connected[h.ID()] {
+			connectedStr = "after an update"
+		}
+		sb.WriteString(fmt.Sprintf(
+			"$H_{%d}$ & %s & $%s$ & %d & %s \\\\\n",
+			h.ID(),
+			h.IP(),
+			switchName(h.Switch().TopoNode().ID()),
+			h.SwitchPort(),
+			connectedStr,
+		))
+	}
+	sb.WriteString("\\hline\n\\end{tabular}\n\n")
+	return sb.String()
+}
+
+func encodeControllersTable(n *convert.Network) string {
+	var sb strings.Builder
+	sb.WriteString("\\subsection*{Controllers}\n\n")
+	sb.WriteString("\\begin{tabular}{rp{7cm}p{5cm}}\n\\hline\n")
+	sb.WriteString("Controller & Switches & Updated switches \\\\\n\\hline\n")
+	for _, c := range n.Controllers() {
+		nodeIds := []int64{}
+		for _, sw := range c.Switches() {
+			nodeIds = append(nodeIds, sw.TopoNode().ID())
+		}
+		slices.Sort(nodeIds)
+		switches := []string{}
+		for _, nodeId := range nodeIds {
+			switches = append(switches, "$"+switchName(nodeId)+"$")
+		}
+
+		updated := []string{}
+		for _, nodeId := range slices.Sorted(maps.Keys(c.NewFlowTables())) {
+			updated = append(updated, "$"+switchName(nodeId)+"$")
+		}
+
+		sb.WriteString(fmt.Sprintf(
+			"$%s%d$ & %s & %s \\\\\n",
+			CONTROLLER_BASE_NAME,
+			c.ID(),
+			strings.Join(switches, ", "),
+			strings.Join(updated, ", "),
+		))
+	}
+	sb.WriteString("\\hline\n\\end{tabular}\n\n")
+	return sb.String()
+}
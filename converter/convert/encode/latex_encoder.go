@@ -3,10 +3,14 @@ package encode
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"maps"
+	"slices"
 	"strings"
 
 	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/netkat"
 )
 
 const (
@@ -20,34 +24,80 @@ const (
 	CONTROLLER_BASE_NAME = "C"
 	UP_CHANNEL_NAME      = "Up"
 	HELP_CHANNEL_NAME    = "Help"
+	TOPOLOGY_TERM_NAME   = "Topo"
+	NETWORK_TERM_NAME    = "Net"
+	SDN_TERM_NAME        = "SDN"
 )
 
+var LATEX_SYMBOLS = SymbolEncoding{
+	ONE:    "1",
+	ZERO:   "0",
+	EQ:     "=",
+	OR:     "+",
+	AND:    "\\cdot",
+	NEG:    "\\neg",
+	STAR:   "*",
+	ASSIGN: "\\leftarrow",
+	DUP:    "\\mathit{dup}",
+
+	BOT:    "\\bot",
+	SEQ:    "\\, ;\\, ",
+	RECV:   "\\, ?\\, ",
+	SEND:   "\\, !\\, ",
+	PAR:    "\\, \\|\\, ",
+	DEF:    "\\triangleq",
+	NONDET: "\\, \\oplus\\,",
+}
+
 type LatexEncoder struct {
 	sym             SymbolEncoding
 	proactiveSwitch bool
+	compactPolicies bool
+	linkTerms       bool
+	optimize        bool
+	standalone      bool
+	title           string
+
+	optimizer *convert.FlowTableOptimizer // set while encoding a network with optimised flow tables
+}
+
+type LatexEncoderOptions struct {
+	ProactiveSwitch bool
+	// encode each flow table as one factored NetKAT policy instead of one branch per flow rule
+	CompactPolicies bool
+	/*
+		Restrict the flow rules to their switch with a test on the 'sw' field and add the
+		topology as NetKAT link terms, together with the network term (policy · topology)*
+	*/
+	LinkTerms bool
+	// encode the flow tables as compressed, prioritised rule lists, see convert.FlowTableOptimizer
+	OptimizeFlowTables bool
+	/*
+		Write a complete LaTeX document: a preamble with macros for the operators, a drawing
+		of the topology, tables of the hosts and controllers and the program, see encodeDocumentHead
+	*/
+	Standalone bool
+	Title      string // title of the standalone document, e.g. the name of the topology
 }
 
 func NewLatexEncoder(proactiveSwitch bool) LatexEncoder {
+	return NewLatexEncoderWithOptions(LatexEncoderOptions{ProactiveSwitch: proactiveSwitch})
+}
+
+func NewLatexEncoderWithOptions(opts LatexEncoderOptions) LatexEncoder {
+	sym := LATEX_SYMBOLS
+	if opts.Standalone {
+		sym = LATEX_MACRO_SYMBOLS
+	}
+
 	return LatexEncoder{
-		sym: SymbolEncoding{
-			ONE:    "1",
-			ZERO:   "0",
-			EQ:     "=",
-			OR:     "+",
-			AND:    "\\cdot",
-			NEG:    "\\neg",
-			STAR:   "*",
-			ASSIGN: "\\leftarrow",
-
-			BOT:    "\\bot",
-			SEQ:    "\\, ;\\, ",
-			RECV:   "\\, ?\\, ",
-			SEND:   "\\, !\\, ",
-			PAR:    "\\, \\|\\, ",
-			DEF:    "\\triangleq",
-			NONDET: "\\, \\oplus\\,",
-		},
-		proactiveSwitch: proactiveSwitch,
+		sym:             sym,
+		proactiveSwitch: opts.ProactiveSwitch,
+		compactPolicies: opts.CompactPolicies,
+		linkTerms:       opts.LinkTerms,
+		optimize:        opts.OptimizeFlowTables,
+		standalone:      opts.Standalone,
+		title:           opts.Title,
 	}
 }
 
@@ -59,35 +109,62 @@ func (f *LatexEncoder) ProactiveSwitch() bool {
 	return f.proactiveSwitch
 }
 
+// Returns the encoding of the network, see EncodeTo
 func (f *LatexEncoder) Encode(n *convert.Network) (string, error) {
+	var sb strings.Builder
+	err := f.EncodeTo(n, &sb)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+/*
+Writes the encoding of the network to w and splits it into pages while writing. The terms of
+the switches and controllers are written one at a time, as soon as they are encoded. The
+document head, the topology and network terms and the SDN term describe the whole network and
+are built as a whole before they are written, and the flow table optimiser computes the
+reachability of the whole network before the first term is written.
+*/
+func (f *LatexEncoder) EncodeTo(n *convert.Network, w io.Writer) error {
 	if n == nil {
-		return "", errors.New("Received nil network!")
+		return errors.New("Received nil network!")
 	}
 
-	fmtSwitches, nonEmptySwitches := f.encodeSwitches(n.Switches())
-	fmtControllers, usedControllers := f.encodeControllers(n.Controllers())
-	arrayBlockStr := fmtSwitches + fmtControllers + f.encodeSDNTerm(
-		nonEmptySwitches,
-		usedControllers,
-	)
-	pages := sliceContent(arrayBlockStr, LINES_PER_PAGE, NEW_LN)
+	f.Prepare(n)
+	if f.standalone {
+		_, err := io.WriteString(w, f.encodeDocumentHead(n))
+		if err != nil {
+			return err
+		}
+	}
 
-	var sb strings.Builder
-	sep := ""
-	for _, page := range pages {
-		sb.WriteString(sep)
-		sb.WriteString(BEGIN_EQ_ARRAY)
-		sb.WriteString(page)
-		sb.WriteString(END_EQ_ARRAY)
-		sep = NEW_PAGE
+	pw := newPageWriter(w, LINES_PER_PAGE)
+	nonEmptySwitches := f.encodeSwitches(n.Switches(), pw)
+	usedControllers := f.encodeControllers(n.Controllers(), pw)
+	if f.linkTerms {
+		pw.WriteString(f.encodeNetKATModel(n))
 	}
+	pw.WriteString(f.encodeSDNTerm(nonEmptySwitches, usedControllers))
 
-	return sb.String(), nil
+	err := pw.Close()
+	if err == nil && f.standalone {
+		_, err = io.WriteString(w, LATEX_DOCUMENT_TAIL)
+	}
+	return err
 }
 
-func (f *LatexEncoder) encodeSwitches(switches []*convert.Switch) (string, []*convert.Switch) {
+// Prepares the encoder for the flow tables of the network, see FlowTablePolicies. EncodeTo calls it itself.
+func (f *LatexEncoder) Prepare(n *convert.Network) {
+	f.optimizer = nil
+	if f.optimize {
+		f.optimizer = convert.NewFlowTableOptimizer(n, f.linkTerms)
+	}
+}
+
+// writes the terms of the switches and returns the switches whose term is not empty
+func (f *LatexEncoder) encodeSwitches(switches []*convert.Switch, pw *pageWriter) []*convert.Switch {
 	nonEmptySwitches := []*convert.Switch{}
-	var sb strings.Builder
 
 	for _, sw := range switches {
 		c := sw.Controller()
@@ -98,8 +175,8 @@ func (f *LatexEncoder) encodeSwitches(switches []*convert.Switch) (string, []*co
 
 		swStr := f.encodeSwitch(*sw, willReceiveUpdate)
 		if swStr != "" {
-			sb.WriteString(swStr)
-			sb.WriteString(NEW_LN)
+			pw.WriteString(swStr)
+			pw.WriteString(NEW_LN)
 			nonEmptySwitches = append(nonEmptySwitches, sw)
 		}
 
@@ -109,21 +186,21 @@ func (f *LatexEncoder) encodeSwitches(switches []*convert.Switch) (string, []*co
 
 		// TODO This can be merged in the encodeSwitch function
 		newSwName := f.encodeSwitchName(*sw, true)
-		updatedSwStrs := f.encodeNetKATPolicies(newFlowTable.ToNetKATPolicies(), newSwName)
+		updatedSwStrs := f.encodeFlowTable(newFlowTable, sw.TopoNode().ID(), newSwName)
 		if len(updatedSwStrs) != 0 {
 			fmtNewSw := f.joinNonDetThridColumn(updatedSwStrs)
-			sb.WriteString(fmt.Sprintf("%s & %s & %s", newSwName, f.sym.DEF, fmtNewSw))
-			sb.WriteString(NEW_LN + NEW_LN)
+			pw.WriteString(fmt.Sprintf("%s & %s & %s", newSwName, f.sym.DEF, fmtNewSw))
+			pw.WriteString(NEW_LN + NEW_LN)
 		}
 	}
 
-	return sb.String(), nonEmptySwitches
+	return nonEmptySwitches
 }
 
 func (f *LatexEncoder) encodeSwitch(sw convert.Switch, canBeEmpty bool) string {
 	swName := f.encodeSwitchName(sw, false)
 
-	fmtFlowRules := f.encodeNetKATPolicies(sw.FlowTable().ToNetKATPolicies(), swName)
+	fmtFlowRules := f.encodeFlowTable(sw.FlowTable(), sw.TopoNode().ID(), swName)
 
 	if len(fmtFlowRules) == 0 {
 		if !canBeEmpty {
@@ -140,15 +217,76 @@ func (f *LatexEncoder) encodeSwitch(sw convert.Switch, canBeEmpty bool) string {
 	return fmt.Sprintf("%s & %s & %s %s", swName, f.sym.DEF, fmtSw, NEW_LN)
 }
 
-func (f *LatexEncoder) encodeNetKATPolicies(
-	policies []*convert.SimpleNetKATPolicy,
-	swName string,
-) []string {
+// returns the flow table as NetKAT policies, one per branch of the switch term
+func (f *LatexEncoder) encodeFlowTable(ft *convert.FlowTable, nodeId int64, swName string) []string {
+	policies := f.FlowTablePolicies(ft, nodeId)
+	if !f.compactPolicies || len(policies) == 0 {
+		return f.encodeNetKATPolicies(policies, swName)
+	}
+	return []string{breakColumn(f.encodeNetKATPolicies(policies, swName)[0])}
+}
+
+/*
+Returns the NetKAT policies of the branches of the term of a switch with the given flow table,
+as EncodeTo writes them: one per flow rule or, with compact policies, one for the whole flow
+table. The flow tables are only optimised after Prepare was called for their network.
+*/
+func (f *LatexEncoder) FlowTablePolicies(ft *convert.FlowTable, nodeId int64) []netkat.Policy {
+	if f.optimizer != nil {
+		ft = f.optimizer.Optimize(nodeId, ft)
+	}
+
+	if !f.compactPolicies {
+		policies := []netkat.Policy{}
+		if ft.HasPriorityRules() {
+			policies = ft.ToPrioritisedNetKATPolicies()
+		} else {
+			for _, policy := range ft.ToNetKATPolicies() {
+				policies = append(policies, policy.ToPolicy())
+			}
+		}
+
+		for i := range policies {
+			policies[i] = f.restrictToSwitch(policies[i], nodeId)
+		}
+		return policies
+	}
+
+	policy := ft.ToNetKATPolicy()
+	if _, isZero := policy.(netkat.Zero); isZero {
+		return []netkat.Policy{}
+	}
+	return []netkat.Policy{f.restrictToSwitch(policy, nodeId)}
+}
+
+func (f *LatexEncoder) restrictToSwitch(policy netkat.Policy, nodeId int64) netkat.Policy {
+	if !f.linkTerms {
+		return policy
+	}
+	return netkat.NewSeq(convert.SwitchTest(nodeId), policy)
+}
+
+// returns the definitions of the topology term and of the network term (policy · topology)*
+func (f *LatexEncoder) encodeNetKATModel(n *convert.Network) string {
+	var sb strings.Builder
+
+	topoStr := breakColumn(f.sym.FormatPolicy(n.TopologyPolicy()))
+	sb.WriteString(fmt.Sprintf("%s & %s & %s %s", TOPOLOGY_TERM_NAME, f.sym.DEF, topoStr, NEW_LN))
+	sb.WriteString(NEW_LN)
+
+	netStr := breakColumn(f.sym.FormatPolicy(n.NetKATModel()))
+	sb.WriteString(fmt.Sprintf("%s & %s & %s %s", NETWORK_TERM_NAME, f.sym.DEF, netStr, NEW_LN))
+	sb.WriteString(NEW_LN)
+
+	return sb.String()
+}
+
+func (f *LatexEncoder) encodeNetKATPolicies(policies []netkat.Policy, swName string) []string {
 	fmtFlowRules := []string{}
 	for _, policy := range policies {
 		fmtFlowRules = append(fmtFlowRules, fmt.Sprintf(
 			"(%s) %s %s",
-			policy.ToString(f.sym.AND, f.sym.EQ, f.sym.ASSIGN),
+			f.sym.FormatPolicy(policy),
 			f.sym.SEQ, swName,
 		))
 	}
@@ -205,7 +343,7 @@ func (f *LatexEncoder) encodeSDNTerm(sws []*convert.Switch, c []*convert.Control
 		sb.WriteString(prefix + fmt.Sprintf("%s%d", CONTROLLER_BASE_NAME, c.ID()))
 	}
 
-	return fmt.Sprintf("SDN & %s & %s", f.sym.DEF, breakColumn(sb.String()))
+	return fmt.Sprintf("%s & %s & %s", SDN_TERM_NAME, f.sym.DEF, breakColumn(sb.String()))
 }
 
 func (f *LatexEncoder) encodeSwitchName(sw convert.Switch, isNew bool) string {
@@ -216,29 +354,27 @@ func (f *LatexEncoder) encodeSwitchName(sw convert.Switch, isNew bool) string {
 	return name
 }
 
-func (f *LatexEncoder) encodeControllers(
-	controllers []*convert.Controller,
-) (string, []*convert.Controller) {
+// writes the terms of the controllers and returns the controllers whose term is not empty
+func (f *LatexEncoder) encodeControllers(controllers []*convert.Controller, pw *pageWriter) []*convert.Controller {
 	usedControllers := []*convert.Controller{}
-	var sb strings.Builder
 
 	for _, c := range controllers {
 		cStr := f.encodeController(c)
 		if cStr != "" {
-			sb.WriteString(cStr)
-			sb.WriteString(NEW_LN)
+			pw.WriteString(cStr)
+			pw.WriteString(NEW_LN)
 			usedControllers = append(usedControllers, c)
 		}
 	}
 
-	return sb.String(), usedControllers
+	return usedControllers
 }
 
 func (f *LatexEncoder) encodeController(c *convert.Controller) string {
 	fmtCommStrs := []string{}
 	cName := fmt.Sprintf("%s%d", CONTROLLER_BASE_NAME, c.ID())
 
-	for key := range c.NewFlowTables() {
+	for _, key := range slices.Sorted(maps.Keys(c.NewFlowTables())) {
 		commStr := f.encodeCommunication(cName, key, false)
 		fmtCommStrs = append(fmtCommStrs, commStr)
 	}
@@ -257,24 +393,6 @@ func (f *LatexEncoder) joinNonDetThridColumn(strs []string) string {
 	return strings.Join(strs, nonDetSep)
 }
 
-/*
-Breaks the given string into slices of 'linesPerPage' lines based on the
-given separator 'sep'.
-*/
-func sliceContent(str string, linesPerPage int, sep string) []string {
-	contentLines := strings.SplitAfter(str, sep)
-	pages := []string{}
-
-	for i := range (len(contentLines) / linesPerPage) + 1 {
-		start := i * linesPerPage
-		end := min(start+linesPerPage, len(contentLines))
-		page := strings.Join(contentLines[start:end], "")
-		pages = append(pages, page)
-	}
-
-	return pages
-}
-
 // assumes the given string is in the third column (considered the last column) of the array environment
 func breakColumn(line string) string {
 	divisions, err := divideLatexString(line, THIRD_COL_MAX_LEN)
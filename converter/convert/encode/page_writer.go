@@ -0,0 +1,75 @@
+package encode
+
+import (
+	"io"
+	"strings"
+)
+
+/*
+Writes the lines of the program to an io.Writer inside array environments, and starts a new
+page with a new environment every 'linesPerPage' lines. The lines are counted while they are
+written, so the program does not have to be kept in memory to split it into pages. Lines end
+with NEW_LN, which is expected to be written as a whole, i.e. not split over two writes.
+
+Like bufio.Writer, the first error is kept and the following writes are skipped; Close returns it.
+*/
+type pageWriter struct {
+	w            io.Writer
+	linesPerPage int
+	lines        int  // nr of complete lines written so far
+	inLine       bool // whether the current line already has content
+	started      bool // whether the first environment was opened
+	err          error
+}
+
+func newPageWriter(w io.Writer, linesPerPage int) *pageWriter {
+	return &pageWriter{w: w, linesPerPage: max(linesPerPage, 1)}
+}
+
+func (pw *pageWriter) WriteString(s string) {
+	for len(s) > 0 && pw.err == nil {
+		if !pw.inLine {
+			pw.startLine()
+		}
+
+		end := strings.Index(s, NEW_LN)
+		if end < 0 {
+			pw.write(s)
+			return
+		}
+
+		end += len(NEW_LN)
+		pw.write(s[:end])
+		pw.lines++
+		pw.inLine = false
+		s = s[end:]
+	}
+}
+
+// opens the first environment, or a new page if the current one is full
+func (pw *pageWriter) startLine() {
+	switch {
+	case !pw.started:
+		pw.write(BEGIN_EQ_ARRAY)
+		pw.started = true
+	case pw.lines%pw.linesPerPage == 0:
+		pw.write(END_EQ_ARRAY + NEW_PAGE + BEGIN_EQ_ARRAY)
+	}
+	pw.inLine = true
+}
+
+func (pw *pageWriter) write(s string) {
+	if pw.err == nil {
+		_, pw.err = io.WriteString(pw.w, s)
+	}
+}
+
+// Closes the last environment and returns the first error that occurred while writing
+func (pw *pageWriter) Close() error {
+	if !pw.started {
+		pw.write(BEGIN_EQ_ARRAY)
+		pw.started = true
+	}
+	pw.write(END_EQ_ARRAY)
+	return pw.err
+}
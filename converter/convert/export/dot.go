@@ -0,0 +1,153 @@
+package export
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+const (
+	PATH_BEFORE_COLOR = "blue"
+	PATH_AFTER_COLOR  = "red"
+)
+
+// fill colours of the controller domains, reused when there are more controllers
+var DOMAIN_COLORS = []string{
+	"lightblue", "lightgoldenrod", "palegreen", "lightpink", "lightsalmon", "plum", "lightcyan", "wheat",
+}
+
+/*
+Writes the network as a Graphviz DOT graph: switches, links labelled with the (global) port
+numbers at both ends, hosts and the controller domains as coloured clusters. If PathDest is
+the id of a host, the forwarding paths towards it are overlaid as arrows: in PATH_BEFORE_COLOR
+following the current flow tables and in PATH_AFTER_COLOR following the flow tables after the
+controllers' updates. Set PathDest to convert.ANY_HOST for no overlay.
+*/
+type DOTExporter struct {
+	PathDest int64
+}
+
+func NewDOTExporter() *DOTExporter {
+	return &DOTExporter{PathDest: convert.ANY_HOST}
+}
+
+func (e *DOTExporter) Export(n *convert.Network, w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("graph network {\n")
+	sb.WriteString("\tnode [shape=ellipse, style=filled, fillcolor=white];\n")
+
+	inDomain := make(map[int64]bool)
+	for i, c := range n.Controllers() {
+		sb.WriteString(fmt.Sprintf("\tsubgraph cluster_c%d {\n", c.ID()))
+		sb.WriteString(fmt.Sprintf("\t\tlabel=\"controller %d\";\n", c.ID()))
+		sb.WriteString(fmt.Sprintf(
+			"\t\tstyle=filled; color=\"%s\";\n",
+			DOMAIN_COLORS[i%len(DOMAIN_COLORS)],
+		))
+		for _, sw := range sortedSwitches(c.Switches()) {
+			writeDOTSwitch(&sb, "\t\t", n, sw)
+			inDomain[sw.TopoNode().ID()] = true
+		}
+		sb.WriteString("\t}\n")
+	}
+
+	for _, sw := range sortedSwitches(n.Switches()) {
+		if !inDomain[sw.TopoNode().ID()] {
+			writeDOTSwitch(&sb, "\t", n, sw)
+		}
+	}
+
+	for _, link := range n.Links() {
+		sb.WriteString(fmt.Sprintf(
+			"\t\"%s\" -- \"%s\" [taillabel=\"%d\", headlabel=\"%d\"];\n",
+			SwitchName(link.TopoEdge().From().ID()),
+			SwitchName(link.TopoEdge().To().ID()),
+			link.FromPort(),
+			link.ToPort(),
+		))
+	}
+
+	connected := make(map[int64]bool)
+	for _, h := range n.Hosts() {
+		connected[h.ID()] = true
+	}
+	for _, h := range n.CreatedHosts() {
+		style := "solid"
+		if !connected[h.ID()] {
+			// only reachable after a controller update
+			style = "dashed"
+		}
+		sb.WriteString(fmt.Sprintf(
+			"\t\"%s\" [shape=box, style=\"filled,%s\", label=\"%s\\n%s\"];\n",
+			HostName(h.ID()),
+			style,
+			HostName(h.ID()),
+			h.IP(),
+		))
+		sb.WriteString(fmt.Sprintf(
+			"\t\"%s\" -- \"%s\" [headlabel=\"%d\", style=%s];\n",
+			HostName(h.ID()),
+			SwitchName(h.Switch().TopoNode().ID()),
+			h.SwitchPort(),
+			style,
+		))
+	}
+
+	if e.PathDest != convert.ANY_HOST {
+		writeDOTPath(&sb, n, e.PathDest, false)
+		writeDOTPath(&sb, n, e.PathDest, true)
+	}
+
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func sortedSwitches(switches []*convert.Switch) []*convert.Switch {
+	sorted := slices.Clone(switches)
+	slices.SortFunc(sorted, func(a, b *convert.Switch) int {
+		return cmp.Compare(a.TopoNode().ID(), b.TopoNode().ID())
+	})
+	return sorted
+}
+
+func writeDOTSwitch(sb *strings.Builder, indent string, n *convert.Network, sw *convert.Switch) {
+	nodeId := sw.TopoNode().ID()
+	label := SwitchName(nodeId)
+	if topoLabel, exists := n.Topology().NodeAttr(nodeId, util.LABEL_ATTR); exists {
+		label += "\\n" + strings.ReplaceAll(topoLabel, "\"", "\\\"")
+	}
+	sb.WriteString(fmt.Sprintf("%s\"%s\" [label=\"%s\"];\n", indent, SwitchName(nodeId), label))
+}
+
+// draws an arrow for every hop of the packets towards the destination host
+func writeDOTPath(sb *strings.Builder, n *convert.Network, destHostId int64, afterUpdate bool) {
+	color, label := PATH_BEFORE_COLOR, "before"
+	if afterUpdate {
+		color, label = PATH_AFTER_COLOR, "after"
+	}
+
+	for _, h := range forwardingHops(n, destHostId, afterUpdate) {
+		var target string
+		if h.toSw != nil {
+			target = SwitchName(h.toSw.TopoNode().ID())
+		} else {
+			target = HostName(h.toHost.ID())
+		}
+
+		sb.WriteString(fmt.Sprintf(
+			"\t\"%s\" -- \"%s\" [dir=forward, color=%s, penwidth=2, constraint=false, "+
+				"label=\"%s\", fontcolor=%s];\n",
+			SwitchName(h.from.TopoNode().ID()),
+			target,
+			color,
+			label,
+			color,
+		))
+	}
+}
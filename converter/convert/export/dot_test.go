@@ -0,0 +1,42 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDOTExporter(t *testing.T) {
+	graph := export(t, NewDOTExporter(), tinyNetwork(t))
+
+	checkContains(t, graph,
+		"\tsubgraph cluster_c0 {\n"+
+			"\t\tlabel=\"controller 0\";\n"+
+			"\t\tstyle=filled; color=\"lightblue\";\n"+
+			"\t\t\"s1\" [label=\"s1\\nAmsterdam\"];\n"+
+			"\t}\n"+
+			"\t\"s2\" [label=\"s2\\nUtrecht\"];\n",
+		"\t\"s1\" -- \"s2\" [taillabel=\"0\", headlabel=\"1\"];\n",
+		"\t\"h1\" [shape=box, style=\"filled,solid\", label=\"h1\\n10.0.0.2\"];\n"+
+			"\t\"h1\" -- \"s2\" [headlabel=\"3\", style=solid];\n",
+	)
+	if strings.Contains(graph, "dir=forward") {
+		t.Error("no path must be drawn without a destination")
+	}
+}
+
+func TestDOTExporterPaths(t *testing.T) {
+	graph := export(t, &DOTExporter{PathDest: 1}, tinyNetwork(t))
+
+	hop := func(from, to, color, label string) string {
+		return "\t\"" + from + "\" -- \"" + to + "\" [dir=forward, color=" + color +
+			", penwidth=2, constraint=false, label=\"" + label + "\", fontcolor=" + color + "];\n"
+	}
+	// after the update, s1 sends the packets for h1 back to h0
+	checkContains(t, graph,
+		hop("s1", "s2", PATH_BEFORE_COLOR, "before")+hop("s2", "h1", PATH_BEFORE_COLOR, "before")+
+			hop("s1", "h0", PATH_AFTER_COLOR, "after")+hop("s2", "h1", PATH_AFTER_COLOR, "after"),
+	)
+	if strings.Count(graph, "dir=forward") != 4 {
+		t.Errorf("got %d hops, want 4", strings.Count(graph, "dir=forward"))
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Package export writes generated networks in the formats of other tools, e.g. OpenFlow rules
+that can be installed in Open vSwitch, so the DyNetKAT model can be compared with an emulation.
+*/
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+)
+
+const SW_BASE_NAME = "s"
+
+type NetworkExporter interface {
+	Export(n *convert.Network, w io.Writer) error
+}
+
+/*
+Returns the name of the switch at the given topology node. Names start from 's1', since
+emulators such as Mininet derive the datapath id from the number and 0 is not a valid one.
+*/
+func SwitchName(nodeId int64) string {
+	return fmt.Sprintf("%s%d", SW_BASE_NAME, DatapathId(nodeId))
+}
+
+func DatapathId(nodeId int64) int64 {
+	return nodeId + 1
+}
+
+/*
+Maps the globally unique ports of the switch to the port numbers used on the switch itself,
+which start from 1 and follow the order of the global port numbers.
+*/
+func LocalPorts(sw *convert.Switch) map[int64]int64 {
+	localPorts := make(map[int64]int64)
+	for i, port := range sw.Ports() {
+		localPorts[port] = int64(i + 1)
+	}
+	return localPorts
+}
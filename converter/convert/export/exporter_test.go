@@ -0,0 +1,80 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+/*
+Two switches joined by a link, with a host on each of them, that forward the HTTPS packets
+between the hosts. The controller of the first switch updates it to send the packets for h1
+back to h0 and to drop the packets for h0.
+*/
+func tinyNetworkJSON() convert.NetworkJSON {
+	https := map[string]int64{"tcpDst": 443}
+	return convert.NetworkJSON{
+		Version:  convert.NETWORK_JSON_VERSION,
+		Metadata: convert.MetadataJSON{Name: "tiny", HeaderSchema: "tcpDst=443"},
+		Switches: []convert.SwitchJSON{
+			{
+				NodeId: 0,
+				Attrs:  util.Attributes{util.LABEL_ATTR: "Amsterdam", util.LONGITUDE_ATTR: "4.9", util.LATITUDE_ATTR: "52.4"},
+				FlowTable: convert.FlowTableJSON{Entries: []convert.FlowRuleJSON{
+					{Dst: 0, InPort: 0, Headers: https, OutPorts: []int64{2}},
+					{Dst: 1, InPort: 2, Headers: https, OutPorts: []int64{0}},
+				}},
+				NewFlowTable: &convert.FlowTableJSON{
+					Entries: []convert.FlowRuleJSON{{Dst: 1, InPort: 2, Headers: https, OutPorts: []int64{2}}},
+					Rules:   []convert.FlowRuleJSON{{Priority: 1, Dst: 0, InPort: 0, Headers: https, OutPorts: []int64{}}},
+				},
+			},
+			{
+				NodeId: 1,
+				Attrs:  util.Attributes{util.LABEL_ATTR: "Utrecht", util.LONGITUDE_ATTR: "5.1", util.LATITUDE_ATTR: "52.1"},
+				FlowTable: convert.FlowTableJSON{Entries: []convert.FlowRuleJSON{
+					{Dst: 0, InPort: 3, Headers: https, OutPorts: []int64{1}},
+					{Dst: 1, InPort: 1, Headers: https, OutPorts: []int64{3}},
+				}},
+			},
+		},
+		Links: []convert.LinkJSON{{From: 0, To: 1, FromPort: 0, ToPort: 1}},
+		Hosts: []convert.HostJSON{
+			{Id: 0, Switch: 0, Port: 2, Connected: true},
+			{Id: 1, Switch: 1, Port: 3, Connected: true},
+		},
+		Controllers: []convert.ControllerJSON{{Id: 0, Switches: []int64{0}}},
+	}
+}
+
+func tinyNetwork(t *testing.T) *convert.Network {
+	return tinyNetworkFromJSON(t, tinyNetworkJSON())
+}
+
+func tinyNetworkFromJSON(t *testing.T, netJSON convert.NetworkJSON) *convert.Network {
+	n, err := convert.NewNetworkFromJSON(netJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func export(t *testing.T, exporter NetworkExporter, n *convert.Network) string {
+	var sb strings.Builder
+	if err := exporter.Export(n, &sb); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+// checks that the output contains every expected part
+func checkContains(t *testing.T, output string, parts ...string) {
+	t.Helper()
+	for _, part := range parts {
+		if !strings.Contains(output, part) {
+			t.Errorf("the output does not contain\n%s\n\n%s", part, output)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package export
+
+import (
+	"cmp"
+	"encoding/json"
+	"io"
+	"math"
+	"slices"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+// distance in degrees between a switch and the hosts around it on the map
+const HOST_OFFSET = 0.05
+
+type geoPoint [2]float64 // longitude, latitude
+
+type geoGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+type geoFeature struct {
+	Type       string         `json:"type"`
+	Geometry   geoGeometry    `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type geoFeatureCollection struct {
+	Type     string       `json:"type"`
+	Features []geoFeature `json:"features"`
+}
+
+/*
+Writes the network as a GeoJSON FeatureCollection, using the Longitude and Latitude attributes
+of the topology nodes (e.g. of the Topology Zoo). Switches and hosts are points, with the hosts
+placed around their switch, links are lines and the controller domains are the convex hulls of
+their switches. The forwarding paths towards every host are line features, both following the
+current flow tables (state 'before') and after the controllers' updates (state 'after').
+Switches without coordinates are left out, together with their links, hosts and path hops.
+*/
+type GeoJSONExporter struct{}
+
+func (e *GeoJSONExporter) Export(n *convert.Network, w io.Writer) error {
+	swPoints := make(map[int64]geoPoint)
+	for _, sw := range n.Switches() {
+		nodeId := sw.TopoNode().ID()
+		if lon, lat, exists := n.Topology().Coordinates(nodeId); exists {
+			swPoints[nodeId] = geoPoint{lon, lat}
+		}
+	}
+	hostPoints := placeHosts(n.CreatedHosts(), swPoints)
+
+	features := []geoFeature{}
+	for _, sw := range sortedSwitches(n.Switches()) {
+		nodeId := sw.TopoNode().ID()
+		point, exists := swPoints[nodeId]
+		if !exists {
+			continue
+		}
+
+		props := map[string]any{"type": "switch", "name": SwitchName(nodeId), "nodeId": nodeId}
+		if label, exists := n.Topology().NodeAttr(nodeId, util.LABEL_ATTR); exists {
+			props["label"] = label
+		}
+		if c := controllerOf(n, sw); c != nil {
+			props["controller"] = c.ID()
+		}
+		features = append(features, newGeoFeature("Point", point, props))
+	}
+
+	for _, link := range n.Links() {
+		from, to := link.TopoEdge().From().ID(), link.TopoEdge().To().ID()
+		fromPoint, fromExists := swPoints[from]
+		toPoint, toExists := swPoints[to]
+		if !fromExists || !toExists {
+			continue
+		}
+
+		features = append(features, newGeoFeature("LineString", []geoPoint{fromPoint, toPoint}, map[string]any{
+			"type":     "link",
+			"from":     SwitchName(from),
+			"to":       SwitchName(to),
+			"fromPort": link.FromPort(),
+			"toPort":   link.ToPort(),
+		}))
+	}
+
+	connected := make(map[int64]bool)
+	for _, h := range n.Hosts() {
+		connected[h.ID()] = true
+	}
+	for _, h := range n.CreatedHosts() {
+		point, exists := hostPoints[h.ID()]
+		if !exists {
+			continue
+		}
+
+		swName := SwitchName(h.Switch().TopoNode().ID())
+		features = append(features, newGeoFeature("Point", point, map[string]any{
+			"type":      "host",
+			"name":      HostName(h.ID()),
+			"ip":        h.IP(),
+			"mac":       h.MAC(),
+			"switch":    swName,
+			"connected": connected[h.ID()],
+		}))
+		features = append(features, newGeoFeature(
+			"LineString",
+			[]geoPoint{point, swPoints[h.Switch().TopoNode().ID()]},
+			map[string]any{
+				"type":      "hostLink",
+				"host":      HostName(h.ID()),
+				"switch":    swName,
+				"port":      h.SwitchPort(),
+				"connected": connected[h.ID()],
+			},
+		))
+	}
+
+	for _, c := range n.Controllers() {
+		points := []geoPoint{}
+		for _, sw := range c.Switches() {
+			if point, exists := swPoints[sw.TopoNode().ID()]; exists {
+				points = append(points, point)
+			}
+		}
+		if len(points) == 0 {
+			continue
+		}
+
+		props := map[string]any{"type": "domain", "controller": c.ID(), "switches": len(c.Switches())}
+		hull := convexHull(points)
+		if len(hull) < 3 {
+			features = append(features, newGeoFeature("MultiPoint", hull, props))
+		} else {
+			// the ring of a polygon ends at its first point
+			ring := append(hull, hull[0])
+			features = append(features, newGeoFeature("Polygon", [][]geoPoint{ring}, props))
+		}
+	}
+
+	for _, dest := range n.CreatedHosts() {
+		for _, afterUpdate := range []bool{false, true} {
+			lines := [][]geoPoint{}
+			for _, h := range forwardingHops(n, dest.ID(), afterUpdate) {
+				from, fromExists := swPoints[h.from.TopoNode().ID()]
+				var to geoPoint
+				var toExists bool
+				if h.toSw != nil {
+					to, toExists = swPoints[h.toSw.TopoNode().ID()]
+				} else {
+					to, toExists = hostPoints[h.toHost.ID()]
+				}
+				if fromExists && toExists {
+					lines = append(lines, []geoPoint{from, to})
+				}
+			}
+			if len(lines) == 0 {
+				continue
+			}
+
+			state := "before"
+			if afterUpdate {
+				state = "after"
+			}
+			features = append(features, newGeoFeature("MultiLineString", lines, map[string]any{
+				"type":  "path",
+				"dest":  HostName(dest.ID()),
+				"state": state,
+			}))
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(geoFeatureCollection{Type: "FeatureCollection", Features: features})
+}
+
+func newGeoFeature(geometryType string, coordinates any, props map[string]any) geoFeature {
+	return geoFeature{
+		Type:       "Feature",
+		Geometry:   geoGeometry{Type: geometryType, Coordinates: coordinates},
+		Properties: props,
+	}
+}
+
+func controllerOf(n *convert.Network, sw *convert.Switch) *convert.Controller {
+	for _, c := range n.Controllers() {
+		if slices.Contains(c.Switches(), sw) {
+			return c
+		}
+	}
+	return nil
+}
+
+// places the hosts of each switch evenly on a circle of HOST_OFFSET degrees around it
+func placeHosts(hosts []*convert.Host, swPoints map[int64]geoPoint) map[int64]geoPoint {
+	swHosts := make(map[int64][]*convert.Host)
+	for _, h := range hosts {
+		nodeId := h.Switch().TopoNode().ID()
+		swHosts[nodeId] = append(swHosts[nodeId], h)
+	}
+
+	hostPoints := make(map[int64]geoPoint)
+	for nodeId, hosts := range swHosts {
+		center, exists := swPoints[nodeId]
+		if !exists {
+			continue
+		}
+		for i, h := range hosts {
+			angle := 2 * math.Pi * float64(i) / float64(len(hosts))
+			hostPoints[h.ID()] = geoPoint{
+				center[0] + HOST_OFFSET*math.Cos(angle),
+				center[1] + HOST_OFFSET*math.Sin(angle),
+			}
+		}
+	}
+	return hostPoints
+}
+
+// Returns the convex hull of the points in counter-clockwise order (Andrew's monotone chain)
+func convexHull(points []geoPoint) []geoPoint {
+	sorted := slices.Clone(points)
+	slices.SortFunc(sorted, func(a, b geoPoint) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+	sorted = slices.Compact(sorted)
+	if len(sorted) < 3 {
+		return sorted
+	}
+
+	cross := func(o, a, b geoPoint) float64 {
+		return (a[0]-o[0])*(b[1]-o[1]) - (a[1]-o[1])*(b[0]-o[0])
+	}
+
+	hull := []geoPoint{}
+	for _, p := range sorted {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(sorted) - 2; i >= 0; i-- {
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], sorted[i]) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, sorted[i])
+	}
+
+	// the last point is the first one again
+	return hull[:len(hull)-1]
+}
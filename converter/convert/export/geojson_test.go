@@ -0,0 +1,112 @@
+package export
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+type decodedGeoFeature struct {
+	Geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// returns the features of the collection with the given 'type' property
+func exportGeoFeatures(t *testing.T, exportedJSON string) map[string][]decodedGeoFeature {
+	var collection struct {
+		Type     string              `json:"type"`
+		Features []decodedGeoFeature `json:"features"`
+	}
+	if err := json.Unmarshal([]byte(exportedJSON), &collection); err != nil {
+		t.Fatal(err)
+	}
+	if collection.Type != "FeatureCollection" {
+		t.Fatalf("got type '%s'", collection.Type)
+	}
+
+	features := make(map[string][]decodedGeoFeature)
+	for _, f := range collection.Features {
+		kind := f.Properties["type"].(string)
+		features[kind] = append(features[kind], f)
+	}
+	return features
+}
+
+func pointOf(t *testing.T, f decodedGeoFeature) geoPoint {
+	var point geoPoint
+	if err := json.Unmarshal(f.Geometry.Coordinates, &point); err != nil {
+		t.Fatal(err)
+	}
+	return point
+}
+
+func closeTo(a, b geoPoint) bool {
+	return math.Abs(a[0]-b[0]) < 1e-9 && math.Abs(a[1]-b[1]) < 1e-9
+}
+
+func TestGeoJSONExporter(t *testing.T) {
+	features := exportGeoFeatures(t, export(t, &GeoJSONExporter{}, tinyNetwork(t)))
+
+	counts := map[string]int{"switch": 2, "link": 1, "host": 2, "hostLink": 2, "domain": 1, "path": 4}
+	for kind, count := range counts {
+		if len(features[kind]) != count {
+			t.Errorf("got %d features of type %s, want %d", len(features[kind]), kind, count)
+		}
+	}
+
+	s1 := features["switch"][0]
+	if s1.Properties["name"] != "s1" || s1.Properties["label"] != "Amsterdam" || s1.Properties["controller"] != 0.0 {
+		t.Errorf("got switch properties %v", s1.Properties)
+	}
+	if !closeTo(pointOf(t, s1), geoPoint{4.9, 52.4}) {
+		t.Errorf("got switch coordinates %v", pointOf(t, s1))
+	}
+	if _, inDomain := features["switch"][1].Properties["controller"]; inDomain {
+		t.Error("s2 has no controller")
+	}
+
+	// a single host is placed east of its switch
+	h0 := features["host"][0]
+	if h0.Properties["ip"] != "10.0.0.1" || !closeTo(pointOf(t, h0), geoPoint{4.9 + HOST_OFFSET, 52.4}) {
+		t.Errorf("got host %v at %v", h0.Properties, pointOf(t, h0))
+	}
+
+	// a domain of one switch is a point, not a polygon
+	if features["domain"][0].Geometry.Type != "MultiPoint" {
+		t.Errorf("got domain geometry %s", features["domain"][0].Geometry.Type)
+	}
+
+	// after the update, s1 drops the packets for h0, so only the hop from s2 to s1 remains
+	hopsNr := make(map[string]int)
+	for _, path := range features["path"] {
+		var lines [][]geoPoint
+		if err := json.Unmarshal(path.Geometry.Coordinates, &lines); err != nil {
+			t.Fatal(err)
+		}
+		hopsNr[path.Properties["dest"].(string)+" "+path.Properties["state"].(string)] = len(lines)
+	}
+	want := map[string]int{"h0 before": 2, "h0 after": 1, "h1 before": 2, "h1 after": 2}
+	for path, count := range want {
+		if hopsNr[path] != count {
+			t.Errorf("got %d hops for the path to %s, want %d", hopsNr[path], path, count)
+		}
+	}
+}
+
+func TestGeoJSONExporterSkipsSwitchesWithoutCoordinates(t *testing.T) {
+	netJSON := tinyNetworkJSON()
+	delete(netJSON.Switches[1].Attrs, util.LONGITUDE_ATTR)
+	features := exportGeoFeatures(t, export(t, &GeoJSONExporter{}, tinyNetworkFromJSON(t, netJSON)))
+
+	counts := map[string]int{"switch": 1, "link": 0, "host": 1, "hostLink": 1, "domain": 1, "path": 2}
+	for kind, count := range counts {
+		if len(features[kind]) != count {
+			t.Errorf("got %d features of type %s, want %d", len(features[kind]), kind, count)
+		}
+	}
+}
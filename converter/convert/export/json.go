@@ -0,0 +1,20 @@
+package export
+
+import (
+	"io"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+)
+
+/*
+Writes the whole network in its versioned JSON representation (see convert.NetworkJSON), which
+can be edited by hand or by other tools and loaded again with convert.ReadNetworkJSON, e.g. to
+encode it in other formats without generating the network again.
+*/
+type NetworkJSONExporter struct {
+	Name string // name of the network in the metadata
+}
+
+func (e *NetworkJSONExporter) Export(n *convert.Network, w io.Writer) error {
+	return convert.WriteNetworkJSON(n, e.Name, w)
+}
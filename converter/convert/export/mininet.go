@@ -0,0 +1,176 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+)
+
+const (
+	HOST_BASE_NAME  = "h"
+	HOST_IP_NETMASK = 16 // all the host addresses are in 10.0.0.0/16, see convert.HostIP
+)
+
+/*
+Writes a Mininet Python script that emulates the network: a switch per topology node, the links
+and hosts on the same ports as the OpenFlow exporters (see LocalPorts) and the static flow tables
+installed at start-up. The switches run without a controller. If UpdateDelay is positive, the
+script installs the controllers' updated flow tables after this delay. The delay can also be
+set when running the script, with '--update-after <seconds>'.
+*/
+type MininetExporter struct {
+	UpdateDelay time.Duration
+}
+
+func HostName(hostId int64) string {
+	return fmt.Sprintf("%s%d", HOST_BASE_NAME, hostId)
+}
+
+func (e *MininetExporter) Export(n *convert.Network, w io.Writer) error {
+	allFlows, err := networkFlows(n)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`#!/usr/bin/env python3
+"""
+Mininet emulation of a generated network. The switches run without a controller and start
+with the static flow tables; the controllers' updated flow tables are installed after the
+update delay, or with 'py apply_updates(net)' from the Mininet CLI.
+
+Usage: sudo python3 <script> [--update-after SECONDS] [--no-cli]
+"""
+
+import argparse
+import time
+
+from mininet.cli import CLI
+from mininet.log import info, setLogLevel
+from mininet.net import Mininet
+from mininet.node import OVSSwitch
+
+`)
+	sb.WriteString(fmt.Sprintf("UPDATE_DELAY = %g  # seconds, 0 to not apply the updates\n", e.UpdateDelay.Seconds()))
+	sb.WriteString(fmt.Sprintf("OF_VERSION = '%s'\n\n", OF_VERSION))
+
+	sb.WriteString("INITIAL_FLOWS = {\n")
+	for _, swFlows := range allFlows {
+		writePythonFlows(&sb, SwitchName(swFlows.sw.TopoNode().ID()), swFlows.flows)
+	}
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("UPDATED_FLOWS = {\n")
+	for _, swFlows := range allFlows {
+		if swFlows.hasUpdate {
+			writePythonFlows(&sb, SwitchName(swFlows.sw.TopoNode().ID()), swFlows.updatedFlows)
+		}
+	}
+	sb.WriteString("}\n\n\n")
+
+	sb.WriteString("def build(net):\n")
+	for _, swFlows := range allFlows {
+		nodeId := swFlows.sw.TopoNode().ID()
+		sb.WriteString(fmt.Sprintf(
+			"    net.addSwitch('%s', dpid='%016x', protocols=OF_VERSION, failMode='secure')\n",
+			SwitchName(nodeId),
+			DatapathId(nodeId),
+		))
+	}
+
+	localPorts := make(map[int64]map[int64]int64)
+	for _, swFlows := range allFlows {
+		localPorts[swFlows.sw.TopoNode().ID()] = swFlows.localPorts
+	}
+
+	for _, link := range n.Links() {
+		from, to := link.TopoEdge().From().ID(), link.TopoEdge().To().ID()
+		sb.WriteString(fmt.Sprintf(
+			"    net.addLink('%s', '%s', port1=%d, port2=%d)\n",
+			SwitchName(from),
+			SwitchName(to),
+			localPorts[from][link.FromPort()],
+			localPorts[to][link.ToPort()],
+		))
+	}
+
+	for _, h := range n.CreatedHosts() {
+		nodeId := h.Switch().TopoNode().ID()
+		sb.WriteString(fmt.Sprintf(
+			"    net.addHost('%s', ip='%s/%d', mac='%s')\n",
+			HostName(h.ID()),
+			h.IP(),
+			HOST_IP_NETMASK,
+			h.MAC(),
+		))
+
+		// hosts without flow rules do not have a port in the flow tables
+		portArg := ""
+		if port, exists := localPorts[nodeId][h.SwitchPort()]; exists {
+			portArg = fmt.Sprintf(", port2=%d", port)
+		}
+		sb.WriteString(fmt.Sprintf(
+			"    net.addLink('%s', '%s'%s)\n",
+			HostName(h.ID()),
+			SwitchName(nodeId),
+			portArg,
+		))
+	}
+
+	sb.WriteString(`
+
+def install_flows(net, flows):
+    for name, rules in flows.items():
+        switch = net.get(name)
+        switch.cmd('ovs-ofctl -O %s del-flows %s' % (OF_VERSION, name))
+        for rule in rules:
+            switch.cmd("ovs-ofctl -O %s add-flow %s '%s'" % (OF_VERSION, name, rule))
+
+
+def apply_updates(net):
+    info('*** Installing the updated flow tables\n')
+    install_flows(net, UPDATED_FLOWS)
+
+
+def main():
+    parser = argparse.ArgumentParser()
+    parser.add_argument('--update-after', type=float, default=UPDATE_DELAY,
+                        help='seconds after which the updated flow tables are installed, 0 to not install them')
+    parser.add_argument('--no-cli', action='store_true', help='exit instead of starting the Mininet CLI')
+    args = parser.parse_args()
+
+    setLogLevel('info')
+    net = Mininet(controller=None, switch=OVSSwitch, autoSetMacs=False)
+    build(net)
+    net.start()
+    # the flow rules only forward unicast traffic, so ARP requests are never answered
+    net.staticArp()
+    install_flows(net, INITIAL_FLOWS)
+
+    if args.update_after > 0:
+        time.sleep(args.update_after)
+        apply_updates(net)
+
+    if not args.no_cli:
+        CLI(net)
+    net.stop()
+
+
+if __name__ == '__main__':
+    main()
+`)
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+func writePythonFlows(sb *strings.Builder, swName string, flows []ofFlow) {
+	sb.WriteString(fmt.Sprintf("    '%s': [\n", swName))
+	for _, flow := range ofctlFlows(flows) {
+		sb.WriteString(fmt.Sprintf("        '%s',\n", flow))
+	}
+	sb.WriteString("    ],\n")
+}
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMininetExporter(t *testing.T) {
+	script := export(t, &MininetExporter{UpdateDelay: 2500 * time.Millisecond}, tinyNetwork(t))
+
+	checkContains(t, script,
+		"UPDATE_DELAY = 2.5  #",
+		"INITIAL_FLOWS = {\n"+
+			"    's1': [\n"+
+			"        'priority=1000,in_port=1,"+tinyToH0+",actions=output:2',\n"+
+			"        'priority=1000,in_port=2,"+tinyToH1+",actions=output:1',\n"+
+			"    ],\n"+
+			"    's2': [\n",
+		"UPDATED_FLOWS = {\n"+
+			"    's1': [\n"+
+			"        'priority=1001,in_port=1,"+tinyToH0+",actions=drop',\n"+
+			"        'priority=1000,in_port=2,"+tinyToH1+",actions=in_port',\n"+
+			"    ],\n"+
+			"}\n",
+		// the links and hosts use the same local ports as the flow rules
+		"def build(net):\n"+
+			"    net.addSwitch('s1', dpid='0000000000000001', protocols=OF_VERSION, failMode='secure')\n"+
+			"    net.addSwitch('s2', dpid='0000000000000002', protocols=OF_VERSION, failMode='secure')\n"+
+			"    net.addLink('s1', 's2', port1=1, port2=1)\n"+
+			"    net.addHost('h0', ip='10.0.0.1/16', mac='00:00:00:00:00:01')\n"+
+			"    net.addLink('h0', 's1', port2=2)\n"+
+			"    net.addHost('h1', ip='10.0.0.2/16', mac='00:00:00:00:00:02')\n"+
+			"    net.addLink('h1', 's2', port2=2)\n",
+	)
+}
+
+func TestMininetExporterWithoutUpdates(t *testing.T) {
+	netJSON := tinyNetworkJSON()
+	netJSON.Switches[0].NewFlowTable = nil
+	netJSON.Controllers = nil
+	n := tinyNetworkFromJSON(t, netJSON)
+
+	script := export(t, &MininetExporter{}, n)
+	checkContains(t, script, "UPDATE_DELAY = 0  #", "UPDATED_FLOWS = {\n}\n")
+	if strings.Count(script, "'s1': [") != 1 {
+		t.Error("s1 must only have initial flows")
+	}
+}
@@ -0,0 +1,398 @@
+package export
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+)
+
+const (
+	OF_BASE_PRIORITY = 1000 // OpenFlow priority of the rules with convert.DEFAULT_PRIORITY
+	OF_MAX_PRIORITY  = 65535
+	OF_VERSION       = "OpenFlow13"
+	OF_IN_PORT       = "IN_PORT" // reserved port for sending a packet back where it came from
+	IPV4_ETH_TYPE    = 0x800
+	TCP_IP_PROTO     = 6
+)
+
+/*
+Writes the flow tables as a shell script of 'ovs-ofctl add-flow' commands. Running the script
+without arguments installs the initial flow tables, running it with 'update' installs the flow
+tables of the controllers' updates. Port numbers are local to each switch, see LocalPorts.
+*/
+type OfctlExporter struct{}
+
+/*
+Writes the flow tables as OpenFlow 1.3 flow-mods in the JSON format of the Ryu ofctl REST API
+(the body of /stats/flowentry/add), grouped per switch together with the port mapping.
+*/
+type OpenFlowJSONExporter struct{}
+
+// An OpenFlow rule with the port numbers of its switch
+type ofFlow struct {
+	priority int
+	match    []ofMatchField
+	outPorts []int64 // a port equal to the incoming one is output to OF_IN_PORT, no ports means drop
+	inPort   int64   // the incoming port, 0 if any
+}
+
+type ofMatchField struct {
+	ofctlName string
+	jsonName  string
+	value     any
+}
+
+// the flow rules of a switch, and of its update if it has one
+type switchFlows struct {
+	sw           *convert.Switch
+	localPorts   map[int64]int64
+	flows        []ofFlow
+	updatedFlows []ofFlow
+	hasUpdate    bool
+}
+
+func networkFlows(n *convert.Network) ([]switchFlows, error) {
+	if n == nil {
+		return []switchFlows{}, errors.New("Received nil network!")
+	}
+
+	switches := slices.Clone(n.Switches())
+	slices.SortFunc(switches, func(a, b *convert.Switch) int {
+		return cmp.Compare(a.TopoNode().ID(), b.TopoNode().ID())
+	})
+
+	allFlows := []switchFlows{}
+	for _, sw := range switches {
+		swFlows := switchFlows{sw: sw, localPorts: LocalPorts(sw)}
+
+		var err error
+		swFlows.flows, err = openFlowRules(sw, sw.FlowTable(), swFlows.localPorts)
+		if err != nil {
+			return []switchFlows{}, err
+		}
+
+		newFt, hasUpdate := sw.NewFlowTable()
+		if hasUpdate {
+			swFlows.hasUpdate = true
+			swFlows.updatedFlows, err = openFlowRules(sw, newFt, swFlows.localPorts)
+			if err != nil {
+				return []switchFlows{}, err
+			}
+		}
+		allFlows = append(allFlows, swFlows)
+	}
+
+	return allFlows, nil
+}
+
+/*
+Converts the rules of a flow table to OpenFlow rules. The outgoing ports that only move a packet
+over a link to the next switch are left out, since the link itself does this in a real network.
+*/
+func openFlowRules(
+	sw *convert.Switch,
+	ft *convert.FlowTable,
+	localPorts map[int64]int64,
+) ([]ofFlow, error) {
+	flows := []ofFlow{}
+	swName := SwitchName(sw.TopoNode().ID())
+
+	for _, rule := range ft.PriorityRules() {
+		flow := ofFlow{
+			priority: min(max(OF_BASE_PRIORITY+rule.Priority, 0), OF_MAX_PRIORITY),
+			outPorts: []int64{},
+		}
+
+		if rule.Match.InPort != convert.ANY_PORT {
+			inPort, exists := localPorts[rule.Match.InPort]
+			if !exists {
+				return []ofFlow{}, errors.New(fmt.Sprintf(
+					"Incoming port %d of a rule is not a port of switch %s!", rule.Match.InPort, swName,
+				))
+			}
+			flow.inPort = inPort
+			flow.match = append(flow.match, ofMatchField{"in_port", "in_port", inPort})
+		}
+		fields, err := headerMatch(rule.Match)
+		if err != nil {
+			return []ofFlow{}, err
+		}
+		flow.match = append(flow.match, fields...)
+
+		peerPort, hasPeer := sw.PeerPort(rule.Match.InPort)
+		for _, outPort := range rule.OutPorts {
+			localPort, exists := localPorts[outPort]
+			switch {
+			case exists:
+				flow.outPorts = append(flow.outPorts, localPort)
+			case hasPeer && outPort == peerPort:
+				continue
+			default:
+				return []ofFlow{}, errors.New(fmt.Sprintf(
+					"Outgoing port %d of a rule is not a port of switch %s!", outPort, swName,
+				))
+			}
+		}
+
+		if !rule.Drops() && len(flow.outPorts) == 0 {
+			continue
+		}
+		flows = append(flows, flow)
+	}
+
+	return flows, nil
+}
+
+// returns the OpenFlow match fields of the destination and the header fields, with their prerequisites
+func headerMatch(match convert.FlowMatch) ([]ofMatchField, error) {
+	fields := []ofMatchField{}
+	if match.DestHostId != convert.ANY_HOST {
+		mac, err := convert.HostMAC(match.DestHostId)
+		if err != nil {
+			return []ofMatchField{}, err
+		}
+		fields = append(fields, ofMatchField{"dl_dst", "eth_dst", mac})
+	}
+
+	headers := match.Headers
+	if headers[convert.SRC_FIELD] != convert.NO_VALUE {
+		mac, err := convert.HostMAC(headers[convert.SRC_FIELD])
+		if err != nil {
+			return []ofMatchField{}, err
+		}
+		fields = append(fields, ofMatchField{"dl_src", "eth_src", mac})
+	}
+
+	ethType, ipProto := headers[convert.ETH_TYPE_FIELD], headers[convert.IP_PROTO_FIELD]
+	tcpDst := headers[convert.TCP_DST_FIELD]
+	if tcpDst != convert.NO_VALUE && ipProto == convert.NO_VALUE {
+		ipProto = TCP_IP_PROTO
+	}
+	if ipProto != convert.NO_VALUE && ethType == convert.NO_VALUE {
+		ethType = IPV4_ETH_TYPE
+	}
+
+	if ethType != convert.NO_VALUE {
+		fields = append(fields, ofMatchField{"dl_type", "eth_type", ethType})
+	}
+	if vlan := headers[convert.VLAN_FIELD]; vlan != convert.NO_VALUE {
+		fields = append(fields, ofMatchField{"dl_vlan", "vlan_vid", vlan})
+	}
+	if ipProto != convert.NO_VALUE {
+		fields = append(fields, ofMatchField{"nw_proto", "ip_proto", ipProto})
+	}
+	if tcpDst != convert.NO_VALUE {
+		fields = append(fields, ofMatchField{"tp_dst", "tcp_dst", tcpDst})
+	}
+
+	return fields, nil
+}
+
+// describes what the global port of the switch is connected to
+func describePort(n *convert.Network, sw *convert.Switch, port int64) string {
+	nodeId := sw.TopoNode().ID()
+	for _, link := range sw.Links() {
+		from, to := link.TopoEdge().From().ID(), link.TopoEdge().To().ID()
+		switch {
+		case from == nodeId && link.FromPort() == port:
+			return fmt.Sprintf("link to %s", SwitchName(to))
+		case to == nodeId && link.ToPort() == port:
+			return fmt.Sprintf("link to %s", SwitchName(from))
+		}
+	}
+
+	for _, h := range n.CreatedHosts() {
+		if h.SwitchPort() == port {
+			return fmt.Sprintf("host %d", h.ID())
+		}
+	}
+	return "edge port"
+}
+
+func (_ *OfctlExporter) Export(n *convert.Network, w io.Writer) error {
+	allFlows, err := networkFlows(n)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n")
+	sb.WriteString("# OpenFlow rules of the generated network for Open vSwitch.\n")
+	sb.WriteString("# Usage: ./<script> [install|update]\n")
+	sb.WriteString("#\n# Port numbers are local to each switch:\n")
+	for _, swFlows := range allFlows {
+		portStrs := []string{}
+		for _, port := range swFlows.sw.Ports() {
+			portStrs = append(portStrs, fmt.Sprintf(
+				"%d = port %d (%s)",
+				swFlows.localPorts[port],
+				port,
+				describePort(n, swFlows.sw, port),
+			))
+		}
+		sb.WriteString(fmt.Sprintf(
+			"#   %s: %s\n",
+			SwitchName(swFlows.sw.TopoNode().ID()),
+			strings.Join(portStrs, ", "),
+		))
+	}
+	sb.WriteString(fmt.Sprintf("\nOFCTL=\"ovs-ofctl -O %s\"\n", OF_VERSION))
+
+	sb.WriteString("\ninstall() {\n")
+	for _, swFlows := range allFlows {
+		writeOfctlFlows(&sb, SwitchName(swFlows.sw.TopoNode().ID()), swFlows.flows)
+	}
+	sb.WriteString("}\n")
+
+	sb.WriteString("\nupdate() {\n\t:\n")
+	for _, swFlows := range allFlows {
+		if !swFlows.hasUpdate {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\t# installed by controller %d\n", swFlows.sw.Controller().ID()))
+		writeOfctlFlows(&sb, SwitchName(swFlows.sw.TopoNode().ID()), swFlows.updatedFlows)
+	}
+	sb.WriteString("}\n")
+	sb.WriteString("\n\"${1:-install}\"\n")
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+func writeOfctlFlows(sb *strings.Builder, swName string, flows []ofFlow) {
+	sb.WriteString(fmt.Sprintf("\t$OFCTL del-flows %s\n", swName))
+	for _, flow := range ofctlFlows(flows) {
+		sb.WriteString(fmt.Sprintf("\t$OFCTL add-flow %s \"%s\"\n", swName, flow))
+	}
+}
+
+// returns the flows in the syntax of 'ovs-ofctl add-flow'
+func ofctlFlows(flows []ofFlow) []string {
+	ofctlFlows := []string{}
+	for _, flow := range flows {
+		fields := []string{fmt.Sprintf("priority=%d", flow.priority)}
+		for _, field := range flow.match {
+			value := fmt.Sprint(field.value)
+			if field.ofctlName == "dl_type" {
+				value = fmt.Sprintf("0x%04x", field.value)
+			}
+			fields = append(fields, fmt.Sprintf("%s=%s", field.ofctlName, value))
+		}
+
+		actions := []string{}
+		for _, outPort := range flow.outPorts {
+			if outPort == flow.inPort {
+				actions = append(actions, "in_port")
+				continue
+			}
+			actions = append(actions, fmt.Sprintf("output:%d", outPort))
+		}
+		if len(actions) == 0 {
+			actions = append(actions, "drop")
+		}
+
+		ofctlFlows = append(ofctlFlows, fmt.Sprintf(
+			"%s,actions=%s",
+			strings.Join(fields, ","),
+			strings.Join(actions, ","),
+		))
+	}
+	return ofctlFlows
+}
+
+type ofJSONDocument struct {
+	Version  string         `json:"version"`
+	Switches []ofJSONSwitch `json:"switches"`
+}
+
+type ofJSONSwitch struct {
+	Name         string          `json:"name"`
+	Dpid         int64           `json:"dpid"`
+	NodeId       int64           `json:"nodeId"`
+	Ports        []ofJSONPort    `json:"ports"`
+	Flows        []ofJSONFlowMod `json:"flows"`
+	UpdatedFlows []ofJSONFlowMod `json:"updatedFlows,omitempty"`
+}
+
+type ofJSONPort struct {
+	Port        int64  `json:"port"`
+	GlobalPort  int64  `json:"globalPort"`
+	Description string `json:"description"`
+}
+
+type ofJSONFlowMod struct {
+	Dpid     int64          `json:"dpid"`
+	TableId  int            `json:"table_id"`
+	Priority int            `json:"priority"`
+	Match    map[string]any `json:"match"`
+	Actions  []ofJSONAction `json:"actions"`
+}
+
+type ofJSONAction struct {
+	Type string `json:"type"`
+	Port any    `json:"port"`
+}
+
+func (_ *OpenFlowJSONExporter) Export(n *convert.Network, w io.Writer) error {
+	allFlows, err := networkFlows(n)
+	if err != nil {
+		return err
+	}
+
+	doc := ofJSONDocument{Version: OF_VERSION, Switches: []ofJSONSwitch{}}
+	for _, swFlows := range allFlows {
+		nodeId := swFlows.sw.TopoNode().ID()
+		jsonSw := ofJSONSwitch{
+			Name:   SwitchName(nodeId),
+			Dpid:   DatapathId(nodeId),
+			NodeId: nodeId,
+			Ports:  []ofJSONPort{},
+			Flows:  toJSONFlowMods(DatapathId(nodeId), swFlows.flows),
+		}
+		for _, port := range swFlows.sw.Ports() {
+			jsonSw.Ports = append(jsonSw.Ports, ofJSONPort{
+				Port:        swFlows.localPorts[port],
+				GlobalPort:  port,
+				Description: describePort(n, swFlows.sw, port),
+			})
+		}
+		if swFlows.hasUpdate {
+			jsonSw.UpdatedFlows = toJSONFlowMods(DatapathId(nodeId), swFlows.updatedFlows)
+		}
+		doc.Switches = append(doc.Switches, jsonSw)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+func toJSONFlowMods(dpid int64, flows []ofFlow) []ofJSONFlowMod {
+	flowMods := []ofJSONFlowMod{}
+	for _, flow := range flows {
+		flowMod := ofJSONFlowMod{
+			Dpid:     dpid,
+			Priority: flow.priority,
+			Match:    make(map[string]any),
+			Actions:  []ofJSONAction{},
+		}
+		for _, field := range flow.match {
+			flowMod.Match[field.jsonName] = field.value
+		}
+		for _, outPort := range flow.outPorts {
+			var port any = outPort
+			if outPort == flow.inPort {
+				port = OF_IN_PORT
+			}
+			flowMod.Actions = append(flowMod.Actions, ofJSONAction{Type: "OUTPUT", Port: port})
+		}
+		flowMods = append(flowMods, flowMod)
+	}
+	return flowMods
+}
@@ -0,0 +1,88 @@
+package export
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	tinyHTTPSMatch = "dl_type=0x0800,nw_proto=6,tp_dst=443"
+	tinyToH0       = "dl_dst=00:00:00:00:00:01," + tinyHTTPSMatch
+	tinyToH1       = "dl_dst=00:00:00:00:00:02," + tinyHTTPSMatch
+)
+
+func TestOfctlExporter(t *testing.T) {
+	script := export(t, &OfctlExporter{}, tinyNetwork(t))
+
+	checkContains(t, script,
+		"#   s1: 1 = port 0 (link to s2), 2 = port 2 (host 0)\n"+
+			"#   s2: 1 = port 1 (link to s1), 2 = port 3 (host 1)\n",
+		"install() {\n"+
+			"\t$OFCTL del-flows s1\n"+
+			"\t$OFCTL add-flow s1 \"priority=1000,in_port=1,"+tinyToH0+",actions=output:2\"\n"+
+			"\t$OFCTL add-flow s1 \"priority=1000,in_port=2,"+tinyToH1+",actions=output:1\"\n"+
+			"\t$OFCTL del-flows s2\n"+
+			"\t$OFCTL add-flow s2 \"priority=1000,in_port=2,"+tinyToH0+",actions=output:1\"\n"+
+			"\t$OFCTL add-flow s2 \"priority=1000,in_port=1,"+tinyToH1+",actions=output:2\"\n"+
+			"}\n",
+		// only s1 is updated, a drop rule has no actions and a packet sent back leaves at in_port
+		"update() {\n\t:\n"+
+			"\t# installed by controller 0\n"+
+			"\t$OFCTL del-flows s1\n"+
+			"\t$OFCTL add-flow s1 \"priority=1001,in_port=1,"+tinyToH0+",actions=drop\"\n"+
+			"\t$OFCTL add-flow s1 \"priority=1000,in_port=2,"+tinyToH1+",actions=in_port\"\n"+
+			"}\n",
+	)
+}
+
+func TestOpenFlowJSONExporter(t *testing.T) {
+	var doc ofJSONDocument
+	if err := json.Unmarshal([]byte(export(t, &OpenFlowJSONExporter{}, tinyNetwork(t))), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != OF_VERSION || len(doc.Switches) != 2 {
+		t.Fatalf("got version %s and %d switches", doc.Version, len(doc.Switches))
+	}
+
+	s1, s2 := doc.Switches[0], doc.Switches[1]
+	wantPorts := []ofJSONPort{{1, 0, "link to s2"}, {2, 2, "host 0"}}
+	if s1.Name != "s1" || s1.Dpid != 1 || !reflect.DeepEqual(s1.Ports, wantPorts) {
+		t.Errorf("got switch %s with dpid %d and ports %+v", s1.Name, s1.Dpid, s1.Ports)
+	}
+	if len(s2.UpdatedFlows) != 0 {
+		t.Errorf("s2 is not updated, got %d updated flows", len(s2.UpdatedFlows))
+	}
+
+	// JSON numbers are decoded as float64
+	wantMatch := map[string]any{
+		"in_port": 2.0, "eth_dst": "00:00:00:00:00:02", "eth_type": float64(IPV4_ETH_TYPE), "ip_proto": 6.0, "tcp_dst": 443.0,
+	}
+	flow := s1.Flows[1]
+	if flow.Dpid != 1 || flow.Priority != OF_BASE_PRIORITY || !reflect.DeepEqual(flow.Match, wantMatch) {
+		t.Errorf("got flow %+v", flow)
+	}
+	if want := []ofJSONAction{{"OUTPUT", 1.0}}; !reflect.DeepEqual(flow.Actions, want) {
+		t.Errorf("got actions %+v, want %+v", flow.Actions, want)
+	}
+
+	drop, back := s1.UpdatedFlows[0], s1.UpdatedFlows[1]
+	if len(drop.Actions) != 0 || drop.Priority != OF_BASE_PRIORITY+1 {
+		t.Errorf("got drop flow %+v", drop)
+	}
+	if want := []ofJSONAction{{"OUTPUT", OF_IN_PORT}}; !reflect.DeepEqual(back.Actions, want) {
+		t.Errorf("got actions %+v, want %+v", back.Actions, want)
+	}
+}
+
+func TestOpenFlowExportersRejectForeignPorts(t *testing.T) {
+	netJSON := tinyNetworkJSON()
+	netJSON.Switches[1].FlowTable.Entries[0].OutPorts = []int64{2}
+	n := tinyNetworkFromJSON(t, netJSON)
+
+	err := (&OfctlExporter{}).Export(n, &strings.Builder{})
+	if err == nil || !strings.Contains(err.Error(), "not a port of switch s2") {
+		t.Errorf("expected an error for a port of another switch, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package export
+
+import (
+	"slices"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+)
+
+// A hop of the packets towards a destination: from a switch to the next switch or to a host
+type hop struct {
+	from   *convert.Switch
+	toSw   *convert.Switch // nil if the hop ends at a host
+	toHost *convert.Host
+}
+
+/*
+Returns the hops of the packets towards the destination host, for the outgoing ports of the
+rules matching the destination, following either the current flow tables or the flow tables
+after the controllers' updates.
+*/
+func forwardingHops(n *convert.Network, destHostId int64, afterUpdate bool) []hop {
+	hostPorts := make(map[int64]*convert.Host)
+	for _, h := range n.CreatedHosts() {
+		hostPorts[h.SwitchPort()] = h
+	}
+
+	hops := []hop{}
+	for _, sw := range sortedSwitches(n.Switches()) {
+		ft := sw.FlowTable()
+		if newFt, hasUpdate := sw.NewFlowTable(); hasUpdate && afterUpdate {
+			ft = newFt
+		}
+
+		nodeId := sw.TopoNode().ID()
+		swHops := []hop{}
+		for _, rule := range ft.PriorityRules() {
+			if rule.Match.DestHostId != destHostId && rule.Match.DestHostId != convert.ANY_HOST {
+				continue
+			}
+
+			for _, outPort := range rule.OutPorts {
+				next := hop{from: sw}
+				if h, isHostPort := hostPorts[outPort]; isHostPort {
+					next.toHost = h
+				}
+				for _, link := range sw.Links() {
+					from, to := link.TopoEdge().From().ID(), link.TopoEdge().To().ID()
+					switch {
+					case from == nodeId && link.FromPort() == outPort:
+						next.toSw = n.NodeIdToSw()[to]
+					case to == nodeId && link.ToPort() == outPort:
+						next.toSw = n.NodeIdToSw()[from]
+					}
+				}
+
+				// the ports of the other ends of the links are reached by the hops over the links
+				if (next.toSw != nil || next.toHost != nil) && !slices.Contains(swHops, next) {
+					swHops = append(swHops, next)
+				}
+			}
+		}
+		hops = append(hops, swHops...)
+	}
+
+	return hops
+}
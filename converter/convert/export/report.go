@@ -0,0 +1,468 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/encode"
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+const (
+	MARKDOWN_REPORT = "md"
+	HTML_REPORT     = "html"
+
+	REPORT_ANY  = "any"  // wildcard destinations and incoming ports
+	REPORT_DROP = "drop" // out ports of the rules that drop packets
+)
+
+// the DyNetKAT operators of the controller terms, in plain text
+var REPORT_SYMBOLS = encode.SymbolEncoding{
+	ONE:    "1",
+	SEQ:    " ; ",
+	RECV:   " ? ",
+	SEND:   " ! ",
+	DEF:    " ≜ ",
+	NONDET: " ⊕ ",
+}
+
+// A file with an encoding of the network that the report links to
+type ReportFile struct {
+	Title string
+	Path  string // relative to the report
+}
+
+/*
+Writes a page for reviewers that describes the network without DyNetKAT terms: the topology
+metadata, the placement of the hosts and controllers, the flow tables of every switch before
+and after the update, the controller terms and links to the encoded files. The page is written
+in Markdown or, with HTML_REPORT as format, as a self-contained HTML page.
+*/
+type ReportExporter struct {
+	Name   string // name of the topology
+	Format string // MARKDOWN_REPORT (default) or HTML_REPORT
+	Files  []ReportFile
+}
+
+func (e *ReportExporter) Export(n *convert.Network, w io.Writer) error {
+	sections := e.sections(n)
+
+	var content string
+	switch e.Format {
+	case "", MARKDOWN_REPORT:
+		content = writeMarkdownReport(sections)
+	case HTML_REPORT:
+		content = writeHTMLReport(e.title(), sections)
+	default:
+		return errors.New(fmt.Sprintf("Unknown report format '%s'!", e.Format))
+	}
+
+	_, err := io.WriteString(w, content)
+	return err
+}
+
+type reportTable struct {
+	header []string
+	rows   [][]string
+}
+
+// A part of a section: a paragraph, a table, preformatted text or a list of links
+type reportBlock struct {
+	text  string
+	table *reportTable
+	code  string
+	links []ReportFile
+}
+
+type reportSection struct {
+	level  int // heading level, from 1
+	title  string
+	blocks []reportBlock
+}
+
+func (e *ReportExporter) title() string {
+	if e.Name == "" {
+		return "Network report"
+	}
+	return "Network report: " + e.Name
+}
+
+func (e *ReportExporter) sections(n *convert.Network) []reportSection {
+	ports := portLabels(n)
+
+	sections := []reportSection{
+		{level: 1, title: e.title()},
+		{level: 2, title: "Topology", blocks: []reportBlock{{table: topologyTable(n, e.Name)}}},
+	}
+	if len(e.Files) > 0 {
+		sections = append(sections, reportSection{
+			level:  2,
+			title:  "Encoded files",
+			blocks: []reportBlock{{links: e.Files}},
+		})
+	}
+	sections = append(
+		sections,
+		reportSection{level: 2, title: "Hosts", blocks: []reportBlock{{table: hostsTable(n)}}},
+		reportSection{level: 2, title: "Controllers", blocks: controllerBlocks(n)},
+		reportSection{
+			level: 2,
+			title: "Flow tables",
+			blocks: []reportBlock{{
+				text: "Ports are global. The ports of the switch are followed by the switch or host at the other " +
+					"end of their link, the ports of other switches (e.g. in the link rules) by the switch they are at.",
+			}},
+		},
+	)
+
+	switches := slices.Clone(n.Switches())
+	slices.SortFunc(switches, func(a, b *convert.Switch) int {
+		return int(a.TopoNode().ID() - b.TopoNode().ID())
+	})
+	for _, sw := range switches {
+		sections = append(sections, reportSection{
+			level:  3,
+			title:  termName(encode.SW_BASE_NAME, sw.TopoNode().ID()),
+			blocks: switchBlocks(sw, ports),
+		})
+	}
+
+	return sections
+}
+
+func termName(baseName string, id int64) string {
+	return fmt.Sprintf("%s%d", baseName, id)
+}
+
+func hostName(hostId int64) string {
+	return fmt.Sprintf("H%d", hostId)
+}
+
+// the switch a port is at and the switch or host at the other end of its link
+type portEnds struct {
+	owner string
+	peer  string
+}
+
+func portLabels(n *convert.Network) map[int64]portEnds {
+	labels := make(map[int64]portEnds)
+	for _, sw := range n.Switches() {
+		for _, link := range sw.Links() {
+			from := termName(encode.SW_BASE_NAME, link.TopoEdge().From().ID())
+			to := termName(encode.SW_BASE_NAME, link.TopoEdge().To().ID())
+			labels[link.FromPort()] = portEnds{owner: from, peer: to}
+			labels[link.ToPort()] = portEnds{owner: to, peer: from}
+		}
+	}
+	for _, h := range n.CreatedHosts() {
+		labels[h.SwitchPort()] = portEnds{
+			owner: termName(encode.SW_BASE_NAME, h.Switch().TopoNode().ID()),
+			peer:  hostName(h.ID()),
+		}
+	}
+	return labels
+}
+
+func topologyTable(n *convert.Network, name string) *reportTable {
+	topo := n.Topology()
+	stats := util.ComputeTopologyStats(name, topo)
+
+	controlled := 0
+	for _, c := range n.Controllers() {
+		controlled += len(c.NewFlowTables())
+	}
+
+	table := &reportTable{
+		header: []string{"Property", "Value"},
+		rows: [][]string{
+			{"Switches", strconv.Itoa(stats.Nodes)},
+			{"Links", strconv.Itoa(stats.Edges)},
+			{"Connected components", strconv.Itoa(stats.Components)},
+			{"Diameter (hops)", strconv.Itoa(stats.Diameter)},
+			{"Average degree", strconv.FormatFloat(stats.AvgDegree, 'f', 2, 64)},
+			{"Bridges", strconv.Itoa(stats.Bridges)},
+			{"Articulation points", strconv.Itoa(stats.ArticulationPoints)},
+			{"Hosts (connected / created)", fmt.Sprintf("%d / %d", len(n.Hosts()), len(n.CreatedHosts()))},
+			{"Controllers", strconv.Itoa(len(n.Controllers()))},
+			{"Updated switches", strconv.Itoa(controlled)},
+		},
+	}
+	if schema := n.HeaderSchema().String(); schema != "" {
+		table.rows = append(table.rows, []string{"Matched header fields", schema})
+	}
+	for _, key := range slices.Sorted(maps.Keys(topo.Attrs)) {
+		if value := topo.Attrs[key]; value != "" {
+			table.rows = append(table.rows, []string{key, value})
+		}
+	}
+	return table
+}
+
+func hostsTable(n *convert.Network) *reportTable {
+	connected := make(map[int64]bool)
+	for _, h := range n.Hosts() {
+		connected[h.ID()] = true
+	}
+
+	table := &reportTable{header: []string{"Host", "IP address", "MAC address", "Switch", "Port", "Connected"}}
+	for _, h := range n.CreatedHosts() {
+		connectedStr := "from the start"
+		if !connected[h.ID()] {
+			connectedStr = "after an update"
+		}
+		table.rows = append(table.rows, []string{
+			hostName(h.ID()),
+			h.IP(),
+			h.MAC(),
+			termName(encode.SW_BASE_NAME, h.Switch().TopoNode().ID()),
+			strconv.FormatInt(h.SwitchPort(), 10),
+			connectedStr,
+		})
+	}
+	return table
+}
+
+func controllerBlocks(n *convert.Network) []reportBlock {
+	table := &reportTable{header: []string{"Controller", "Switches", "Updated switches"}}
+	terms := []string{}
+	for _, c := range n.Controllers() {
+		nodeIds := []int64{}
+		for _, sw := range c.Switches() {
+			nodeIds = append(nodeIds, sw.TopoNode().ID())
+		}
+		slices.Sort(nodeIds)
+		updatedIds := slices.Sorted(maps.Keys(c.NewFlowTables()))
+
+		table.rows = append(table.rows, []string{
+			termName(encode.CONTROLLER_BASE_NAME, c.ID()),
+			joinTermNames(encode.SW_BASE_NAME, nodeIds),
+			joinTermNames(encode.SW_BASE_NAME, updatedIds),
+		})
+		if term := controllerTerm(c, updatedIds); term != "" {
+			terms = append(terms, term)
+		}
+	}
+
+	blocks := []reportBlock{{table: table}}
+	if len(terms) > 0 {
+		blocks = append(
+			blocks,
+			reportBlock{text: fmt.Sprintf(
+				"Each controller sends a message over the channel %s{i} to install the new flow table of switch i:",
+				encode.UP_CHANNEL_NAME,
+			)},
+			reportBlock{code: strings.Join(terms, "\n")},
+		)
+	}
+	return blocks
+}
+
+func joinTermNames(baseName string, ids []int64) string {
+	names := []string{}
+	for _, id := range ids {
+		names = append(names, termName(baseName, id))
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, ", ")
+}
+
+// writes the term of the controller like the LatexEncoder does, with REPORT_SYMBOLS
+func controllerTerm(c *convert.Controller, updatedIds []int64) string {
+	if len(updatedIds) == 0 {
+		return ""
+	}
+
+	sym := REPORT_SYMBOLS
+	cName := termName(encode.CONTROLLER_BASE_NAME, c.ID())
+	comms := []string{}
+	for _, nodeId := range updatedIds {
+		comms = append(comms, termName(encode.UP_CHANNEL_NAME, nodeId)+sym.SEND+sym.ONE+sym.SEQ+cName)
+	}
+	return cName + sym.DEF + strings.Join(comms, sym.NONDET)
+}
+
+func switchBlocks(sw *convert.Switch, ports map[int64]portEnds) []reportBlock {
+	swName := termName(encode.SW_BASE_NAME, sw.TopoNode().ID())
+	controller := "none"
+	if c := sw.GetController(); c != nil {
+		controller = termName(encode.CONTROLLER_BASE_NAME, c.ID())
+	}
+	hosts := []string{}
+	for _, h := range sw.Hosts() {
+		hosts = append(hosts, fmt.Sprintf("%s (port %d)", hostName(h.ID()), h.SwitchPort()))
+	}
+	if len(hosts) == 0 {
+		hosts = append(hosts, "none")
+	}
+
+	blocks := []reportBlock{
+		{text: fmt.Sprintf("Controller: %s. Hosts: %s.", controller, strings.Join(hosts, ", "))},
+		{text: "Before the update:"},
+		{table: flowTableTable(sw.FlowTable(), swName, ports)},
+	}
+	if newFt, exists := sw.NewFlowTable(); exists {
+		blocks = append(blocks, reportBlock{text: "After the update:"}, reportBlock{table: flowTableTable(newFt, swName, ports)})
+	} else {
+		blocks = append(blocks, reportBlock{text: "The flow table is not updated."})
+	}
+	return blocks
+}
+
+func flowTableTable(ft *convert.FlowTable, swName string, ports map[int64]portEnds) *reportTable {
+	rules := ft.PriorityRules()
+
+	withHeaders := false
+	for _, rule := range rules {
+		withHeaders = withHeaders || rule.Match.Headers != convert.NoHeaderValues()
+	}
+
+	table := &reportTable{header: []string{"Priority", "Destination", "In port"}}
+	if withHeaders {
+		table.header = append(table.header, "Headers")
+	}
+	table.header = append(table.header, "Out ports")
+
+	for _, rule := range rules {
+		dst := REPORT_ANY
+		if rule.Match.DestHostId != convert.ANY_HOST {
+			dst = hostName(rule.Match.DestHostId)
+		}
+		row := []string{strconv.Itoa(rule.Priority), dst, portLabel(rule.Match.InPort, swName, ports)}
+
+		if withHeaders {
+			headers := []string{}
+			for field, value := range rule.Match.Headers {
+				if value != convert.NO_VALUE {
+					headers = append(headers, fmt.Sprintf("%s=%d", convert.HeaderField(field), value))
+				}
+			}
+			row = append(row, strings.Join(headers, ", "))
+		}
+
+		outPorts := []string{}
+		for _, port := range rule.OutPorts {
+			outPorts = append(outPorts, portLabel(port, swName, ports))
+		}
+		if rule.Drops() {
+			outPorts = append(outPorts, REPORT_DROP)
+		}
+		table.rows = append(table.rows, append(row, strings.Join(outPorts, ", ")))
+	}
+
+	if len(table.rows) == 0 {
+		table.rows = append(table.rows, slices.Repeat([]string{"-"}, len(table.header)))
+	}
+	return table
+}
+
+func portLabel(port int64, swName string, ports map[int64]portEnds) string {
+	ends, exists := ports[port]
+	switch {
+	case port == convert.ANY_PORT:
+		return REPORT_ANY
+	case !exists:
+		return strconv.FormatInt(port, 10)
+	case ends.owner != swName:
+		return fmt.Sprintf("%d (at %s)", port, ends.owner)
+	default:
+		return fmt.Sprintf("%d (%s)", port, ends.peer)
+	}
+}
+
+func writeMarkdownReport(sections []reportSection) string {
+	escape := func(text string) string {
+		return strings.ReplaceAll(text, "|", "\\|")
+	}
+
+	var sb strings.Builder
+	for _, section := range sections {
+		sb.WriteString(strings.Repeat("#", section.level) + " " + section.title + "\n\n")
+
+		for _, block := range section.blocks {
+			switch {
+			case block.table != nil:
+				sb.WriteString("| " + strings.Join(block.table.header, " | ") + " |\n")
+				sb.WriteString(strings.Repeat("| --- ", len(block.table.header)) + "|\n")
+				for _, row := range block.table.rows {
+					cells := []string{}
+					for _, cell := range row {
+						cells = append(cells, escape(cell))
+					}
+					sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+				}
+			case block.code != "":
+				sb.WriteString("```\n" + block.code + "\n```\n")
+			case len(block.links) > 0:
+				for _, file := range block.links {
+					sb.WriteString(fmt.Sprintf("- [%s](%s)\n", file.Title, file.Path))
+				}
+			default:
+				sb.WriteString(block.text + "\n")
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func writeHTMLReport(title string, sections []reportSection) string {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	sb.WriteString("<style>\n" +
+		"body { font-family: sans-serif; margin: 2em; }\n" +
+		"table { border-collapse: collapse; margin-bottom: 1em; }\n" +
+		"th, td { border: 1px solid #999; padding: 0.2em 0.6em; text-align: left; }\n" +
+		"th { background: #eee; }\n" +
+		"pre { background: #f6f6f6; padding: 0.6em; overflow-x: auto; }\n" +
+		"</style>\n</head>\n<body>\n")
+
+	for _, section := range sections {
+		sb.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", section.level, html.EscapeString(section.title), section.level))
+
+		for _, block := range section.blocks {
+			switch {
+			case block.table != nil:
+				sb.WriteString("<table>\n<tr>")
+				for _, cell := range block.table.header {
+					sb.WriteString("<th>" + html.EscapeString(cell) + "</th>")
+				}
+				sb.WriteString("</tr>\n")
+				for _, row := range block.table.rows {
+					sb.WriteString("<tr>")
+					for _, cell := range row {
+						sb.WriteString("<td>" + html.EscapeString(cell) + "</td>")
+					}
+					sb.WriteString("</tr>\n")
+				}
+				sb.WriteString("</table>\n")
+			case block.code != "":
+				sb.WriteString("<pre>" + html.EscapeString(block.code) + "</pre>\n")
+			case len(block.links) > 0:
+				sb.WriteString("<ul>\n")
+				for _, file := range block.links {
+					sb.WriteString(fmt.Sprintf(
+						"<li><a href=\"%s\">%s</a></li>\n",
+						html.EscapeString(file.Path),
+						html.EscapeString(file.Title),
+					))
+				}
+				sb.WriteString("</ul>\n")
+			default:
+				sb.WriteString("<p>" + html.EscapeString(block.text) + "</p>\n")
+			}
+		}
+	}
+
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
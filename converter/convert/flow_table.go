@@ -1,41 +1,100 @@
 package convert
 
 import (
+	"cmp"
+	"slices"
 	"strconv"
 
+	"utwente.nl/topology-to-dynetkat-coverter/convert/netkat"
 	"utwente.nl/topology-to-dynetkat-coverter/util"
 )
 
+// Identifies the packets a flow rule applies to
+type FlowMatch struct {
+	DestHostId int64
+	InPort     int64
+	Headers    HeaderValues // optional header fields, see HeaderSchema
+}
+
+func NewFlowMatch(destHostId, inPort int64, headers HeaderValues) FlowMatch {
+	return FlowMatch{
+		DestHostId: destHostId,
+		InPort:     inPort,
+		Headers:    headers,
+	}
+}
+
 type FlowTable struct {
-	entries map[util.I64Tup][]int64 // maps host destination id and incoming port to outgoing port
+	entries map[FlowMatch][]int64 // maps host destination id, incoming port and headers to outgoing port
+	rules   []PriorityRule        // rules with explicit priorities, wildcard matches or drop actions
 }
 
-func (ft *FlowTable) Entries() map[util.I64Tup][]int64 {
+func (ft *FlowTable) Entries() map[FlowMatch][]int64 {
 	return ft.entries
 }
 
-func (ft *FlowTable) setEntries(newEntries map[util.I64Tup][]int64) {
+func (ft *FlowTable) setEntries(newEntries map[FlowMatch][]int64) {
 	ft.entries = newEntries
 }
 
 func NewFlowTable() *FlowTable {
 	return &FlowTable{
-		entries: make(map[util.I64Tup][]int64),
+		entries: make(map[FlowMatch][]int64),
+		rules:   []PriorityRule{},
 	}
 }
 
-func (ft *FlowTable) AddEntry(destHostId, inPort, outPort int64) {
-	key := util.NewI64Tup(destHostId, inPort)
-
+func (ft *FlowTable) AddEntry(match FlowMatch, outPort int64) {
 	// do not add duplicate entries
-	if ft.hasEntry(key, outPort) {
+	if ft.hasEntry(match, outPort) {
 		return
 	}
 
-	ft.entries[key] = append(ft.entries[key], outPort)
+	ft.entries[match] = append(ft.entries[match], outPort)
 }
 
-func (ft *FlowTable) hasEntry(key util.I64Tup, value int64) bool {
+/*
+Adds a prioritised rule. Entries added with AddEntry are rules with DEFAULT_PRIORITY, so a
+rule with a higher priority overrides them for the packets it matches, e.g. to drop them.
+*/
+func (ft *FlowTable) AddRule(rule PriorityRule) {
+	ft.rules = append(ft.rules, rule)
+}
+
+func (ft *FlowTable) HasPriorityRules() bool {
+	return len(ft.rules) > 0
+}
+
+/*
+Returns all the rules of the flow table, the entries included, ordered from the highest to the
+lowest priority. At equal priority, the rules added with AddRule come first.
+*/
+func (ft *FlowTable) PriorityRules() []PriorityRule {
+	rules := slices.Clone(ft.rules)
+	slices.SortStableFunc(rules, comparePriorityRules)
+
+	entryRules := []PriorityRule{}
+	for _, match := range ft.sortedMatches() {
+		entryRules = append(entryRules, NewPriorityRule(DEFAULT_PRIORITY, match, ft.entries[match]...))
+	}
+
+	// insert the entries after the rules with a priority higher than or equal to the default one
+	i := 0
+	for i < len(rules) && rules[i].Priority >= DEFAULT_PRIORITY {
+		i++
+	}
+	return slices.Concat(rules[:i], entryRules, rules[i:])
+}
+
+// Returns the outgoing ports of the rule with the highest priority that matches the packet
+func (ft *FlowTable) Lookup(packet FlowMatch) []int64 {
+	if !ft.HasPriorityRules() {
+		return ft.entries[packet]
+	}
+	return lookupRules(ft.PriorityRules(), packet)
+}
+
+func (ft *FlowTable) hasEntry(key FlowMatch, value int64) bool {
 	if _, exists := ft.entries[key]; !exists {
 		return false
 	}
@@ -49,16 +108,53 @@ func (ft *FlowTable) hasEntry(key util.I64Tup, value int64) bool {
 	return false
 }
 
+// returns the matches of the flow table ordered by destination, headers and incoming port
+func (ft *FlowTable) sortedMatches() []FlowMatch {
+	matches := []FlowMatch{}
+	for match := range ft.entries {
+		matches = append(matches, match)
+	}
+
+	slices.SortFunc(matches, compareMatches)
+	return matches
+}
+
+func compareMatches(a, b FlowMatch) int {
+	if res := cmp.Compare(a.DestHostId, b.DestHostId); res != 0 {
+		return res
+	}
+	if res := slices.Compare(a.Headers[:], b.Headers[:]); res != 0 {
+		return res
+	}
+	return cmp.Compare(a.InPort, b.InPort)
+}
+
+// returns the tests on the destination and the optional header fields of the match
+func (m FlowMatch) headerTests() []util.StrTup {
+	tests := []util.StrTup{}
+	if m.DestHostId != ANY_HOST {
+		tests = append(tests, util.NewStrTup(DST_FIELD_NAME, strconv.FormatInt(m.DestHostId, 10)))
+	}
+	for field, value := range m.Headers {
+		if value != NO_VALUE {
+			tests = append(tests, util.NewStrTup(HeaderField(field).String(), strconv.FormatInt(value, 10)))
+		}
+	}
+	return tests
+}
+
+// Returns one simple policy per entry. The prioritised rules are not included, see ToPrioritisedNetKATPolicies.
 func (ft *FlowTable) ToNetKATPolicies() []*SimpleNetKATPolicy {
 	policies := []*SimpleNetKATPolicy{}
 
-	for hostIdInPort, outPorts := range ft.entries {
-		dstHostId, inPort := hostIdInPort.Fst, hostIdInPort.Snd
-		for _, outPort := range outPorts {
+	for _, match := range ft.sortedMatches() {
+		for _, outPort := range ft.entries[match] {
 			policy := NewSimpleNetKATPolicy()
-			policy.AddTest("dst", strconv.FormatInt(dstHostId, 10))
-			policy.AddTest("port", strconv.FormatInt(inPort, 10))
-			policy.AddAssignment("port", strconv.FormatInt(outPort, 10))
+			for _, test := range match.headerTests() {
+				policy.AddTest(test.Fst, test.Snd)
+			}
+			policy.AddTest(PORT_FIELD_NAME, strconv.FormatInt(match.InPort, 10))
+			policy.AddAssignment(PORT_FIELD_NAME, strconv.FormatInt(outPort, 10))
 			policies = append(policies, policy)
 		}
 	}
@@ -66,18 +162,70 @@ func (ft *FlowTable) ToNetKATPolicies() []*SimpleNetKATPolicy {
 	return policies
 }
 
+/*
+Returns the whole flow table as one NetKAT policy, factoring out the tests on the
+destination and header fields shared by the rules, e.g.:
+
+	(dst = 1) · ((port = 2) · (port <- 3) + (port = 4) · (port <- 5)) + (dst = 2) · ...
+
+Returns 0 for an empty flow table. Flow tables with prioritised rules are returned as the union
+of their ToPrioritisedNetKATPolicies.
+*/
+func (ft *FlowTable) ToNetKATPolicy() netkat.Policy {
+	if ft.HasPriorityRules() {
+		return netkat.Simplify(netkat.NewUnion(ft.ToPrioritisedNetKATPolicies()...))
+	}
+
+	dests := []netkat.Policy{}
+	matches := ft.sortedMatches()
+
+	for i := 0; i < len(matches); {
+		// matches with the same destination and headers are consecutive
+		j := i
+		inPorts := []netkat.Policy{}
+		for ; j < len(matches) && matches[j].DestHostId == matches[i].DestHostId &&
+			matches[j].Headers == matches[i].Headers; j++ {
+			outPorts := []netkat.Policy{}
+			for _, outPort := range ft.entries[matches[j]] {
+				outPorts = append(outPorts, netkat.NewAssign(PORT_FIELD_NAME, strconv.FormatInt(outPort, 10)))
+			}
+			inPortTest := netkat.NewTest(PORT_FIELD_NAME, strconv.FormatInt(matches[j].InPort, 10))
+			inPorts = append(inPorts, netkat.NewSeq(inPortTest, netkat.NewUnion(outPorts...)))
+		}
+
+		tests := []netkat.Predicate{}
+		for _, test := range matches[i].headerTests() {
+			tests = append(tests, netkat.NewTest(test.Fst, test.Snd))
+		}
+		dests = append(dests, netkat.NewSeq(netkat.Conj(tests...), netkat.NewUnion(inPorts...)))
+		i = j
+	}
+
+	return netkat.Simplify(netkat.NewUnion(dests...))
+}
+
+// Returns the policies of all the rules, entries included, encoded as guarded choices by priority
+func (ft *FlowTable) ToPrioritisedNetKATPolicies() []netkat.Policy {
+	return PriorityRulesToNetKATPolicies(ft.PriorityRules())
+}
+
 // returns a deep copy of this flow table
 func (ft *FlowTable) Copy() *FlowTable {
 	newFt := NewFlowTable()
-	entries := make(map[util.I64Tup][]int64)
+	entries := make(map[FlowMatch][]int64)
 
-	for hostIdInPort, outPorts := range ft.entries {
+	for match, outPorts := range ft.entries {
 		newOutPorts := make([]int64, len(outPorts))
 		copy(newOutPorts, outPorts)
 
-		entries[hostIdInPort] = newOutPorts
+		entries[match] = newOutPorts
 	}
 	newFt.setEntries(entries)
 
+	for _, rule := range ft.rules {
+		rule.OutPorts = slices.Clone(rule.OutPorts)
+		newFt.AddRule(rule)
+	}
+
 	return newFt
 }
@@ -0,0 +1,186 @@
+package convert
+
+import (
+	"fmt"
+	"slices"
+)
+
+// priority of the exceptions to the wildcard rules created by the flow table optimiser
+const EXCEPTION_PRIORITY = DEFAULT_PRIORITY + 1
+
+/*
+Compresses flow tables into prioritised rule lists with the same forwarding behavior for all
+the traffic that can reach the switches. Rules for traffic that can never arrive are dropped,
+and the rules of a destination that share their outgoing ports are merged into one rule that
+does not depend on the incoming port, with higher-priority exceptions for the other ports.
+
+Traffic is assumed to enter the network at the edge ports (ports that are not link ends) with
+any destination and header values present in the flow tables, and to then follow the rules of
+both the current and the updated flow tables.
+*/
+type FlowTableOptimizer struct {
+	// whether the policies are restricted to their switch, e.g. by a test on the 'sw' field,
+	// so a switch only receives packets at its own ports
+	located bool
+
+	reachable  map[FlowMatch]bool       // the matches that packets can arrive with
+	groups     []FlowMatch              // the destination and header values present in the flow tables
+	groupPorts map[FlowMatch][]int64    // the reachable ports of each destination and header values
+	nodePorts  map[int64]map[int64]bool // the ports of the switch at each topology node
+}
+
+func NewFlowTableOptimizer(n *Network, located bool) *FlowTableOptimizer {
+	o := &FlowTableOptimizer{
+		located:    located,
+		reachable:  make(map[FlowMatch]bool),
+		groups:     []FlowMatch{},
+		groupPorts: make(map[FlowMatch][]int64),
+		nodePorts:  make(map[int64]map[int64]bool),
+	}
+
+	linkPorts := make(map[int64]bool)
+	for _, link := range n.Links() {
+		linkPorts[link.fromPort], linkPorts[link.toPort] = true, true
+	}
+
+	groups := make(map[FlowMatch]bool)
+	edgePorts := make(map[int64]bool)
+	tablesRules := [][]PriorityRule{}
+	for _, sw := range n.switches {
+		nodeId := sw.topoNode.ID()
+		ports := make(map[int64]bool)
+		for _, link := range sw.links {
+			ports[link.ownPort(nodeId)] = true
+		}
+		for _, h := range sw.hosts {
+			ports[h.switchPort], edgePorts[h.switchPort] = true, true
+		}
+
+		for _, ft := range sw.FlowTables() {
+			rules := ft.PriorityRules()
+			for _, rule := range rules {
+				inPort := rule.Match.InPort
+				if inPort != ANY_PORT && !linkPorts[inPort] {
+					ports[inPort], edgePorts[inPort] = true, true
+				}
+				if rule.Match.DestHostId != ANY_HOST {
+					groups[groupOf(rule.Match)] = true
+				}
+			}
+			tablesRules = append(tablesRules, rules)
+		}
+		o.nodePorts[nodeId] = ports
+	}
+
+	for group := range groups {
+		o.groups = append(o.groups, group)
+	}
+	slices.SortFunc(o.groups, compareMatches)
+
+	toVisit := []FlowMatch{}
+	for _, group := range o.groups {
+		for port := range edgePorts {
+			toVisit = append(toVisit, NewFlowMatch(group.DestHostId, port, group.Headers))
+		}
+	}
+
+	for len(toVisit) > 0 {
+		match := toVisit[len(toVisit)-1]
+		toVisit = toVisit[:len(toVisit)-1]
+		if o.reachable[match] {
+			continue
+		}
+
+		o.reachable[match] = true
+		o.groupPorts[groupOf(match)] = append(o.groupPorts[groupOf(match)], match.InPort)
+		for _, rules := range tablesRules {
+			for _, outPort := range lookupRules(rules, match) {
+				toVisit = append(toVisit, NewFlowMatch(match.DestHostId, outPort, match.Headers))
+			}
+		}
+	}
+
+	return o
+}
+
+func groupOf(match FlowMatch) FlowMatch {
+	return NewFlowMatch(match.DestHostId, ANY_PORT, match.Headers)
+}
+
+func (o *FlowTableOptimizer) IsReachable(match FlowMatch) bool {
+	return o.reachable[match]
+}
+
+/*
+Returns a new flow table with the optimised prioritised rules of the given flow table of the
+switch at the given topology node. The given flow table is not modified.
+*/
+func (o *FlowTableOptimizer) Optimize(nodeId int64, ft *FlowTable) *FlowTable {
+	optimised := NewFlowTable()
+	rules := ft.PriorityRules()
+
+	for _, group := range o.groups {
+		// the incoming ports that packets of the group can arrive at and their outgoing ports
+		domain := []int64{}
+		outPorts := make(map[int64][]int64)
+		for _, port := range o.groupPorts[group] {
+			if o.located && !o.nodePorts[nodeId][port] {
+				continue
+			}
+			domain = append(domain, port)
+			packet := NewFlowMatch(group.DestHostId, port, group.Headers)
+			outPorts[port] = sortedCopy(lookupRules(rules, packet))
+		}
+		slices.Sort(domain)
+
+		for _, rule := range optimiseGroup(group, domain, outPorts) {
+			optimised.AddRule(rule)
+		}
+	}
+
+	return optimised
+}
+
+/*
+Merges the rules of one destination and header values into a rule for any incoming port, if
+this makes the rule list shorter. The merged rule forwards to the most common outgoing ports,
+and the ports that packets can arrive at with other outgoing ports (or none) become exceptions.
+*/
+func optimiseGroup(group FlowMatch, domain []int64, outPorts map[int64][]int64) []PriorityRule {
+	counts := make(map[string]int)
+	mostCommon, mostCommonKey := []int64{}, ""
+	for _, port := range domain {
+		key := fmt.Sprint(outPorts[port])
+		counts[key]++
+		if len(outPorts[port]) > 0 && counts[key] > counts[mostCommonKey] {
+			mostCommon, mostCommonKey = outPorts[port], key
+		}
+	}
+
+	rules := []PriorityRule{}
+	exactRulesNr := len(domain) - counts[fmt.Sprint([]int64{})]
+	wildcardRulesNr := len(domain) - counts[mostCommonKey] + 1
+	if len(mostCommon) == 0 || wildcardRulesNr >= exactRulesNr {
+		for _, port := range domain {
+			if len(outPorts[port]) > 0 {
+				match := NewFlowMatch(group.DestHostId, port, group.Headers)
+				rules = append(rules, NewPriorityRule(DEFAULT_PRIORITY, match, outPorts[port]...))
+			}
+		}
+		return rules
+	}
+
+	for _, port := range domain {
+		if fmt.Sprint(outPorts[port]) != mostCommonKey {
+			match := NewFlowMatch(group.DestHostId, port, group.Headers)
+			rules = append(rules, NewPriorityRule(EXCEPTION_PRIORITY, match, outPorts[port]...))
+		}
+	}
+	return append(rules, NewPriorityRule(DEFAULT_PRIORITY, group, mostCommon...))
+}
+
+func sortedCopy(values []int64) []int64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return sorted
+}
@@ -0,0 +1,116 @@
+package convert_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	behavior "utwente.nl/topology-to-dynetkat-coverter/convert/network_behavior"
+	"utwente.nl/topology-to-dynetkat-coverter/dynetkat"
+)
+
+// returns the destination and header values of all the rules of the network, for any incoming port
+func flowGroups(n *convert.Network) []convert.FlowMatch {
+	groups := []convert.FlowMatch{}
+	for _, sw := range n.Switches() {
+		for _, ft := range sw.FlowTables() {
+			for _, rule := range ft.PriorityRules() {
+				group := convert.NewFlowMatch(rule.Match.DestHostId, convert.ANY_PORT, rule.Match.Headers)
+				if rule.Match.DestHostId != convert.ANY_HOST && !slices.Contains(groups, group) {
+					groups = append(groups, group)
+				}
+			}
+		}
+	}
+	return groups
+}
+
+func sortedLookup(ft *convert.FlowTable, packet convert.FlowMatch) []int64 {
+	outPorts := ft.Lookup(packet)
+	slices.Sort(outPorts)
+	return outPorts
+}
+
+/*
+The optimised flow tables must forward every packet that can reach a switch, i.e. every
+destination and header values of the network at every reachable port, like the original ones.
+Without the 'src' field, the rules of a destination are shared by several incoming ports and
+are merged into wildcard rules with exceptions.
+*/
+func TestOptimizedFlowTablesAreEquivalent(t *testing.T) {
+	wildcardsNr := 0
+	for _, spec := range []string{"ring:6", "grid:3x3", "fattree:4", "star:5"} {
+		for _, headers := range []string{"src,vlan=10", "vlan=10"} {
+			for _, rounds := range []uint{0, 2} {
+				network, err := dynetkat.Generate(
+					context.Background(),
+					dynetkat.Synthetic(spec),
+					dynetkat.Scenario{Difficulty: behavior.MEDIUM, Hosts: 10, UpdateRounds: rounds},
+					dynetkat.Options{Headers: headers},
+				)
+				if err != nil {
+					t.Fatalf("%s: %s", spec, err)
+				}
+				n := network.Unwrap()
+
+				for _, located := range []bool{false, true} {
+					name := fmt.Sprintf("%s/%s/%d rounds/located %t", spec, headers, rounds, located)
+					t.Run(name, func(t *testing.T) {
+						wildcardsNr += checkOptimizedFlowTables(t, n, located, rounds > 0)
+					})
+				}
+			}
+		}
+	}
+
+	if wildcardsNr == 0 {
+		t.Error("no rules were merged into wildcard rules")
+	}
+}
+
+// returns the number of wildcard rules of the optimised flow tables
+func checkOptimizedFlowTables(t *testing.T, n *convert.Network, located, hasUpdates bool) int {
+	optimizer := convert.NewFlowTableOptimizer(n, located)
+	groups := flowGroups(n)
+
+	packetsNr, newTablesNr, wildcardsNr := 0, 0, 0
+	for _, sw := range n.Switches() {
+		nodeId := sw.TopoNode().ID()
+		if _, exists := sw.NewFlowTable(); exists {
+			newTablesNr++
+		}
+
+		for _, ft := range sw.FlowTables() {
+			optimised := optimizer.Optimize(nodeId, ft)
+			for _, rule := range optimised.PriorityRules() {
+				if rule.Match.InPort == convert.ANY_PORT {
+					wildcardsNr++
+				}
+			}
+			for _, group := range groups {
+				for _, port := range sw.Ports() {
+					packet := convert.NewFlowMatch(group.DestHostId, port, group.Headers)
+					if !optimizer.IsReachable(packet) {
+						continue
+					}
+
+					packetsNr++
+					want, got := sortedLookup(ft, packet), sortedLookup(optimised, packet)
+					if !slices.Equal(got, want) {
+						t.Errorf("switch %d, packet %+v: got ports %v, want %v", nodeId, packet, got, want)
+					}
+				}
+			}
+		}
+	}
+
+	if packetsNr == 0 {
+		t.Error("no packet reaches a switch")
+	}
+	if hasUpdates && newTablesNr == 0 {
+		t.Error("no switch receives a new flow table")
+	}
+	return wildcardsNr
+}
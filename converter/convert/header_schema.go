@@ -0,0 +1,146 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Optional packet header fields that flow rules can match on
+type HeaderField int
+
+const (
+	SRC_FIELD HeaderField = iota
+	ETH_TYPE_FIELD
+	VLAN_FIELD
+	IP_PROTO_FIELD
+	TCP_DST_FIELD
+	HEADER_FIELDS_NR
+)
+
+const (
+	DST_FIELD_NAME  = "dst"
+	PORT_FIELD_NAME = "port"
+	NO_VALUE        = -1 // marks a header field that is not matched
+	SCHEMA_SEP      = ","
+)
+
+var HEADER_FIELD_NAMES = [HEADER_FIELDS_NR]string{"src", "ethType", "vlan", "ipProto", "tcpDst"}
+
+// default values of the header fields, used when the schema does not specify one
+var HEADER_FIELD_DEFAULTS = [HEADER_FIELDS_NR]int64{NO_VALUE, 0x800, 1, 6, 80}
+
+func (f HeaderField) String() string {
+	if f < 0 || f >= HEADER_FIELDS_NR {
+		return fmt.Sprintf("HeaderField(%d)", int(f))
+	}
+	return HEADER_FIELD_NAMES[f]
+}
+
+func ParseHeaderField(name string) (HeaderField, error) {
+	for i, fieldName := range HEADER_FIELD_NAMES {
+		if strings.EqualFold(fieldName, name) {
+			return HeaderField(i), nil
+		}
+	}
+	return HEADER_FIELDS_NR, errors.New(fmt.Sprintf("Unknown header field '%s'!", name))
+}
+
+// The value of every optional header field, NO_VALUE if the field is not matched
+type HeaderValues [HEADER_FIELDS_NR]int64
+
+func NoHeaderValues() HeaderValues {
+	var values HeaderValues
+	for i := range values {
+		values[i] = NO_VALUE
+	}
+	return values
+}
+
+/*
+Specifies which optional header fields the flow rules match on, besides the destination
+host id and the port. The 'src' field is matched against the id of the host sending the packets,
+the other fields against constant values, so flows of different sources or services can be
+routed separately.
+*/
+type HeaderSchema struct {
+	values HeaderValues
+}
+
+// Returns a schema that matches only on the destination host id and the port
+func NewHeaderSchema() *HeaderSchema {
+	return &HeaderSchema{values: NoHeaderValues()}
+}
+
+/*
+Parses a schema of the form 'field1[=value1],field2[=value2],...', for example
+'src,ethType=0x800,tcpDst=443'. Fields without a value get their default value.
+The value of 'src' is always the source host id, so it cannot be given.
+*/
+func ParseHeaderSchema(spec string) (*HeaderSchema, error) {
+	schema := NewHeaderSchema()
+	if strings.TrimSpace(spec) == "" {
+		return schema, nil
+	}
+
+	for _, fieldSpec := range strings.Split(spec, SCHEMA_SEP) {
+		name, valueStr, hasValue := strings.Cut(strings.TrimSpace(fieldSpec), "=")
+		field, err := ParseHeaderField(name)
+		if err != nil {
+			return schema, err
+		}
+
+		value := HEADER_FIELD_DEFAULTS[field]
+		if hasValue && field == SRC_FIELD {
+			return schema, errors.New(fmt.Sprintf(
+				"Header field '%s' takes its value from the source host and cannot be given one!", name))
+		}
+		if hasValue {
+			value, err = strconv.ParseInt(valueStr, 0, 64)
+			if err != nil || value < 0 {
+				return schema, errors.New(fmt.Sprintf("Invalid value for header field '%s'!", name))
+			}
+		}
+		schema.AddField(field, value)
+	}
+
+	return schema, nil
+}
+
+// Adds a field to the schema. The value is ignored for the 'src' field.
+func (s *HeaderSchema) AddField(field HeaderField, value int64) *HeaderSchema {
+	s.values[field] = value
+	if field == SRC_FIELD {
+		s.values[field] = 0
+	}
+	return s
+}
+
+func (s *HeaderSchema) HasField(field HeaderField) bool {
+	return s.values[field] != NO_VALUE
+}
+
+// Returns the header values of the packets sent by 'src'
+func (s *HeaderSchema) Values(src *Host) HeaderValues {
+	values := s.values
+	if s.HasField(SRC_FIELD) && src != nil {
+		values[SRC_FIELD] = src.ID()
+	}
+	return values
+}
+
+func (s *HeaderSchema) String() string {
+	fields := []string{}
+	for field := range HEADER_FIELDS_NR {
+		switch {
+		case !s.HasField(field):
+			continue
+		case field == SRC_FIELD:
+			fields = append(fields, field.String())
+		default:
+			fields = append(fields, fmt.Sprintf("%s=%d", field, s.values[field]))
+		}
+	}
+	return strings.Join(fields, SCHEMA_SEP)
+}
@@ -0,0 +1,63 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHeaderSchema(t *testing.T) {
+	schema, err := ParseHeaderSchema(" src, ethType=0x86dd ,TCPDST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := schema.String(); got != "src,ethType=34525,tcpDst=80" {
+		t.Errorf("got schema '%s'", got)
+	}
+
+	h := Host{id: 7}
+	if values := schema.Values(&h); values[SRC_FIELD] != 7 || values[VLAN_FIELD] != NO_VALUE {
+		t.Errorf("got values %v", values)
+	}
+}
+
+func TestParseHeaderSchemaErrors(t *testing.T) {
+	cases := []string{
+		"dst",
+		"src,vlan=",
+		"vlan=ten",
+		"tcpDst=-1",
+		"src=3",
+		"ethType=0x800,",
+	}
+
+	for _, spec := range cases {
+		_, err := ParseHeaderSchema(spec)
+		if err == nil || !strings.HasSuffix(err.Error(), "!") {
+			t.Errorf("'%s': expected an error, got %v", spec, err)
+		}
+	}
+}
+
+func TestHostAddresses(t *testing.T) {
+	for id, want := range map[int64][2]string{
+		0:             {"10.0.0.1", "00:00:00:00:00:01"},
+		255:           {"10.0.1.0", "00:00:00:00:01:00"},
+		MAX_HOSTS - 1: {"10.0.255.254", "00:00:00:00:ff:fe"},
+	} {
+		ip, ipErr := HostIP(id)
+		mac, macErr := HostMAC(id)
+		if ipErr != nil || macErr != nil || ip != want[0] || mac != want[1] {
+			t.Errorf("host %d: got %s, %s, %v, %v", id, ip, mac, ipErr, macErr)
+		}
+	}
+
+	if _, err := HostIP(MAX_HOSTS); err == nil {
+		t.Error("expected an error for a host id without an address")
+	}
+	if _, err := HostMAC(-1); err == nil {
+		t.Error("expected an error for a negative host id")
+	}
+	if _, err := NewHost(MAX_HOSTS, 0, &Switch{}); err == nil {
+		t.Error("expected an error when creating a host without an address")
+	}
+}
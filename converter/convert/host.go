@@ -1,6 +1,15 @@
 package convert
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	HOST_IP_PREFIX  = "10.0"
+	HOST_MAC_PREFIX = "00:00:00:00"
+	MAX_HOSTS       = 1<<16 - 2 // the host ids 0 to MAX_HOSTS-1 have the address suffixes 0.1 to 255.254
+)
 
 type Host struct {
 	id         int64
@@ -12,6 +21,9 @@ func NewHost(id, switchPort int64, sw *Switch) (Host, error) {
 	if sw == nil {
 		return Host{}, errors.New("Received nil switch!")
 	}
+	if err := checkHostId(id); err != nil {
+		return Host{}, err
+	}
 
 	return Host{
 		id:         id,
@@ -31,3 +43,38 @@ func (h *Host) SwitchPort() int64 {
 func (h *Host) Switch() *Switch {
 	return h.sw
 }
+
+// Returns the IPv4 address of the host, derived from its id: host i has address 10.0.x.y, where x.y = i+1
+func (h *Host) IP() string {
+	ip, _ := HostIP(h.id) // the id is checked when the host is created
+	return ip
+}
+
+// Returns the MAC address of the host, derived from its id in the same way as the IP address
+func (h *Host) MAC() string {
+	mac, _ := HostMAC(h.id) // the id is checked when the host is created
+	return mac
+}
+
+func checkHostId(hostId int64) error {
+	if hostId < 0 || hostId >= MAX_HOSTS {
+		return errors.New(fmt.Sprintf("Host id %d has no address, only %d hosts can be addressed!", hostId, MAX_HOSTS))
+	}
+	return nil
+}
+
+func HostIP(hostId int64) (string, error) {
+	if err := checkHostId(hostId); err != nil {
+		return "", err
+	}
+	suffix := hostId + 1
+	return fmt.Sprintf("%s.%d.%d", HOST_IP_PREFIX, suffix>>8, suffix&0xff), nil
+}
+
+func HostMAC(hostId int64) (string, error) {
+	if err := checkHostId(hostId); err != nil {
+		return "", err
+	}
+	suffix := hostId + 1
+	return fmt.Sprintf("%s:%02x:%02x", HOST_MAC_PREFIX, suffix>>8, suffix&0xff), nil
+}
@@ -0,0 +1,255 @@
+package convert
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+const (
+	INTERNAL_ATTR      = "Internal"
+	EXTERNAL_NODE_ATTR = "0" // value of the 'Internal' attribute of external peering points
+	LABELS_SEP         = ","
+)
+
+// Decides which switches new hosts are connected to
+type HostPlacement interface {
+	PickSwitches(n *Network, picksNr uint) ([]*Switch, error)
+}
+
+// Picks switches uniformly at random, with replacement, so multiple hosts may share a switch
+type RandomWithReplcPlacement struct{}
+
+// Picks switches uniformly at random among the switches without hosts
+type RandomPlacement struct{}
+
+// Picks the switches with the lowest degree (edge switches) that have no hosts yet
+type EdgePlacement struct{}
+
+// Picks switches at random among the topology nodes marked as external peering points ('Internal=0')
+type ExternalPlacement struct{}
+
+/*
+Picks switches that are as far apart as possible: starting from a random switch, it repeatedly
+picks the switch with the largest hop distance to the closest switch that already has a host.
+*/
+type MaxDistancePlacement struct{}
+
+/*
+Picks the switches with the given topology node labels, in order: the i-th host created in a
+network is connected to the switch with the i-th label, so the hosts of consecutive calls are
+placed at the following labels, and the same placement can be used for several networks.
+A label may be given multiple times to connect multiple hosts to the same switch.
+*/
+type LabelPlacement struct {
+	Labels []string
+}
+
+/*
+Parses a host placement from its name: 'random-replc', 'random', 'edge', 'external',
+'far' or 'labels:<label1>,<label2>,...'.
+*/
+func ParseHostPlacement(spec string) (HostPlacement, error) {
+	name, params, _ := strings.Cut(spec, ":")
+	switch strings.ToLower(name) {
+	case "", "random-replc":
+		return &RandomWithReplcPlacement{}, nil
+	case "random":
+		return &RandomPlacement{}, nil
+	case "edge":
+		return &EdgePlacement{}, nil
+	case "external":
+		return &ExternalPlacement{}, nil
+	case "far":
+		return &MaxDistancePlacement{}, nil
+	case "labels":
+		if params == "" {
+			return nil, errors.New("Label placement needs at least one label!")
+		}
+		return &LabelPlacement{Labels: strings.Split(params, LABELS_SEP)}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Unknown host placement '%s'!", spec))
+}
+
+func (_ *RandomWithReplcPlacement) PickSwitches(n *Network, picksNr uint) ([]*Switch, error) {
+	nodeIds := slices.Collect(maps.Keys(n.nodeIdToSw))
+	return n.nodeIdsToSwitches(util.RandomFromArrayWithReplc(n.rand, nodeIds, picksNr)), nil
+}
+
+func (_ *RandomPlacement) PickSwitches(n *Network, picksNr uint) ([]*Switch, error) {
+	randIds, err := util.RandomFromArray(n.rand, n.freeNodeIds(), picksNr)
+	if err != nil {
+		return []*Switch{}, errors.New("Not enough switches without hosts!")
+	}
+	return n.nodeIdsToSwitches(randIds), nil
+}
+
+func (_ *EdgePlacement) PickSwitches(n *Network, picksNr uint) ([]*Switch, error) {
+	freeIds := n.freeNodeIds()
+	if int(picksNr) > len(freeIds) {
+		return []*Switch{}, errors.New("Not enough switches without hosts!")
+	}
+
+	slices.SortFunc(freeIds, func(a, b int64) int {
+		degreeCmp := cmp.Compare(len(n.nodeIdToSw[a].links), len(n.nodeIdToSw[b].links))
+		if degreeCmp != 0 {
+			return degreeCmp
+		}
+		return cmp.Compare(a, b)
+	})
+
+	// take whole groups of equal degree, picking at random only within the last group
+	picks := []int64{}
+	for len(picks) < int(picksNr) {
+		degree := len(n.nodeIdToSw[freeIds[0]].links)
+		sameDegree := []int64{}
+		for len(freeIds) > 0 && len(n.nodeIdToSw[freeIds[0]].links) == degree {
+			sameDegree, freeIds = append(sameDegree, freeIds[0]), freeIds[1:]
+		}
+
+		toPick := min(len(sameDegree), int(picksNr)-len(picks))
+		randIds, err := util.RandomFromArray(n.rand, sameDegree, uint(toPick))
+		if err != nil {
+			return []*Switch{}, err
+		}
+		picks = append(picks, randIds...)
+	}
+
+	return n.nodeIdsToSwitches(picks), nil
+}
+
+func (_ *ExternalPlacement) PickSwitches(n *Network, picksNr uint) ([]*Switch, error) {
+	externalIds := []int64{}
+	for _, nodeId := range n.freeNodeIds() {
+		internal, exists := n.topology.NodeAttr(nodeId, INTERNAL_ATTR)
+		if exists && strings.TrimSpace(internal) == EXTERNAL_NODE_ATTR {
+			externalIds = append(externalIds, nodeId)
+		}
+	}
+
+	randIds, err := util.RandomFromArray(n.rand, externalIds, picksNr)
+	if err != nil {
+		return []*Switch{}, errors.New(fmt.Sprintf(
+			"Not enough external nodes without hosts: need %d, found %d!",
+			picksNr,
+			len(externalIds),
+		))
+	}
+	return n.nodeIdsToSwitches(randIds), nil
+}
+
+func (_ *MaxDistancePlacement) PickSwitches(n *Network, picksNr uint) ([]*Switch, error) {
+	freeIds := n.freeNodeIds()
+	if int(picksNr) > len(freeIds) {
+		return []*Switch{}, errors.New("Not enough switches without hosts!")
+	}
+
+	// hop distance from every switch to the closest switch with a host
+	closestDist := make(map[int64]int)
+	addPick := func(nodeId int64) {
+		for otherId, dist := range util.HopDistances(n.topology.Graph, nodeId) {
+			if currDist, exists := closestDist[otherId]; !exists || dist < currDist {
+				closestDist[otherId] = dist
+			}
+		}
+	}
+	for nodeId := range n.hostNodeIds {
+		addPick(nodeId)
+	}
+
+	picks := []int64{}
+	if len(n.hostNodeIds) == 0 && picksNr > 0 {
+		randIds, err := util.RandomFromArray(n.rand, freeIds, 1)
+		if err != nil {
+			return []*Switch{}, err
+		}
+		picks = append(picks, randIds[0])
+		addPick(randIds[0])
+	}
+
+	for len(picks) < int(picksNr) {
+		var farthestId int64
+		farthestDist := -1
+		for _, nodeId := range freeIds {
+			if slices.Contains(picks, nodeId) {
+				continue
+			}
+
+			dist, exists := closestDist[nodeId]
+			if !exists {
+				// unreachable from all the switches with hosts
+				dist = int(^uint(0) >> 1)
+			}
+			if dist > farthestDist {
+				farthestId, farthestDist = nodeId, dist
+			}
+		}
+		picks = append(picks, farthestId)
+		addPick(farthestId)
+	}
+
+	return n.nodeIdsToSwitches(picks), nil
+}
+
+func (p *LabelPlacement) PickSwitches(n *Network, picksNr uint) ([]*Switch, error) {
+	next := len(n.createdHosts)
+	if next+int(picksNr) > len(p.Labels) {
+		return []*Switch{}, errors.New(fmt.Sprintf(
+			"Not enough labels to place %d more host(s)!",
+			picksNr,
+		))
+	}
+
+	labelToNodeIds := make(map[string][]int64)
+	for nodeId := range n.nodeIdToSw {
+		if label, exists := n.topology.NodeAttr(nodeId, util.LABEL_ATTR); exists {
+			labelToNodeIds[label] = append(labelToNodeIds[label], nodeId)
+		}
+	}
+
+	picks := []int64{}
+	for _, label := range p.Labels[next : next+int(picksNr)] {
+		label = strings.TrimSpace(label)
+		nodeIds := labelToNodeIds[label]
+		switch len(nodeIds) {
+		case 0:
+			return []*Switch{}, errors.New(fmt.Sprintf("No topology node with label '%s'!", label))
+		case 1:
+			picks = append(picks, nodeIds[0])
+		default:
+			slices.Sort(nodeIds)
+			return []*Switch{}, errors.New(fmt.Sprintf(
+				"Label '%s' is ambiguous, it is shared by the topology nodes %v!",
+				label,
+				nodeIds,
+			))
+		}
+	}
+
+	return n.nodeIdsToSwitches(picks), nil
+}
+
+// returns the sorted ids of the topology nodes whose switches have no hosts yet
+func (n *Network) freeNodeIds() []int64 {
+	freeIds := []int64{}
+	for nodeId := range n.nodeIdToSw {
+		if !n.hostNodeIds[nodeId] {
+			freeIds = append(freeIds, nodeId)
+		}
+	}
+	slices.Sort(freeIds)
+	return freeIds
+}
+
+func (n *Network) nodeIdsToSwitches(nodeIds []int64) []*Switch {
+	switches := []*Switch{}
+	for _, nodeId := range nodeIds {
+		switches = append(switches, n.nodeIdToSw[nodeId])
+	}
+	return switches
+}
@@ -0,0 +1,186 @@
+package convert
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+/*
+The star b - {a, c, d} with the line d - e - f - g attached, labelled after the node keys.
+The leaves a, c and g are edge switches, and e and g are external peering points.
+*/
+func placementTopology(t *testing.T) util.Topology {
+	topo, err := util.EdgeListToTopology(strings.NewReader("a b\nb c\nb d\nd e\ne f\nf g\n"), "placement")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for nodeId, attrs := range topo.NodeAttrs {
+		attrs[INTERNAL_ATTR] = "1"
+		if attrs[util.LABEL_ATTR] == "e" || attrs[util.LABEL_ATTR] == "g" {
+			attrs[INTERNAL_ATTR] = EXTERNAL_NODE_ATTR
+		}
+		topo.NodeAttrs[nodeId] = attrs
+	}
+	return topo
+}
+
+func placementNetwork(t *testing.T, spec string) *Network {
+	placement, err := ParseHostPlacement(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := NewNetwork(placementTopology(t), NetworkOptions{HostPlacement: placement})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// returns the labels of the switches of the hosts
+func hostLabels(n *Network, hosts []*Host) []string {
+	labels := []string{}
+	for _, h := range hosts {
+		label, _ := n.topology.NodeAttr(h.Switch().TopoNode().ID(), util.LABEL_ATTR)
+		labels = append(labels, label)
+	}
+	return labels
+}
+
+func hasDuplicates(labels []string) bool {
+	sorted := slices.Clone(labels)
+	slices.Sort(sorted)
+	return len(slices.Compact(sorted)) != len(labels)
+}
+
+func TestRandomPlacements(t *testing.T) {
+	n := placementNetwork(t, "random-replc")
+	hosts, err := n.CreateHosts(20)
+	if err != nil || len(hosts) != 20 {
+		t.Fatalf("placement with replacement must place any number of hosts, got %d, %v", len(hosts), err)
+	}
+
+	n = placementNetwork(t, "random")
+	hosts, err = n.CreateHosts(7)
+	if err != nil || hasDuplicates(hostLabels(n, hosts)) {
+		t.Errorf("got %v, %v, want one host on every switch", hostLabels(n, hosts), err)
+	}
+	if _, err := n.CreateHosts(1); err == nil {
+		t.Error("expected an error when all the switches have hosts")
+	}
+}
+
+func TestEdgePlacement(t *testing.T) {
+	n := placementNetwork(t, "edge")
+	hosts, err := n.CreateHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels := hostLabels(n, hosts)
+	slices.Sort(labels)
+	if !slices.Equal(labels, []string{"a", "c", "g"}) {
+		t.Errorf("got %v, want the leaves a, c and g", labels)
+	}
+}
+
+func TestExternalPlacement(t *testing.T) {
+	n := placementNetwork(t, "external")
+	hosts, err := n.CreateHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels := hostLabels(n, hosts)
+	slices.Sort(labels)
+	if !slices.Equal(labels, []string{"e", "g"}) {
+		t.Errorf("got %v, want the external nodes e and g", labels)
+	}
+	if _, err := n.CreateHosts(1); err == nil {
+		t.Error("expected an error when all the external nodes have hosts")
+	}
+}
+
+func TestMaxDistancePlacement(t *testing.T) {
+	n := placementNetwork(t, "far")
+	first, err := n.CreateHosts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := n.CreateHosts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second host is placed at the largest distance from the first one
+	firstId, secondId := first[0].Switch().TopoNode().ID(), second[0].Switch().TopoNode().ID()
+	dists := util.HopDistances(n.topology.Graph, firstId)
+	for nodeId, dist := range dists {
+		if dist > dists[secondId] {
+			t.Errorf("node %d is farther from node %d than the picked node %d", nodeId, firstId, secondId)
+		}
+	}
+}
+
+func TestLabelPlacement(t *testing.T) {
+	placement, err := ParseHostPlacement("labels:c, g,c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the labels are consumed per network, so the placement can be reused
+	for range 2 {
+		n, err := NewNetwork(placementTopology(t), NetworkOptions{HostPlacement: placement})
+		if err != nil {
+			t.Fatal(err)
+		}
+		first, err := n.CreateHosts(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := n.CreateHosts(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		labels := hostLabels(n, append(first, second...))
+		if !slices.Equal(labels, []string{"c", "g", "c"}) {
+			t.Errorf("got %v, want [c g c]", labels)
+		}
+		if _, err := n.CreateHosts(1); err == nil {
+			t.Error("expected an error when the labels are used up")
+		}
+	}
+}
+
+func TestLabelPlacementErrors(t *testing.T) {
+	if _, err := ParseHostPlacement("labels:"); err == nil {
+		t.Error("expected an error for a placement without labels")
+	}
+	if _, err := ParseHostPlacement("closest"); err == nil {
+		t.Error("expected an error for an unknown placement")
+	}
+
+	n := placementNetwork(t, "labels:x")
+	if _, err := n.CreateHosts(1); err == nil {
+		t.Error("expected an error for an unknown label")
+	}
+
+	topo := placementTopology(t)
+	for nodeId, attrs := range topo.NodeAttrs {
+		if attrs[util.LABEL_ATTR] == "f" {
+			attrs[util.LABEL_ATTR] = "g"
+			topo.NodeAttrs[nodeId] = attrs
+		}
+	}
+	n, err := NewNetwork(topo, NetworkOptions{HostPlacement: &LabelPlacement{Labels: []string{"g"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = n.CreateHosts(1)
+	if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected an error for a label shared by two nodes, got %v", err)
+	}
+}
@@ -27,3 +27,19 @@ func (l *Link) FromPort() int64 {
 func (l *Link) ToPort() int64 {
 	return l.toPort
 }
+
+// returns the port of the link at the end of the given topology node
+func (l *Link) ownPort(nodeId int64) int64 {
+	if l.topoEdge.From().ID() == nodeId {
+		return l.fromPort
+	}
+	return l.toPort
+}
+
+// returns the port of the link at the end opposite to the given topology node
+func (l *Link) peerPort(nodeId int64) int64 {
+	if l.topoEdge.From().ID() == nodeId {
+		return l.toPort
+	}
+	return l.fromPort
+}
@@ -0,0 +1,441 @@
+/*
+Package metrics measures the size and complexity of encoded DyNetKAT programs, e.g. to pick
+benchmarks by the complexity of their encodings rather than by the size of their topologies.
+The metrics count the terms of the program as the encoder writes them, whatever its options,
+either built from the network or parsed from an encoding.
+*/
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/decode"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/encode"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/netkat"
+)
+
+var METRICS_COLUMNS = []string{
+	"flowRules",
+	"newFlowRules",
+	"maxSwitchRules",
+	"atoms",
+	"recursiveVars",
+	"channels",
+	"choiceWidth",
+	"stateSpace",
+}
+
+// The flow rules of the terms of a switch, before (SW<i>) and after (SW<i>') its update
+type SwitchMetrics struct {
+	Name     string
+	Rules    int
+	NewRules int
+	Updated  bool
+}
+
+type Metrics struct {
+	Switches       []SwitchMetrics // in the order of the program
+	FlowRules      int             // in the switch terms before the updates
+	NewFlowRules   int             // in the terms of the updated switches
+	MaxSwitchRules int             // in the largest switch term
+	Atoms          int             // tests, assignments and dups in all the NetKAT policies
+	Definitions    int
+	RecursiveVars  int // definitions that can reach themselves
+	Channels       int
+	ChoiceWidth    int // largest number of branches of a non-deterministic choice
+	/*
+		Estimate of the number of configurations of the SDN term: the product, over its parallel
+		terms, of the number of definitions each of them can reach, e.g. 2^u for u updated
+		switches with one controller. The packets and the intermediate steps of the
+		communications are not counted.
+	*/
+	StateSpace float64
+}
+
+/*
+Returns the metrics of the encoding of the network with the LatexEncoder and the given options.
+The terms of the program are built from the network one definition at a time, as the encoder
+writes them, but they are neither formatted nor parsed.
+*/
+func Compute(n *convert.Network, opts encode.LatexEncoderOptions) (Metrics, error) {
+	if n == nil {
+		return Metrics{}, errors.New("Received nil network!")
+	}
+
+	encoder := encode.NewLatexEncoderWithOptions(opts)
+	encoder.Prepare(n)
+	c := newCollector()
+	sdnTerms := []string{}
+
+	for _, sw := range n.Switches() {
+		id := sw.TopoNode().ID()
+		swName := fmt.Sprintf("%s%d", encode.SW_BASE_NAME, id)
+		newSwName := swName + "'"
+		newFlowTable, willReceiveUpdate := convert.NewFlowTable(), false
+		if sw.Controller() != nil {
+			newFlowTable, willReceiveUpdate = sw.Controller().NewFlowTables()[id]
+		}
+
+		branches := prefixes(encoder.FlowTablePolicies(sw.FlowTable(), id), swName)
+		if len(branches) != 0 || willReceiveUpdate {
+			if len(branches) == 0 {
+				// the switch drops all packets until it is updated
+				branches = append(branches, decode.Prefix{Policy: netkat.Zero{}, Cont: decode.Var{Name: swName}})
+			}
+			branches = append(branches, communication(id, newSwName, opts.ProactiveSwitch))
+			c.add(swName, choice(branches))
+			sdnTerms = append(sdnTerms, swName)
+		}
+
+		if !willReceiveUpdate {
+			continue
+		}
+		newBranches := prefixes(encoder.FlowTablePolicies(newFlowTable, id), newSwName)
+		if len(newBranches) != 0 {
+			c.add(newSwName, choice(newBranches))
+		}
+	}
+
+	for _, controller := range n.Controllers() {
+		if len(controller.NewFlowTables()) == 0 {
+			continue
+		}
+
+		cName := fmt.Sprintf("%s%d", encode.CONTROLLER_BASE_NAME, controller.ID())
+		branches := []decode.Term{}
+		for _, id := range slices.Sorted(maps.Keys(controller.NewFlowTables())) {
+			branches = append(branches, communication(id, cName, opts.ProactiveSwitch))
+		}
+		c.add(cName, choice(branches))
+		sdnTerms = append(sdnTerms, cName)
+	}
+
+	if opts.LinkTerms {
+		c.add(encode.TOPOLOGY_TERM_NAME, decode.Prefix{Policy: n.TopologyPolicy()})
+		c.add(encode.NETWORK_TERM_NAME, decode.Prefix{Policy: n.NetKATModel()})
+	}
+
+	sdnVars := []decode.Term{}
+	for _, name := range sdnTerms {
+		sdnVars = append(sdnVars, decode.Var{Name: name})
+	}
+	sdnTerm := decode.Term(decode.Par{Terms: sdnVars})
+	if len(sdnVars) == 1 {
+		sdnTerm = sdnVars[0]
+	}
+	c.add(encode.SDN_TERM_NAME, sdnTerm)
+
+	return c.result(sdnTerms), nil
+}
+
+// Returns the metrics of a parsed program
+func FromProgram(p *decode.Program) Metrics {
+	c := newCollector()
+	for _, def := range p.Defs {
+		c.add(def.Name, def.Body)
+	}
+	return c.result(roots(p, c.refs))
+}
+
+// returns the branches '(policy) ; name' of a term
+func prefixes(policies []netkat.Policy, name string) []decode.Term {
+	terms := []decode.Term{}
+	for _, policy := range policies {
+		terms = append(terms, decode.Prefix{Policy: policy, Cont: decode.Var{Name: name}})
+	}
+	return terms
+}
+
+// returns the communication of an update over the channels of the switch, followed by the named term
+func communication(channelId int64, name string, proactiveSwitch bool) decode.Term {
+	var term decode.Term = decode.Comm{
+		Channel: fmt.Sprintf("%s%d", encode.UP_CHANNEL_NAME, channelId),
+		Send:    true,
+		Msg:     netkat.One{},
+		Cont:    decode.Var{Name: name},
+	}
+	if proactiveSwitch {
+		term = decode.Comm{
+			Channel: fmt.Sprintf("%s%d", encode.HELP_CHANNEL_NAME, channelId),
+			Msg:     netkat.One{},
+			Cont:    term,
+		}
+	}
+	return term
+}
+
+func choice(terms []decode.Term) decode.Term {
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	return decode.Choice{Terms: terms}
+}
+
+// accumulates the metrics of the definitions of a program, one definition at a time
+type collector struct {
+	m        Metrics
+	defNames []string
+	refs     map[string][]string // maps a definition to the definitions it refers to
+	channels map[string]bool
+	swIndex  map[string]int
+}
+
+func newCollector() *collector {
+	return &collector{
+		m:        Metrics{Switches: []SwitchMetrics{}},
+		defNames: []string{},
+		refs:     make(map[string][]string),
+		channels: make(map[string]bool),
+		swIndex:  make(map[string]int),
+	}
+}
+
+func (c *collector) add(name string, body decode.Term) {
+	m := &c.m
+	m.Definitions++
+	c.defNames = append(c.defNames, name)
+
+	tw := &termWalker{channels: c.channels}
+	tw.walk(body)
+	c.refs[name] = tw.refs
+	m.Atoms += tw.atoms
+	m.ChoiceWidth = max(m.ChoiceWidth, tw.choiceWidth)
+
+	swName, isNew := strings.CutSuffix(name, "'")
+	id, isSw := strings.CutPrefix(swName, encode.SW_BASE_NAME)
+	if _, err := strconv.ParseInt(id, 10, 64); !isSw || err != nil {
+		return
+	}
+
+	i, exists := c.swIndex[swName]
+	if !exists {
+		i = len(m.Switches)
+		c.swIndex[swName] = i
+		m.Switches = append(m.Switches, SwitchMetrics{Name: swName})
+	}
+	rules := flowRules(body)
+	if isNew {
+		m.Switches[i].NewRules, m.Switches[i].Updated = rules, true
+		m.NewFlowRules += rules
+	} else {
+		m.Switches[i].Rules = rules
+		m.FlowRules += rules
+	}
+	m.MaxSwitchRules = max(m.MaxSwitchRules, rules)
+}
+
+// returns the metrics of the added definitions, given the parallel terms of the SDN term
+func (c *collector) result(roots []string) Metrics {
+	m := c.m
+	m.Channels = len(c.channels)
+
+	for _, name := range c.defNames {
+		if slices.Contains(reachable(name, c.refs, false), name) {
+			m.RecursiveVars++
+		}
+	}
+
+	m.StateSpace = 1
+	for _, root := range roots {
+		m.StateSpace *= float64(len(reachable(root, c.refs, true)))
+	}
+	return m
+}
+
+// counts the flow rules of a switch term as the products of the policies of its branches
+func flowRules(body decode.Term) int {
+	rules := 0
+	choices := []decode.Term{body}
+	if choice, isChoice := body.(decode.Choice); isChoice {
+		choices = choice.Terms
+	}
+
+	for _, branch := range choices {
+		if prefix, isPrefix := branch.(decode.Prefix); isPrefix {
+			products, err := netkat.Products(prefix.Policy)
+			if err != nil {
+				// policies that cannot be written as products count as one rule
+				rules++
+				continue
+			}
+			rules += len(products)
+		}
+	}
+	return rules
+}
+
+/*
+Returns the parallel terms of the SDN term or, if the program has none, the definitions that
+no other definition refers to
+*/
+func roots(p *decode.Program, refs map[string][]string) []string {
+	if body, exists := p.Lookup(encode.SDN_TERM_NAME); exists {
+		terms := []decode.Term{body}
+		if par, isPar := body.(decode.Par); isPar {
+			terms = par.Terms
+		}
+
+		names := []string{}
+		for _, term := range terms {
+			if v, isVar := term.(decode.Var); isVar {
+				names = append(names, v.Name)
+			}
+		}
+		return names
+	}
+
+	referenced := make(map[string]bool)
+	for name, defRefs := range refs {
+		for _, ref := range defRefs {
+			if ref != name {
+				referenced[ref] = true
+			}
+		}
+	}
+	names := []string{}
+	for _, def := range p.Defs {
+		if !referenced[def.Name] {
+			names = append(names, def.Name)
+		}
+	}
+	return names
+}
+
+// returns the definitions that can be reached from the given one, which is included if 'withSelf' is set
+func reachable(name string, refs map[string][]string, withSelf bool) []string {
+	visited := map[string]bool{}
+	queue := slices.Clone(refs[name])
+	if withSelf {
+		queue = append(queue, name)
+	}
+
+	result := []string{}
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+		if visited[curr] {
+			continue
+		}
+		visited[curr] = true
+		result = append(result, curr)
+		queue = append(queue, refs[curr]...)
+	}
+	return result
+}
+
+// collects the references, channels, atoms and choices of a term
+type termWalker struct {
+	refs        []string
+	channels    map[string]bool
+	atoms       int
+	choiceWidth int
+}
+
+func (tw *termWalker) walk(t decode.Term) {
+	switch t := t.(type) {
+	case decode.Var:
+		if !slices.Contains(tw.refs, t.Name) {
+			tw.refs = append(tw.refs, t.Name)
+		}
+	case decode.Prefix:
+		tw.atoms += countAtoms(t.Policy)
+		if t.Cont != nil {
+			tw.walk(t.Cont)
+		}
+	case decode.Comm:
+		tw.channels[t.Channel] = true
+		tw.atoms += countAtoms(t.Msg)
+		tw.walk(t.Cont)
+	case decode.Choice:
+		tw.choiceWidth = max(tw.choiceWidth, len(t.Terms))
+		for _, term := range t.Terms {
+			tw.walk(term)
+		}
+	case decode.Par:
+		for _, term := range t.Terms {
+			tw.walk(term)
+		}
+	}
+}
+
+func countAtoms(p netkat.Policy) int {
+	switch p := p.(type) {
+	case netkat.Test, netkat.Assign, netkat.Dup:
+		return 1
+	case netkat.Neg:
+		return countAtoms(p.Pred)
+	case netkat.And:
+		return sumAtoms(p.Preds)
+	case netkat.Or:
+		return sumAtoms(p.Preds)
+	case netkat.Union:
+		return sumAtoms(p.Pols)
+	case netkat.Seq:
+		return sumAtoms(p.Pols)
+	case netkat.Star:
+		return countAtoms(p.Pol)
+	default:
+		return 0
+	}
+}
+
+func sumAtoms[P netkat.Policy](pols []P) int {
+	atoms := 0
+	for _, pol := range pols {
+		atoms += countAtoms(pol)
+	}
+	return atoms
+}
+
+func (m Metrics) Row() []string {
+	return []string{
+		strconv.Itoa(m.FlowRules),
+		strconv.Itoa(m.NewFlowRules),
+		strconv.Itoa(m.MaxSwitchRules),
+		strconv.Itoa(m.Atoms),
+		strconv.Itoa(m.RecursiveVars),
+		strconv.Itoa(m.Channels),
+		strconv.Itoa(m.ChoiceWidth),
+		formatStateSpace(m.StateSpace),
+	}
+}
+
+// writes large state spaces as powers of 2, e.g. '2^40'
+func formatStateSpace(stateSpace float64) string {
+	if stateSpace < 1<<20 {
+		return strconv.FormatFloat(stateSpace, 'f', 0, 64)
+	}
+	return fmt.Sprintf("2^%.1f", math.Log2(stateSpace))
+}
+
+func (m Metrics) String() string {
+	updated := 0
+	for _, sw := range m.Switches {
+		if sw.Updated {
+			updated++
+		}
+	}
+
+	return fmt.Sprintf(
+		"%d flow rules in %d switches (%d in the %d updated ones, at most %d per switch term), %d NetKAT atoms, "+
+			"%d definitions (%d recursive), %d channels, choices of up to %d branches, ~%s configurations",
+		m.FlowRules,
+		len(m.Switches),
+		m.NewFlowRules,
+		updated,
+		m.MaxSwitchRules,
+		m.Atoms,
+		m.Definitions,
+		m.RecursiveVars,
+		m.Channels,
+		m.ChoiceWidth,
+		formatStateSpace(m.StateSpace),
+	)
+}
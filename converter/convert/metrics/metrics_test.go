@@ -0,0 +1,72 @@
+package metrics_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert/decode"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/encode"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/metrics"
+	behavior "utwente.nl/topology-to-dynetkat-coverter/convert/network_behavior"
+	"utwente.nl/topology-to-dynetkat-coverter/dynetkat"
+)
+
+// the metrics built from the network must be those of the parsed encoding
+func TestComputeMatchesParsedEncoding(t *testing.T) {
+	specs := []string{"ring:6", "grid:3x3", "fattree:4", "waxman:20:0.4:0.2"}
+	difficulties := []behavior.Difficulty{0, behavior.EASY, behavior.HARD}
+	options := []encode.LatexEncoderOptions{
+		{},
+		{ProactiveSwitch: true},
+		{CompactPolicies: true},
+		{LinkTerms: true},
+		{OptimizeFlowTables: true},
+		{LinkTerms: true, OptimizeFlowTables: true, CompactPolicies: true},
+	}
+
+	for _, spec := range specs {
+		for _, difficulty := range difficulties {
+			network, err := dynetkat.Generate(
+				context.Background(),
+				dynetkat.Synthetic(spec),
+				dynetkat.Scenario{Difficulty: difficulty},
+				dynetkat.Options{},
+			)
+			if err != nil {
+				t.Fatalf("%s: %s", spec, err)
+			}
+
+			for _, opts := range options {
+				t.Run(fmt.Sprintf("%s/%s/%+v", spec, difficulty, opts), func(t *testing.T) {
+					encoder := encode.NewLatexEncoderWithOptions(opts)
+					content, err := encoder.Encode(network.Unwrap())
+					if err != nil {
+						t.Fatal(err)
+					}
+					program, err := decode.NewLatexParser().ParseString(content)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					got, err := metrics.Compute(network.Unwrap(), opts)
+					if err != nil {
+						t.Fatal(err)
+					}
+					want := metrics.FromProgram(program)
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("got metrics\n%s\nwant\n%s", got, want)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestComputeNilNetwork(t *testing.T) {
+	_, err := metrics.Compute(nil, encode.LatexEncoderOptions{})
+	if err == nil {
+		t.Error("expected an error for a nil network")
+	}
+}
@@ -0,0 +1,146 @@
+package netkat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The symbols used to print policies
+type Symbols struct {
+	ZERO   string
+	ONE    string
+	EQ     string
+	ASSIGN string
+	NEG    string
+	AND    string // conjunction and sequential composition
+	OR     string // disjunction and union
+	STAR   string
+	DUP    string
+}
+
+var PLAIN_SYMBOLS = Symbols{
+	ZERO:   "0",
+	ONE:    "1",
+	EQ:     "=",
+	ASSIGN: "<-",
+	NEG:    "~",
+	AND:    ";",
+	OR:     "+",
+	STAR:   "*",
+	DUP:    "dup",
+}
+
+// binding strength of the operators, from the weakest to the strongest
+const (
+	UNION_PREC = iota
+	SEQ_PREC
+	NEG_PREC
+	STAR_PREC
+	ATOM_PREC
+)
+
+/*
+Prints the policy with the given symbols, adding parentheses only where the operator
+precedence requires them. Tests and assignments are always wrapped in parentheses.
+*/
+func Format(p Policy, sym Symbols) string {
+	var sb strings.Builder
+	format(&sb, p, sym)
+	return sb.String()
+}
+
+func (t Test) String() string {
+	return Format(t, PLAIN_SYMBOLS)
+}
+
+func (a Assign) String() string {
+	return Format(a, PLAIN_SYMBOLS)
+}
+
+func precedence(p Policy) int {
+	switch p := p.(type) {
+	case Union, Or:
+		return UNION_PREC
+	case Seq, And:
+		return SEQ_PREC
+	case Neg:
+		return NEG_PREC
+	case Star:
+		return STAR_PREC
+	case Zero, One, Test, Assign, Dup:
+		return ATOM_PREC
+	default:
+		panic(fmt.Sprintf("Unknown NetKAT policy: %T", p))
+	}
+}
+
+func format(sb *strings.Builder, p Policy, sym Symbols) {
+	switch p := p.(type) {
+	case Zero:
+		sb.WriteString(sym.ZERO)
+	case One:
+		sb.WriteString(sym.ONE)
+	case Dup:
+		sb.WriteString(sym.DUP)
+	case Test:
+		sb.WriteString(fmt.Sprintf("(%s %s %s)", p.Field, sym.EQ, p.Value))
+	case Assign:
+		sb.WriteString(fmt.Sprintf("(%s %s %s)", p.Field, sym.ASSIGN, p.Value))
+	case Neg:
+		sb.WriteString(sym.NEG)
+		formatOperand(sb, p.Pred, NEG_PREC+1, sym)
+	case Star:
+		formatOperand(sb, p.Pol, STAR_PREC+1, sym)
+		sb.WriteString(sym.STAR)
+	case And:
+		formatJoined(sb, predsToPolicies(p.Preds), sym.AND, SEQ_PREC, sym)
+	case Seq:
+		formatJoined(sb, p.Pols, sym.AND, SEQ_PREC, sym)
+	case Or:
+		formatJoined(sb, predsToPolicies(p.Preds), " "+sym.OR+" ", UNION_PREC, sym)
+	case Union:
+		formatJoined(sb, p.Pols, " "+sym.OR+" ", UNION_PREC, sym)
+	default:
+		panic(fmt.Sprintf("Unknown NetKAT policy: %T", p))
+	}
+}
+
+func formatJoined(sb *strings.Builder, pols []Policy, sep string, prec int, sym Symbols) {
+	if len(pols) == 0 {
+		// empty conjunctions and sequences are 1, empty disjunctions and unions are 0
+		if prec == SEQ_PREC {
+			sb.WriteString(sym.ONE)
+		} else {
+			sb.WriteString(sym.ZERO)
+		}
+		return
+	}
+
+	for i, pol := range pols {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		// no parentheses are needed between operators of the same precedence, since
+		// conjunction and sequence (or disjunction and union) coincide on predicates
+		formatOperand(sb, pol, prec, sym)
+	}
+}
+
+func formatOperand(sb *strings.Builder, p Policy, minPrec int, sym Symbols) {
+	if precedence(p) >= minPrec {
+		format(sb, p, sym)
+		return
+	}
+
+	sb.WriteString("(")
+	format(sb, p, sym)
+	sb.WriteString(")")
+}
+
+func predsToPolicies(preds []Predicate) []Policy {
+	pols := make([]Policy, len(preds))
+	for i, pred := range preds {
+		pols[i] = pred
+	}
+	return pols
+}
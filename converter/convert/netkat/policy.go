@@ -0,0 +1,144 @@
+/*
+Package netkat defines the abstract syntax of NetKAT policies: predicates (tests, negation,
+conjunction, disjunction, 0 and 1) and policies (predicates, assignments, union, sequential
+composition, Kleene star and dup).
+*/
+package netkat
+
+// A NetKAT policy. Every predicate is also a policy.
+type Policy interface {
+	isPolicy()
+}
+
+// A NetKAT predicate, i.e. a policy that only filters packets
+type Predicate interface {
+	Policy
+	isPredicate()
+}
+
+type (
+	// Drops all packets (false)
+	Zero struct{}
+
+	// Lets all packets through unchanged (true)
+	One struct{}
+
+	// Checks that a packet field has the given value
+	Test struct {
+		Field string
+		Value string
+	}
+
+	// Negation of a predicate
+	Neg struct {
+		Pred Predicate
+	}
+
+	// Conjunction of predicates
+	And struct {
+		Preds []Predicate
+	}
+
+	// Disjunction of predicates
+	Or struct {
+		Preds []Predicate
+	}
+
+	// Assigns a value to a packet field
+	Assign struct {
+		Field string
+		Value string
+	}
+
+	// Union (non-deterministic choice) of policies
+	Union struct {
+		Pols []Policy
+	}
+
+	// Sequential composition of policies
+	Seq struct {
+		Pols []Policy
+	}
+
+	// Kleene star of a policy
+	Star struct {
+		Pol Policy
+	}
+
+	// Records the current packet in the packet history
+	Dup struct{}
+)
+
+func (Zero) isPolicy()   {}
+func (One) isPolicy()    {}
+func (Test) isPolicy()   {}
+func (Neg) isPolicy()    {}
+func (And) isPolicy()    {}
+func (Or) isPolicy()     {}
+func (Assign) isPolicy() {}
+func (Union) isPolicy()  {}
+func (Seq) isPolicy()    {}
+func (Star) isPolicy()   {}
+func (Dup) isPolicy()    {}
+
+func (Zero) isPredicate() {}
+func (One) isPredicate()  {}
+func (Test) isPredicate() {}
+func (Neg) isPredicate()  {}
+func (And) isPredicate()  {}
+func (Or) isPredicate()   {}
+
+func NewTest(field, value string) Test {
+	return Test{Field: field, Value: value}
+}
+
+func NewAssign(field, value string) Assign {
+	return Assign{Field: field, Value: value}
+}
+
+func Not(pred Predicate) Neg {
+	return Neg{Pred: pred}
+}
+
+func Conj(preds ...Predicate) And {
+	return And{Preds: preds}
+}
+
+func Disj(preds ...Predicate) Or {
+	return Or{Preds: preds}
+}
+
+func NewUnion(pols ...Policy) Union {
+	return Union{Pols: pols}
+}
+
+func NewSeq(pols ...Policy) Seq {
+	return Seq{Pols: pols}
+}
+
+func NewStar(pol Policy) Star {
+	return Star{Pol: pol}
+}
+
+// Returns 'if pred then thenPol else elsePol', i.e. (pred · thenPol) + (¬pred · elsePol)
+func IfThenElse(pred Predicate, thenPol, elsePol Policy) Policy {
+	return NewUnion(NewSeq(pred, thenPol), NewSeq(Not(pred), elsePol))
+}
+
+/*
+Returns the prioritised (guarded) choice between the given cases: the policy of the first
+case whose guard holds is applied, and packets matching no guard are dropped. Case i is
+encoded as ¬guard_1 · ... · ¬guard_(i-1) · guard_i · pol_i and the cases are joined by union.
+*/
+func GuardedChoice(guards []Predicate, pols []Policy) Policy {
+	cases := []Policy{}
+	for i := range guards {
+		preds := []Predicate{}
+		for _, higherGuard := range guards[:i] {
+			preds = append(preds, Not(higherGuard))
+		}
+		preds = append(preds, guards[i])
+		cases = append(cases, NewSeq(Conj(preds...), pols[i]))
+	}
+	return NewUnion(cases...)
+}
@@ -0,0 +1,101 @@
+package netkat
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+A product of a policy in sum-of-products form: the tests and negated predicates that a packet
+has to pass, followed by the assignments applied to it.
+*/
+type Product struct {
+	Tests   []Test
+	Negs    []Predicate // the predicates of the negations, e.g. b for ¬b
+	Assigns []Assign
+}
+
+/*
+Returns the policy as a union of products, distributing sequential composition over union,
+e.g. a · (b + c) · (p <- 1) becomes a · b · (p <- 1) + a · c · (p <- 1). Products that test a
+field for two different values are left out, since they drop all packets. Policies with Kleene
+star or dup, and tests following assignments, are not supported.
+*/
+func Products(p Policy) ([]Product, error) {
+	switch p := p.(type) {
+	case Zero:
+		return []Product{}, nil
+	case One:
+		return []Product{{}}, nil
+	case Test:
+		return []Product{{Tests: []Test{p}}}, nil
+	case Neg:
+		return []Product{{Negs: []Predicate{p.Pred}}}, nil
+	case Assign:
+		return []Product{{Assigns: []Assign{p}}}, nil
+	case And:
+		return seqProducts(predsToPolicies(p.Preds))
+	case Seq:
+		return seqProducts(p.Pols)
+	case Or:
+		return unionProducts(predsToPolicies(p.Preds))
+	case Union:
+		return unionProducts(p.Pols)
+	default:
+		return []Product{}, errors.New(fmt.Sprintf("Cannot write %T policies as a sum of products!", p))
+	}
+}
+
+func unionProducts(pols []Policy) ([]Product, error) {
+	products := []Product{}
+	for _, pol := range pols {
+		polProducts, err := Products(pol)
+		if err != nil {
+			return []Product{}, err
+		}
+		products = append(products, polProducts...)
+	}
+	return products, nil
+}
+
+func seqProducts(pols []Policy) ([]Product, error) {
+	products := []Product{{}}
+	for _, pol := range pols {
+		polProducts, err := Products(pol)
+		if err != nil {
+			return []Product{}, err
+		}
+
+		combined := []Product{}
+		for _, first := range products {
+			for _, second := range polProducts {
+				if len(first.Assigns) > 0 && (len(second.Tests) > 0 || len(second.Negs) > 0) {
+					return []Product{}, errors.New("Tests after assignments are not supported!")
+				}
+
+				product := Product{
+					Tests:   append(append([]Test{}, first.Tests...), second.Tests...),
+					Negs:    append(append([]Predicate{}, first.Negs...), second.Negs...),
+					Assigns: append(append([]Assign{}, first.Assigns...), second.Assigns...),
+				}
+				if !contradicts(product.Tests) {
+					combined = append(combined, product)
+				}
+			}
+		}
+		products = combined
+	}
+	return products, nil
+}
+
+// checks whether the tests require a field to have two different values
+func contradicts(tests []Test) bool {
+	values := make(map[string]string)
+	for _, test := range tests {
+		if value, exists := values[test.Field]; exists && value != test.Value {
+			return true
+		}
+		values[test.Field] = test.Value
+	}
+	return false
+}
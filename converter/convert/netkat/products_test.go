@@ -0,0 +1,95 @@
+package netkat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProductsDistributesSeqOverUnion(t *testing.T) {
+	a, b, c := NewTest("dst", "1"), NewTest("port", "2"), NewTest("port", "3")
+	assign := NewAssign("port", "4")
+
+	products, err := Products(NewSeq(a, NewUnion(b, c), assign))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Product{
+		{Tests: []Test{a, b}, Negs: []Predicate{}, Assigns: []Assign{assign}},
+		{Tests: []Test{a, c}, Negs: []Predicate{}, Assigns: []Assign{assign}},
+	}
+	if !reflect.DeepEqual(products, want) {
+		t.Errorf("got %+v, want %+v", products, want)
+	}
+}
+
+func TestProductsKeepsNegations(t *testing.T) {
+	a, b := NewTest("dst", "1"), NewTest("port", "2")
+	assign := NewAssign("port", "4")
+
+	products, err := Products(NewSeq(Conj(Not(a), b), assign))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Product{{Tests: []Test{b}, Negs: []Predicate{a}, Assigns: []Assign{assign}}}
+	if !reflect.DeepEqual(products, want) {
+		t.Errorf("got %+v, want %+v", products, want)
+	}
+}
+
+func TestProductsPrunesContradictions(t *testing.T) {
+	policy := NewSeq(
+		NewTest("port", "1"),
+		NewUnion(NewTest("port", "1"), NewTest("port", "2")),
+		NewAssign("port", "3"),
+	)
+
+	products, err := Products(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 1 {
+		t.Fatalf("got %d products, want 1: %+v", len(products), products)
+	}
+	for _, test := range products[0].Tests {
+		if test.Value != "1" {
+			t.Errorf("kept the contradicting test %s", test)
+		}
+	}
+}
+
+func TestProductsOfZeroAndOne(t *testing.T) {
+	products, err := Products(Zero{})
+	if err != nil || len(products) != 0 {
+		t.Errorf("Zero: got %+v, %v, want no products", products, err)
+	}
+
+	products, err = Products(One{})
+	if err != nil || len(products) != 1 {
+		t.Errorf("One: got %+v, %v, want one empty product", products, err)
+	}
+}
+
+func TestProductsRejectsTestsAfterAssignments(t *testing.T) {
+	policies := []Policy{
+		NewSeq(NewAssign("port", "1"), NewTest("dst", "2")),
+		NewSeq(NewAssign("port", "1"), Not(NewTest("dst", "2"))),
+	}
+
+	for _, policy := range policies {
+		_, err := Products(policy)
+		if err == nil {
+			t.Errorf("expected an error for %+v", policy)
+		}
+	}
+}
+
+func TestProductsRejectsStarAndDup(t *testing.T) {
+	for _, policy := range []Policy{NewStar(NewTest("dst", "1")), NewSeq(NewTest("dst", "1"), Dup{})} {
+		_, err := Products(policy)
+		if err == nil {
+			t.Errorf("expected an error for %+v", policy)
+		}
+	}
+}
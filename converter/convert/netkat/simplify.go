@@ -0,0 +1,308 @@
+package netkat
+
+/*
+Simplifies the policy using the NetKAT axioms: 0 and 1 are absorbed or propagated,
+nested unions, sequences, conjunctions and disjunctions are flattened, duplicates are removed,
+consecutive predicates of a sequence are merged into one conjunction, contradicting tests
+become 0, negated tests implied by other tests are dropped or reduced to the tests that are
+not implied, and tests that follow an assignment to the same field are resolved.
+*/
+func Simplify(p Policy) Policy {
+	switch p := p.(type) {
+	case Predicate:
+		return simplifyPred(p)
+	case Union:
+		return simplifyUnion(p)
+	case Seq:
+		return simplifySeq(p)
+	case Star:
+		inner := Simplify(p.Pol)
+		switch inner := inner.(type) {
+		case Zero, One:
+			return One{}
+		case Star:
+			return inner
+		}
+		return Star{Pol: inner}
+	}
+	return p
+}
+
+func simplifyPred(p Predicate) Predicate {
+	switch p := p.(type) {
+	case Neg:
+		inner := simplifyPred(p.Pred)
+		switch inner := inner.(type) {
+		case Zero:
+			return One{}
+		case One:
+			return Zero{}
+		case Neg:
+			return inner.Pred
+		}
+		return Neg{Pred: inner}
+	case And:
+		return simplifyAnd(p.Preds)
+	case Or:
+		return simplifyOr(p.Preds)
+	}
+	return p
+}
+
+func simplifyAnd(preds []Predicate) Predicate {
+	flat := []Predicate{}
+	for _, pred := range preds {
+		switch pred := simplifyPred(pred).(type) {
+		case Zero:
+			return Zero{}
+		case One:
+			continue
+		case And:
+			flat = append(flat, pred.Preds...)
+		default:
+			flat = append(flat, pred)
+		}
+	}
+
+	// the field values required by the positive tests of the conjunction
+	required := make(map[string]string)
+	for _, pred := range flat {
+		if test, isTest := pred.(Test); isTest {
+			if value, exists := required[test.Field]; exists && value != test.Value {
+				return Zero{}
+			}
+			required[test.Field] = test.Value
+		}
+	}
+
+	result := []Predicate{}
+	for _, pred := range dedupePreds(flat) {
+		neg, isNeg := pred.(Neg)
+		if !isNeg {
+			result = append(result, pred)
+			continue
+		}
+
+		switch testsImplied(neg.Pred, required) {
+		case IMPLIED:
+			return Zero{}
+		case CONTRADICTED:
+			continue
+		}
+		result = append(result, dropImpliedTests(neg, required))
+	}
+
+	switch len(result) {
+	case 0:
+		return One{}
+	case 1:
+		return result[0]
+	}
+	return And{Preds: result}
+}
+
+type implication int
+
+const (
+	UNKNOWN      implication = iota
+	IMPLIED                  // the predicate always holds given the required values
+	CONTRADICTED             // the predicate never holds given the required values
+)
+
+// checks whether a test or a conjunction of tests follows from, or contradicts, the required field values
+func testsImplied(pred Predicate, required map[string]string) implication {
+	tests := []Predicate{pred}
+	if and, isAnd := pred.(And); isAnd {
+		tests = and.Preds
+	}
+
+	allImplied := true
+	for _, p := range tests {
+		test, isTest := p.(Test)
+		if !isTest {
+			allImplied = false
+			continue
+		}
+
+		value, exists := required[test.Field]
+		switch {
+		case !exists:
+			allImplied = false
+		case value != test.Value:
+			return CONTRADICTED
+		}
+	}
+
+	if allImplied {
+		return IMPLIED
+	}
+	return UNKNOWN
+}
+
+// drops the tests of a negated conjunction that already follow from the required values, e.g. a · ¬(a · b) = a · ¬b
+func dropImpliedTests(neg Neg, required map[string]string) Predicate {
+	and, isAnd := neg.Pred.(And)
+	if !isAnd {
+		return neg
+	}
+
+	remaining := []Predicate{}
+	for _, pred := range and.Preds {
+		if test, isTest := pred.(Test); isTest {
+			if value, exists := required[test.Field]; exists && value == test.Value {
+				continue
+			}
+		}
+		remaining = append(remaining, pred)
+	}
+
+	if len(remaining) == 1 {
+		return Not(remaining[0])
+	}
+	return Not(And{Preds: remaining})
+}
+
+func simplifyOr(preds []Predicate) Predicate {
+	flat := []Predicate{}
+	for _, pred := range preds {
+		switch pred := simplifyPred(pred).(type) {
+		case One:
+			return One{}
+		case Zero:
+			continue
+		case Or:
+			flat = append(flat, pred.Preds...)
+		default:
+			flat = append(flat, pred)
+		}
+	}
+
+	flat = dedupePreds(flat)
+	switch len(flat) {
+	case 0:
+		return Zero{}
+	case 1:
+		return flat[0]
+	}
+	return Or{Preds: flat}
+}
+
+func simplifyUnion(u Union) Policy {
+	flat := []Policy{}
+	for _, pol := range u.Pols {
+		switch pol := Simplify(pol).(type) {
+		case Zero:
+			continue
+		case Union:
+			flat = append(flat, pol.Pols...)
+		default:
+			flat = append(flat, pol)
+		}
+	}
+
+	flat = dedupe(flat)
+	switch len(flat) {
+	case 0:
+		return Zero{}
+	case 1:
+		return flat[0]
+	}
+	return Union{Pols: flat}
+}
+
+func simplifySeq(s Seq) Policy {
+	flat := []Policy{}
+	for _, pol := range s.Pols {
+		switch pol := Simplify(pol).(type) {
+		case Zero:
+			return Zero{}
+		case One:
+			continue
+		case Seq:
+			flat = append(flat, pol.Pols...)
+		default:
+			flat = append(flat, pol)
+		}
+	}
+
+	result := []Policy{}
+	for _, pol := range flat {
+		if len(result) == 0 {
+			result = append(result, pol)
+			continue
+		}
+
+		last := result[len(result)-1]
+		pred, isPred := pol.(Predicate)
+		lastPred, lastIsPred := last.(Predicate)
+		lastAssign, lastIsAssign := last.(Assign)
+
+		switch {
+		case isPred && lastIsPred:
+			merged := simplifyAnd([]Predicate{lastPred, pred})
+			if _, isZero := merged.(Zero); isZero {
+				return Zero{}
+			}
+			result[len(result)-1] = merged
+		case isPred && lastIsAssign:
+			// the assigned value decides the tests on the same field
+			required := map[string]string{lastAssign.Field: lastAssign.Value}
+			implied := testsImplied(pred, required)
+			if neg, isNeg := pred.(Neg); isNeg {
+				implied = map[implication]implication{
+					IMPLIED:      CONTRADICTED,
+					CONTRADICTED: IMPLIED,
+				}[testsImplied(neg.Pred, required)]
+			}
+
+			switch implied {
+			case CONTRADICTED:
+				return Zero{}
+			case UNKNOWN:
+				result = append(result, pol)
+			}
+		case lastIsAssign:
+			if assign, isAssign := pol.(Assign); isAssign && assign.Field == lastAssign.Field {
+				result[len(result)-1] = assign
+				continue
+			}
+			result = append(result, pol)
+		default:
+			result = append(result, pol)
+		}
+	}
+
+	switch len(result) {
+	case 0:
+		return One{}
+	case 1:
+		return result[0]
+	}
+	return Seq{Pols: result}
+}
+
+func dedupe(pols []Policy) []Policy {
+	seen := make(map[string]bool)
+	result := []Policy{}
+	for _, pol := range pols {
+		key := Format(pol, PLAIN_SYMBOLS)
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, pol)
+		}
+	}
+	return result
+}
+
+func dedupePreds(preds []Predicate) []Predicate {
+	seen := make(map[string]bool)
+	result := []Predicate{}
+	for _, pred := range preds {
+		key := Format(pred, PLAIN_SYMBOLS)
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, pred)
+		}
+	}
+	return result
+}
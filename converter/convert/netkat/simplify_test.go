@@ -0,0 +1,69 @@
+package netkat
+
+import "testing"
+
+var (
+	dstTest    = NewTest("dst", "1")
+	portTest   = NewTest("port", "2")
+	otherPort  = NewTest("port", "3")
+	portAssign = NewAssign("port", "4")
+)
+
+func TestSimplify(t *testing.T) {
+	cases := []struct {
+		name string
+		pol  Policy
+		want string
+	}{
+		{"ones are absorbed", NewSeq(One{}, dstTest, One{}), "(dst = 1)"},
+		{"zero propagates through sequences", NewSeq(dstTest, Zero{}, portAssign), "0"},
+		{"zeros and duplicates are dropped from unions", NewUnion(Zero{}, dstTest, dstTest), "(dst = 1)"},
+		{"nested unions are flattened", NewUnion(NewUnion(dstTest, portTest), otherPort), "(dst = 1) + (port = 2) + (port = 3)"},
+		{"one absorbs disjunctions", Disj(dstTest, One{}), "1"},
+		{"double negations cancel", Not(Not(dstTest)), "(dst = 1)"},
+		{"consecutive predicates are merged", NewSeq(dstTest, portTest), "(dst = 1);(port = 2)"},
+		{"contradicting tests", Conj(portTest, otherPort), "0"},
+		{"negated implied test", Conj(dstTest, Not(dstTest)), "0"},
+		{"negated contradicted test", Conj(portTest, Not(otherPort)), "(port = 2)"},
+		{"implied tests of a negated conjunction", Conj(dstTest, Not(Conj(dstTest, portTest))), "(dst = 1);~(port = 2)"},
+		{"test after an assignment holds", NewSeq(portAssign, NewTest("port", "4"), Dup{}), "(port <- 4);dup"},
+		{"test after an assignment fails", NewSeq(portAssign, portTest), "0"},
+		{"negated test after an assignment holds", NewSeq(portAssign, Not(portTest)), "(port <- 4)"},
+		{"later assignments override earlier ones", NewSeq(portAssign, NewAssign("port", "5")), "(port <- 5)"},
+		{"nested stars", NewStar(NewStar(portAssign)), "(port <- 4)*"},
+		{"star of zero", NewStar(Zero{}), "1"},
+	}
+
+	for _, c := range cases {
+		if got := Format(Simplify(c.pol), PLAIN_SYMBOLS); got != c.want {
+			t.Errorf("%s: got '%s', want '%s'", c.name, got, c.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		pol  Policy
+		want string
+	}{
+		{NewSeq(NewUnion(dstTest, portTest), portAssign), "((dst = 1) + (port = 2));(port <- 4)"},
+		{NewUnion(NewSeq(dstTest, portAssign), portTest), "(dst = 1);(port <- 4) + (port = 2)"},
+		{NewStar(NewSeq(dstTest, Dup{})), "((dst = 1);dup)*"},
+		{Not(Conj(dstTest, portTest)), "~((dst = 1);(port = 2))"},
+		{Not(Not(dstTest)), "~(~(dst = 1))"},
+		{Union{}, "0"},
+		{Seq{}, "1"},
+	}
+
+	for _, c := range cases {
+		if got := Format(c.pol, PLAIN_SYMBOLS); got != c.want {
+			t.Errorf("got '%s', want '%s'", got, c.want)
+		}
+	}
+
+	latex := Symbols{ZERO: "0", ONE: "1", EQ: "=", ASSIGN: "\\leftarrow", NEG: "\\neg", AND: " \\cdot ", OR: "\\oplus", STAR: "^*", DUP: "dup"}
+	got := Format(NewUnion(Not(dstTest), NewStar(portAssign)), latex)
+	if want := "\\neg(dst = 1) \\oplus (port \\leftarrow 4)^*"; got != want {
+		t.Errorf("got '%s', want '%s'", got, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"utwente.nl/topology-to-dynetkat-coverter/convert/netkat"
 	"utwente.nl/topology-to-dynetkat-coverter/util"
 )
 
@@ -48,3 +49,18 @@ func (snp *SimpleNetKATPolicy) ToString(AndSym, EqSym, AssignSym string) string
 
 	return sb.String()
 }
+
+// Returns the policy as a NetKAT AST: the conjunction of the tests followed by the assignments
+func (snp *SimpleNetKATPolicy) ToPolicy() netkat.Policy {
+	tests := []netkat.Predicate{}
+	for _, test := range snp.completeTest {
+		tests = append(tests, netkat.NewTest(test.Fst, test.Snd))
+	}
+
+	pols := []netkat.Policy{netkat.Conj(tests...)}
+	for _, assig := range snp.completeAssignment {
+		pols = append(pols, netkat.NewAssign(assig.Fst, assig.Snd))
+	}
+
+	return netkat.NewSeq(pols...)
+}
@@ -0,0 +1,83 @@
+package convert
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert/netkat"
+)
+
+const SW_FIELD_NAME = "sw"
+
+// Returns the test that restricts a policy to the switch of the given topology node
+func SwitchTest(nodeId int64) netkat.Test {
+	return netkat.NewTest(SW_FIELD_NAME, strconv.FormatInt(nodeId, 10))
+}
+
+/*
+Returns the NetKAT link term of both directions of the link, i.e.
+(sw = a · port = p) · (sw ← b · port ← q) + (sw = b · port = q) · (sw ← a · port ← p),
+where p and q are the ports of the switches a and b at the ends of the link.
+*/
+func (l *Link) ToNetKATPolicy() netkat.Policy {
+	from, to := l.topoEdge.From().ID(), l.topoEdge.To().ID()
+	return netkat.NewUnion(
+		linkDirection(from, l.fromPort, to, l.toPort),
+		linkDirection(to, l.toPort, from, l.fromPort),
+	)
+}
+
+func linkDirection(fromId, fromPort, toId, toPort int64) netkat.Policy {
+	fromPortStr, toPortStr := strconv.FormatInt(fromPort, 10), strconv.FormatInt(toPort, 10)
+	return netkat.NewSeq(
+		netkat.Conj(SwitchTest(fromId), netkat.NewTest(PORT_FIELD_NAME, fromPortStr)),
+		netkat.NewAssign(SW_FIELD_NAME, strconv.FormatInt(toId, 10)),
+		netkat.NewAssign(PORT_FIELD_NAME, toPortStr),
+	)
+}
+
+// Returns the links between the switches of the network ordered by their ports
+func (n *Network) Links() []*Link {
+	seen := make(map[*Link]bool)
+	links := []*Link{}
+	for _, sw := range n.switches {
+		for _, link := range sw.links {
+			if !seen[link] {
+				seen[link] = true
+				links = append(links, link)
+			}
+		}
+	}
+
+	slices.SortFunc(links, func(a, b *Link) int {
+		return cmp.Compare(a.fromPort, b.fromPort)
+	})
+	return links
+}
+
+// Returns the topology of the network as the union of the link terms of all its links
+func (n *Network) TopologyPolicy() netkat.Policy {
+	pols := []netkat.Policy{}
+	for _, link := range n.Links() {
+		pols = append(pols, link.ToNetKATPolicy())
+	}
+	return netkat.Simplify(netkat.NewUnion(pols...))
+}
+
+/*
+Returns the policy of the whole network: the union of the flow tables of all switches,
+each one restricted to its switch by a test on the 'sw' field.
+*/
+func (n *Network) NetKATPolicy() netkat.Policy {
+	pols := []netkat.Policy{}
+	for _, sw := range n.switches {
+		pols = append(pols, netkat.NewSeq(SwitchTest(sw.topoNode.ID()), sw.flowTable.ToNetKATPolicy()))
+	}
+	return netkat.Simplify(netkat.NewUnion(pols...))
+}
+
+// Returns the standard NetKAT model of the network, (policy · topology)*
+func (n *Network) NetKATModel() netkat.Policy {
+	return netkat.NewStar(netkat.NewSeq(n.NetKATPolicy(), n.TopologyPolicy()))
+}
@@ -1,49 +1,97 @@
 package convert
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"maps"
+	"math/rand"
 	"slices"
 
 	"gonum.org/v1/gonum/graph"
-	"gonum.org/v1/gonum/graph/path"
 	"utwente.nl/topology-to-dynetkat-coverter/util"
 )
 
 type Network struct {
-	topology      util.Graph
-	shortestPaths map[util.I64Tup][]*Switch // maps a tuple of topology node ids to a switch path
+	topology      util.Topology
+	pathTrees     map[int64]*util.ShortestPathTree // shortest paths from the switches paths start at
+	shortestPaths map[util.I64Tup][]*Switch        // maps a tuple of topology node ids to the picked switch path
+	hostPlacement HostPlacement
+	headerSchema  *HeaderSchema
+	rand          *rand.Rand
+	ctx           context.Context
 
 	switches   []*Switch
 	nodeIdToSw map[int64]*Switch
 
-	controllers []*Controller
-	hosts       []*Host
-	portNr      int64
-	hostId      int64
+	controllers  []*Controller
+	hosts        []*Host
+	createdHosts []*Host        // all the hosts created so far, including those not in 'hosts'
+	hostNodeIds  map[int64]bool // node ids of the switches that hosts were created at
+	portNr       int64
+	hostId       int64
 }
 
-func NewNetwork(topo util.Graph) (*Network, error) {
+// Options for creating a network. The zero value selects the default behavior.
+type NetworkOptions struct {
+	HostPlacement HostPlacement // defaults to uniform random placement with replacement
+	HeaderSchema  *HeaderSchema // defaults to matching only the destination host id and the port
+	// the source of the random picks, e.g. of the host placements and of co-equal shortest paths.
+	// Defaults to a new generator seeded with util.SEED, so networks are reproducible by default.
+	Rand *rand.Rand
+	// checked while the flow rules of the hosts are built, so that large networks can be canceled.
+	// Defaults to context.Background().
+	Context context.Context
+}
+
+func NewNetwork(topo util.Topology, opts NetworkOptions) (*Network, error) {
 	var portNr int64 = 0
 
-	edgeToLink, err := makeLinks(topo, &portNr)
+	edgeToLink, err := makeLinks(topo.Graph, &portNr)
 	if err != nil {
 		return &Network{}, err
 	}
 
-	switches, err := makeSwitchesFromTopology(topo, edgeToLink)
+	switches, err := makeSwitchesFromTopology(topo.Graph, edgeToLink)
 	if err != nil {
 		return &Network{}, err
 	}
 
+	hostPlacement := opts.HostPlacement
+	if hostPlacement == nil {
+		hostPlacement = &RandomWithReplcPlacement{}
+	}
+
+	headerSchema := opts.HeaderSchema
+	if headerSchema == nil {
+		headerSchema = NewHeaderSchema()
+	}
+
+	r := opts.Rand
+	if r == nil {
+		r = util.NewRand(util.SEED)
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	return &Network{
 		topology:      topo,
-		shortestPaths: computeShortestPaths(topo, switches),
+		hostPlacement: hostPlacement,
+		headerSchema:  headerSchema,
+		rand:          r,
+		ctx:           ctx,
+		pathTrees:     make(map[int64]*util.ShortestPathTree),
+		shortestPaths: make(map[util.I64Tup][]*Switch),
 		switches:      switches,
 		nodeIdToSw:    mapNodeToSwitch(switches),
 		portNr:        portNr,
 		hostId:        0,
 		hosts:         []*Host{},
+		createdHosts:  []*Host{},
+		hostNodeIds:   make(map[int64]bool),
 	}, nil
 }
 
@@ -57,6 +105,22 @@ func mapNodeToSwitch(switches []*Switch) map[int64]*Switch {
 	return nodeIdToSwitch
 }
 
+func (n *Network) Topology() util.Topology {
+	return n.topology
+}
+
+func (n *Network) HeaderSchema() *HeaderSchema {
+	return n.headerSchema
+}
+
+// Returns the error of the context of the network once it is canceled, see NetworkOptions.Context
+func (n *Network) ContextErr() error {
+	if n.ctx == nil {
+		return nil
+	}
+	return n.ctx.Err()
+}
+
 func (n *Network) PortNr() int64 {
 	return n.portNr
 }
@@ -77,6 +141,14 @@ func (n *Network) Hosts() []*Host {
 	return n.hosts
 }
 
+/*
+Returns all the hosts created so far: the connected hosts returned by Hosts and the hosts
+created by CreateHosts that are only reachable after a controller update, e.g. outside hosts.
+*/
+func (n *Network) CreatedHosts() []*Host {
+	return n.createdHosts
+}
+
 func (n *Network) Controllers() []*Controller {
 	return n.controllers
 }
@@ -87,14 +159,14 @@ func makeSwitchesFromTopology(
 ) ([]*Switch, error) {
 	switches := []*Switch{}
 
-	iter := topo.Nodes()
-	for iter.Next() {
-		links, err := getSwitchLinks(topo, iter.Node(), edgeToLink)
+	// the nodes and edges are sorted, so that the switches and ports are numbered alike in every run
+	for _, node := range util.GetNodesArrayFromIter(topo) {
+		links, err := getSwitchLinks(topo, node, edgeToLink)
 		if err != nil {
 			return []*Switch{}, err
 		}
 
-		newSw, err := NewSwitch(iter.Node(), links)
+		newSw, err := NewSwitch(node, links)
 		if err != nil {
 			return []*Switch{}, err
 		}
@@ -111,10 +183,9 @@ func makeLinks(topo util.Graph, portNr *int64) (map[util.I64Tup]*Link, error) {
 	}
 
 	edgeTolink := make(map[util.I64Tup]*Link)
-	iter := topo.Edges()
-	for iter.Next() {
-		newLink := NewLink(iter.Edge(), *portNr, *portNr+1)
-		edgeId := util.NewI64Tup(iter.Edge().From().ID(), iter.Edge().To().ID())
+	for _, edge := range util.GetEdgesArrayFromIter(topo) {
+		newLink := NewLink(edge, *portNr, *portNr+1)
+		edgeId := util.NewI64Tup(edge.From().ID(), edge.To().ID())
 		edgeTolink[edgeId] = newLink
 		*portNr += 2
 	}
@@ -133,8 +204,11 @@ func getSwitchLinks(
 
 	links := []*Link{}
 	for _, edge := range incidentEdges {
-		edgeId := util.NewI64Tup(edge.From().ID(), edge.To().ID())
-		link, exists := edgeToLink[edgeId]
+		// the links are mapped by the orientation of their edge in the topology
+		link, exists := edgeToLink[util.NewI64Tup(edge.From().ID(), edge.To().ID())]
+		if !exists {
+			link, exists = edgeToLink[util.NewI64Tup(edge.To().ID(), edge.From().ID())]
+		}
 		if !exists || link == nil {
 			return []*Link{}, errors.New("Edge is not mapped to a link!")
 		}
@@ -144,33 +218,34 @@ func getSwitchLinks(
 	return links, nil
 }
 
-func nodePathToSwitchPath(path []graph.Node, nodeToSwitch map[int64]*Switch) []*Switch {
-	switchPath := []*Switch{}
-	for _, node := range path {
-		switchPath = append(switchPath, nodeToSwitch[node.ID()])
+/*
+Returns a shortest path between the switches, both included. The paths from a switch are only
+computed when one of them is first needed, and the path picked between two switches is kept, so
+all the flows between them follow the same path.
+*/
+func (n *Network) shortestPath(srcSw, destSw *Switch) ([]*Switch, bool) {
+	srcId, destId := srcSw.topoNode.ID(), destSw.topoNode.ID()
+	if path, exists := n.shortestPaths[util.NewI64Tup(srcId, destId)]; exists {
+		return path, true
 	}
-	return switchPath
-}
 
-func computeShortestPaths(topo util.Graph, switches []*Switch) map[util.I64Tup][]*Switch {
-	nodePaths := path.DijkstraAllPaths(&topo)
-	nodeToSwitch := mapNodeToSwitch(switches)
-	switchPaths := make(map[util.I64Tup][]*Switch)
-
-	for i := range len(switches) {
-		sw1Id := switches[i].topoNode.ID()
-
-		for j := range len(switches) {
-			sw2Id := switches[j].topoNode.ID()
-			nodePath, _, _ := nodePaths.Between(sw1Id, sw2Id)
-			switchPaths[util.NewI64Tup(sw1Id, sw2Id)] = nodePathToSwitchPath(
-				nodePath,
-				nodeToSwitch,
-			)
-		}
+	tree, exists := n.pathTrees[srcId]
+	if !exists {
+		tree = util.NewShortestPathTree(n.topology.Graph, srcId)
+		n.pathTrees[srcId] = tree
 	}
 
-	return switchPaths
+	nodePath := tree.PathTo(n.rand, destId)
+	if nodePath == nil {
+		return []*Switch{}, false
+	}
+
+	path := []*Switch{}
+	for _, nodeId := range nodePath {
+		path = append(path, n.nodeIdToSw[nodeId])
+	}
+	n.shortestPaths[util.NewI64Tup(srcId, destId)] = path
+	return path, true
 }
 
 func (n *Network) assignHosts(hostsNr uint) error {
@@ -190,7 +265,15 @@ func (n *Network) assignHosts(hostsNr uint) error {
 func (n *Network) CreateHosts(hostsNr uint) ([]*Host, error) {
 	hosts := []*Host{}
 
-	randSws, err := n.pickRandomSwitches(hostsNr)
+	if len(n.switches) == 0 {
+		return []*Host{}, errors.New("Network has no switches!")
+	}
+	if n.hostId+int64(hostsNr) > MAX_HOSTS {
+		return []*Host{}, errors.New(fmt.Sprintf(
+			"Cannot create %d more hosts, only %d hosts can be addressed!", hostsNr, MAX_HOSTS))
+	}
+
+	randSws, err := n.hostPlacement.PickSwitches(n, hostsNr)
 	if err != nil {
 		return []*Host{}, err
 	}
@@ -201,6 +284,8 @@ func (n *Network) CreateHosts(hostsNr uint) ([]*Host, error) {
 			return []*Host{}, err
 		}
 		hosts = append(hosts, &newHost)
+		n.createdHosts = append(n.createdHosts, &newHost)
+		n.hostNodeIds[randSw.topoNode.ID()] = true
 
 		n.hostId++
 		n.portNr++
@@ -209,24 +294,6 @@ func (n *Network) CreateHosts(hostsNr uint) ([]*Host, error) {
 	return hosts, nil
 }
 
-// Turns out that the switches order in the array is not static,
-// so we must pick them by ID
-func (n *Network) pickRandomSwitches(picksNr uint) ([]*Switch, error) {
-	if len(n.switches) == 0 {
-		return []*Switch{}, errors.New("Network has no switches!")
-	}
-	nodeIds := slices.Collect(maps.Keys(n.nodeIdToSw))
-
-	randIdPicks := util.RandomFromArrayWithReplc(nodeIds, picksNr)
-
-	randSws := []*Switch{}
-	for _, nodeId := range randIdPicks {
-		randSws = append(randSws, n.nodeIdToSw[nodeId])
-	}
-
-	return randSws, nil
-}
-
 func (n *Network) populateFlowTables(h1, h2 *Host) error {
 	if h1 == nil || h2 == nil {
 		return errors.New("Null arguments!")
@@ -237,10 +304,11 @@ func (n *Network) populateFlowTables(h1, h2 *Host) error {
 		return err
 	}
 
+	headers := n.headerSchema.Values(h1)
 	for nodeId, portTuples := range entries {
 		for _, fromPortToPort := range portTuples {
-			n.nodeIdToSw[nodeId].FlowTable().
-				AddEntry(h2.ID(), fromPortToPort.Fst, fromPortToPort.Snd)
+			match := NewFlowMatch(h2.ID(), fromPortToPort.Fst, headers)
+			n.nodeIdToSw[nodeId].FlowTable().AddEntry(match, fromPortToPort.Snd)
 		}
 	}
 
@@ -260,7 +328,7 @@ func (n *Network) GetFlowRulesForSwitchPath(
 		return make(map[int64][]util.I64Tup), errors.New("Null arguments!")
 	}
 
-	path, exists := n.shortestPaths[util.NewI64Tup(srcSw.topoNode.ID(), destSw.topoNode.ID())]
+	path, exists := n.shortestPath(srcSw, destSw)
 	if !exists {
 		return make(map[int64][]util.I64Tup), errors.New("Could not find path between switches!")
 	}
@@ -301,6 +369,9 @@ func (n *Network) AddAndConnectHosts(hostsNr uint) error {
 	}
 
 	for i := range len(n.hosts) {
+		if err := n.ContextErr(); err != nil {
+			return err
+		}
 		for j := i + 1; j < len(n.hosts); j++ {
 			err := n.populateFlowTables(n.hosts[i], n.hosts[j])
 			if err != nil {
@@ -332,7 +403,7 @@ func (n *Network) AddControllers(controllersNr uint) error {
 	}
 
 	nodeIds := slices.Collect(maps.Keys(n.nodeIdToSw))
-	randOrder, err := util.RandomFromArray(nodeIds, uint(len(nodeIds)))
+	randOrder, err := util.RandomFromArray(n.rand, nodeIds, uint(len(nodeIds)))
 	if err != nil {
 		return err
 	}
@@ -343,7 +414,9 @@ func (n *Network) AddControllers(controllersNr uint) error {
 		for _, nodeId := range slice {
 			switches = append(switches, n.nodeIdToSw[nodeId])
 		}
-		n.controllers = append(n.controllers, NewController(switches))
+		// number the controllers within the network, so networks generated in one run are numbered alike
+		c := NewController(int64(len(n.controllers)), switches)
+		n.controllers = append(n.controllers, c)
 	}
 
 	return nil
@@ -9,8 +9,12 @@ type Behavior interface {
 	ModifyNetwork(n *convert.Network) error
 }
 
-func NewNetworkWithBehavior(topo util.Graph, b Behavior) (*convert.Network, error) {
-	newNet, err := convert.NewNetwork(topo)
+func NewNetworkWithBehavior(
+	topo util.Topology,
+	b Behavior,
+	opts convert.NetworkOptions,
+) (*convert.Network, error) {
+	newNet, err := convert.NewNetwork(topo, opts)
 	if err != nil {
 		return newNet, err
 	}
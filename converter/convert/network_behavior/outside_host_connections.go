@@ -2,6 +2,7 @@ package behavior
 
 import (
 	"errors"
+	"slices"
 
 	"utwente.nl/topology-to-dynetkat-coverter/convert"
 	"utwente.nl/topology-to-dynetkat-coverter/util"
@@ -11,37 +12,69 @@ const (
 	HOSTS_NR         = 2
 	OUTSIDE_HOSTS_NR = 1
 	CONTROLLERS_NR   = 1
+	UPDATE_ROUNDS    = 1
 )
 
-type OutsideHostConn struct{}
+/*
+Connects hosts with each other and adds controllers, which update the switches so that hosts
+outside the network, created afterwards, can send packets to the connected hosts. The zero value
+of a field selects its default, e.g. HOSTS_NR.
+
+In every update round, 'OutsideHostsNr' outside hosts are created, which can also send packets to
+the outside hosts of the earlier rounds. The encodings have one update per switch, so the rounds
+are merged into the updates of the controllers, which get larger with every round.
+*/
+type OutsideHostConn struct {
+	HostsNr        uint
+	OutsideHostsNr uint // per update round
+	ControllersNr  uint
+	UpdateRounds   uint
+}
 
-func (_ *OutsideHostConn) ModifyNetwork(n *convert.Network) error {
-	err := n.AddAndConnectHosts(HOSTS_NR)
+func (b *OutsideHostConn) ModifyNetwork(n *convert.Network) error {
+	err := n.AddAndConnectHosts(orDefault(b.HostsNr, HOSTS_NR))
 	if err != nil {
 		return err
 	}
 
-	err = n.AddControllers(CONTROLLERS_NR)
+	err = n.AddControllers(orDefault(b.ControllersNr, CONTROLLERS_NR))
 	if err != nil {
 		return err
 	}
 
-	newHosts, err := n.CreateHosts(OUTSIDE_HOSTS_NR)
-	if err != nil {
-		return err
-	}
+	destHosts := n.Hosts()
+	for range orDefault(b.UpdateRounds, UPDATE_ROUNDS) {
+		newHosts, err := n.CreateHosts(orDefault(b.OutsideHostsNr, OUTSIDE_HOSTS_NR))
+		if err != nil {
+			return err
+		}
 
-	populateControllerNewFlowTables(newHosts, n)
+		err = populateControllerNewFlowTables(newHosts, destHosts, n)
+		if err != nil {
+			return err
+		}
+		destHosts = append(slices.Clone(destHosts), newHosts...)
+	}
 	return nil
 }
 
-func populateControllerNewFlowTables(newHosts []*convert.Host, n *convert.Network) error {
+func orDefault(value, defaultValue uint) uint {
+	if value == 0 {
+		return defaultValue
+	}
+	return value
+}
+
+func populateControllerNewFlowTables(newHosts, destHosts []*convert.Host, n *convert.Network) error {
 	if n == nil {
 		return errors.New("Nil network!")
 	}
 
 	for _, newHost := range newHosts {
-		err := addNewHostConnFlowRules(newHost, n)
+		if err := n.ContextErr(); err != nil {
+			return err
+		}
+		err := addNewHostConnFlowRules(newHost, destHosts, n)
 		if err != nil {
 			return err
 		}
@@ -50,7 +83,7 @@ func populateControllerNewFlowTables(newHosts []*convert.Host, n *convert.Networ
 	return nil
 }
 
-func addNewHostConnFlowRules(newHost *convert.Host, n *convert.Network) error {
+func addNewHostConnFlowRules(newHost *convert.Host, destHosts []*convert.Host, n *convert.Network) error {
 	switch {
 	case newHost == nil:
 		return errors.New("Nil newHost!")
@@ -58,7 +91,7 @@ func addNewHostConnFlowRules(newHost *convert.Host, n *convert.Network) error {
 		return errors.New("Nil network!")
 	}
 
-	for _, host := range n.Hosts() {
+	for _, host := range destHosts {
 		newEntries, err := n.GetFlowRulesForSwitchPath(
 			newHost.Switch(),
 			host.Switch(),
@@ -69,7 +102,8 @@ func addNewHostConnFlowRules(newHost *convert.Host, n *convert.Network) error {
 			return err
 		}
 
-		err = addEntriesToControllerNewFlowTables(n, host.ID(), newEntries)
+		headers := n.HeaderSchema().Values(newHost)
+		err = addEntriesToControllerNewFlowTables(n, host.ID(), headers, newEntries)
 		if err != nil {
 			return err
 		}
@@ -81,6 +115,7 @@ func addNewHostConnFlowRules(newHost *convert.Host, n *convert.Network) error {
 func addEntriesToControllerNewFlowTables(
 	n *convert.Network,
 	destHostId int64,
+	headers convert.HeaderValues,
 	newEntries map[int64][]util.I64Tup,
 ) error {
 	if n == nil {
@@ -94,7 +129,7 @@ func addEntriesToControllerNewFlowTables(
 			return errors.New("Switch has nil controller!")
 		}
 
-		c.AddNewFlowRules(nodeId, destHostId, portTups)
+		c.AddNewFlowRules(nodeId, destHostId, headers, portTups)
 	}
 
 	return nil
@@ -0,0 +1,112 @@
+package behavior
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+)
+
+// The difficulty of a benchmark, from EASY upwards, see Difficulty.Profile
+type Difficulty uint
+
+const (
+	EASY Difficulty = iota + 1
+	MEDIUM
+	HARD
+	EXTREME
+)
+
+var DIFFICULTY_NAMES = map[Difficulty]string{
+	EASY:    "easy",
+	MEDIUM:  "medium",
+	HARD:    "hard",
+	EXTREME: "extreme",
+}
+
+// the header fields matched by the flow rules, added one per difficulty level above EASY
+var PROFILE_HEADER_FIELDS = []convert.HeaderField{
+	convert.SRC_FIELD,
+	convert.ETH_TYPE_FIELD,
+	convert.VLAN_FIELD,
+	convert.IP_PROTO_FIELD,
+	convert.TCP_DST_FIELD,
+}
+
+func (d Difficulty) String() string {
+	if name, exists := DIFFICULTY_NAMES[d]; exists {
+		return name
+	}
+	return strconv.FormatUint(uint64(d), 10)
+}
+
+// Parses the name of a difficulty, e.g. 'hard', or its level, e.g. '3'. Levels above EXTREME are allowed.
+func ParseDifficulty(str string) (Difficulty, error) {
+	for d, name := range DIFFICULTY_NAMES {
+		if strings.EqualFold(name, str) {
+			return d, nil
+		}
+	}
+
+	level, err := strconv.ParseUint(str, 10, 32)
+	if err != nil || level == 0 {
+		return 0, errors.New(fmt.Sprintf("Invalid difficulty '%s'!", str))
+	}
+	return Difficulty(level), nil
+}
+
+/*
+The parameters of a benchmark scenario, see OutsideHostConn. The header fields are matched by
+the flow rules besides the destination and the port, and multiply the flow rules when 'src'
+is one of them.
+*/
+type Profile struct {
+	Difficulty     Difficulty
+	HostsNr        uint
+	OutsideHostsNr uint // per update round
+	ControllersNr  uint
+	UpdateRounds   uint
+	HeaderFields   []convert.HeaderField
+}
+
+/*
+Returns the profile of the difficulty level d, which scales all the parameters together:
+2d hosts, d controllers, (d+1)/2 update rounds of d outside hosts each and d-1 header fields.
+The number of flow rules grows with the square of the hosts, so the hosts grow linearly and
+the update rounds, which also connect the outside hosts with each other, grow more slowly.
+*/
+func (d Difficulty) Profile() Profile {
+	level := max(uint(d), 1)
+	return Profile{
+		Difficulty:     d,
+		HostsNr:        2 * level,
+		OutsideHostsNr: level,
+		ControllersNr:  level,
+		UpdateRounds:   (level + 1) / 2,
+		HeaderFields:   PROFILE_HEADER_FIELDS[:min(int(level-1), len(PROFILE_HEADER_FIELDS))],
+	}
+}
+
+/*
+Returns the behavior that generates the scenario of the profile. There are at most as many
+controllers as switches, so the profile also fits the smallest topologies.
+*/
+func (p Profile) Behavior(switchesNr uint) *OutsideHostConn {
+	return &OutsideHostConn{
+		HostsNr:        p.HostsNr,
+		OutsideHostsNr: p.OutsideHostsNr,
+		ControllersNr:  max(min(p.ControllersNr, switchesNr), 1),
+		UpdateRounds:   p.UpdateRounds,
+	}
+}
+
+// Returns a header schema that matches the header fields of the profile, with their default values
+func (p Profile) HeaderSchema() *convert.HeaderSchema {
+	schema := convert.NewHeaderSchema()
+	for _, field := range p.HeaderFields {
+		schema.AddField(field, convert.HEADER_FIELD_DEFAULTS[field])
+	}
+	return schema
+}
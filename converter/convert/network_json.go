@@ -0,0 +1,452 @@
+package convert
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"gonum.org/v1/gonum/graph/simple"
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+/*
+Version of the JSON representation of networks. It is increased on every change that older
+readers cannot handle, and ReadNetworkJSON rejects documents of other versions.
+*/
+const NETWORK_JSON_VERSION = 1
+
+/*
+The JSON representation of a network. Switches are identified by their topology node id, and
+all the ports are the globally unique port numbers of the network. In flow rules, a 'dst' or
+'inPort' of -1 matches any destination or incoming port (see ANY_HOST and ANY_PORT), a rule
+without outgoing ports drops the packets, and the header fields that are not listed are not
+matched.
+*/
+type NetworkJSON struct {
+	Version     int              `json:"version"`
+	Metadata    MetadataJSON     `json:"metadata"`
+	Switches    []SwitchJSON     `json:"switches"`
+	Links       []LinkJSON       `json:"links"`
+	Hosts       []HostJSON       `json:"hosts"`
+	Controllers []ControllerJSON `json:"controllers"`
+}
+
+type MetadataJSON struct {
+	Name         string          `json:"name,omitempty"`
+	HeaderSchema string          `json:"headerSchema,omitempty"` // see ParseHeaderSchema
+	Attrs        util.Attributes `json:"attrs,omitempty"`        // attributes of the topology
+}
+
+type SwitchJSON struct {
+	NodeId       int64           `json:"nodeId"`
+	Attrs        util.Attributes `json:"attrs,omitempty"` // attributes of the topology node
+	FlowTable    FlowTableJSON   `json:"flowTable"`
+	NewFlowTable *FlowTableJSON  `json:"newFlowTable,omitempty"` // installed by the switch's controller
+}
+
+type LinkJSON struct {
+	From     int64           `json:"from"`
+	To       int64           `json:"to"`
+	FromPort int64           `json:"fromPort"`
+	ToPort   int64           `json:"toPort"`
+	Attrs    util.Attributes `json:"attrs,omitempty"` // attributes of the topology edge
+}
+
+type HostJSON struct {
+	Id     int64 `json:"id"`
+	Switch int64 `json:"switch"`
+	Port   int64 `json:"port"`
+	// whether the host is connected from the start, otherwise it is only reachable after an update
+	Connected bool `json:"connected"`
+}
+
+type ControllerJSON struct {
+	Id       int64   `json:"id"`
+	Switches []int64 `json:"switches"`
+}
+
+type FlowTableJSON struct {
+	Entries []FlowRuleJSON `json:"entries"`
+	Rules   []FlowRuleJSON `json:"rules,omitempty"` // rules with explicit priorities, see PriorityRule
+}
+
+type FlowRuleJSON struct {
+	Priority int              `json:"priority,omitempty"`
+	Dst      int64            `json:"dst"`
+	InPort   int64            `json:"inPort"`
+	Headers  map[string]int64 `json:"headers,omitempty"`
+	OutPorts []int64          `json:"outPorts"`
+}
+
+// Writes the network in its JSON representation, with the given name in the metadata
+func WriteNetworkJSON(n *Network, name string, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(n.ToJSON(name))
+}
+
+// Returns the JSON representation of the network, with the given name in the metadata
+func (n *Network) ToJSON(name string) NetworkJSON {
+	netJSON := NetworkJSON{
+		Version: NETWORK_JSON_VERSION,
+		Metadata: MetadataJSON{
+			Name:         name,
+			HeaderSchema: n.headerSchema.String(),
+			Attrs:        n.topology.Attrs,
+		},
+		Switches:    []SwitchJSON{},
+		Links:       []LinkJSON{},
+		Hosts:       []HostJSON{},
+		Controllers: []ControllerJSON{},
+	}
+
+	for _, sw := range n.switches {
+		nodeId := sw.topoNode.ID()
+		swJSON := SwitchJSON{
+			NodeId:    nodeId,
+			Attrs:     n.topology.NodeAttrs[nodeId],
+			FlowTable: sw.flowTable.ToJSON(),
+		}
+		if newFt, exists := sw.NewFlowTable(); exists {
+			newFtJSON := newFt.ToJSON()
+			swJSON.NewFlowTable = &newFtJSON
+		}
+		netJSON.Switches = append(netJSON.Switches, swJSON)
+	}
+
+	for _, link := range n.Links() {
+		from, to := link.topoEdge.From().ID(), link.topoEdge.To().ID()
+		attrs := n.topology.EdgeAttrs[util.NewI64Tup(from, to)]
+		if attrs == nil {
+			attrs = n.topology.EdgeAttrs[util.NewI64Tup(to, from)]
+		}
+		netJSON.Links = append(netJSON.Links, LinkJSON{
+			From:     from,
+			To:       to,
+			FromPort: link.fromPort,
+			ToPort:   link.toPort,
+			Attrs:    attrs,
+		})
+	}
+
+	for _, h := range n.createdHosts {
+		netJSON.Hosts = append(netJSON.Hosts, HostJSON{
+			Id:        h.id,
+			Switch:    h.sw.topoNode.ID(),
+			Port:      h.switchPort,
+			Connected: slices.Contains(n.hosts, h),
+		})
+	}
+
+	for _, c := range n.controllers {
+		cJSON := ControllerJSON{Id: c.id, Switches: []int64{}}
+		for _, sw := range c.switches {
+			cJSON.Switches = append(cJSON.Switches, sw.topoNode.ID())
+		}
+		netJSON.Controllers = append(netJSON.Controllers, cJSON)
+	}
+
+	return netJSON
+}
+
+// Returns the JSON representation of the flow table, see NetworkJSON
+func (ft *FlowTable) ToJSON() FlowTableJSON {
+	ftJSON := FlowTableJSON{Entries: []FlowRuleJSON{}}
+	for _, match := range ft.sortedMatches() {
+		entry := NewPriorityRule(DEFAULT_PRIORITY, match, ft.entries[match]...)
+		ftJSON.Entries = append(ftJSON.Entries, flowRuleToJSON(entry))
+	}
+
+	rules := slices.Clone(ft.rules)
+	slices.SortStableFunc(rules, comparePriorityRules)
+	for _, rule := range rules {
+		ftJSON.Rules = append(ftJSON.Rules, flowRuleToJSON(rule))
+	}
+	return ftJSON
+}
+
+func flowRuleToJSON(rule PriorityRule) FlowRuleJSON {
+	ruleJSON := FlowRuleJSON{
+		Priority: rule.Priority,
+		Dst:      rule.Match.DestHostId,
+		InPort:   rule.Match.InPort,
+		OutPorts: slices.Clone(rule.OutPorts),
+	}
+	if ruleJSON.OutPorts == nil {
+		ruleJSON.OutPorts = []int64{}
+	}
+
+	for field, value := range rule.Match.Headers {
+		if value == NO_VALUE {
+			continue
+		}
+		if ruleJSON.Headers == nil {
+			ruleJSON.Headers = make(map[string]int64)
+		}
+		ruleJSON.Headers[HeaderField(field).String()] = value
+	}
+	return ruleJSON
+}
+
+// Reads a network from its JSON representation and returns it together with the name in its metadata
+func ReadNetworkJSON(r io.Reader) (*Network, string, error) {
+	var netJSON NetworkJSON
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&netJSON)
+	if err != nil {
+		return &Network{}, "", errors.New(fmt.Sprintf("Invalid network JSON: %s", err))
+	}
+
+	n, err := NewNetworkFromJSON(netJSON)
+	return n, netJSON.Metadata.Name, err
+}
+
+/*
+Rebuilds a network from its JSON representation: the topology, the switches with their
+flow tables, the links and hosts on their ports and the controllers with their updates.
+The network uses the default host placement for hosts created later on.
+*/
+func NewNetworkFromJSON(netJSON NetworkJSON) (*Network, error) {
+	if netJSON.Version != NETWORK_JSON_VERSION {
+		return &Network{}, errors.New(fmt.Sprintf(
+			"Unsupported network JSON version %d, expected %d!",
+			netJSON.Version,
+			NETWORK_JSON_VERSION,
+		))
+	}
+
+	headerSchema, err := ParseHeaderSchema(netJSON.Metadata.HeaderSchema)
+	if err != nil {
+		return &Network{}, err
+	}
+
+	topo := util.NewTopology(*simple.NewUndirectedGraph())
+	for k, v := range netJSON.Metadata.Attrs {
+		topo.Attrs[k] = v
+	}
+	for _, swJSON := range netJSON.Switches {
+		if topo.Graph.Node(swJSON.NodeId) != nil {
+			return &Network{}, errors.New(fmt.Sprintf("Duplicate switch %d!", swJSON.NodeId))
+		}
+		topo.Graph.AddNode(simple.Node(swJSON.NodeId))
+		topo.NodeAttrs[swJSON.NodeId] = util.Attributes{}
+		for k, v := range swJSON.Attrs {
+			topo.NodeAttrs[swJSON.NodeId][k] = v
+		}
+	}
+
+	// maps the topology edges to their ports, in the direction of the JSON link
+	usedPorts := make(map[int64]bool)
+	usePort := func(port int64) error {
+		if port < 0 || usedPorts[port] {
+			return errors.New(fmt.Sprintf("Port %d is invalid or used more than once!", port))
+		}
+		usedPorts[port] = true
+		return nil
+	}
+
+	linkPorts := make(map[util.I64Tup]util.I64Tup)
+	for _, linkJSON := range netJSON.Links {
+		from, to := topo.Graph.Node(linkJSON.From), topo.Graph.Node(linkJSON.To)
+		if from == nil || to == nil || from.ID() == to.ID() || topo.Graph.HasEdgeBetween(from.ID(), to.ID()) {
+			return &Network{}, errors.New(fmt.Sprintf(
+				"Invalid or duplicate link between switches %d and %d!",
+				linkJSON.From,
+				linkJSON.To,
+			))
+		}
+		if err := usePort(linkJSON.FromPort); err != nil {
+			return &Network{}, err
+		}
+		if err := usePort(linkJSON.ToPort); err != nil {
+			return &Network{}, err
+		}
+
+		topo.Graph.SetEdge(topo.Graph.NewEdge(from, to))
+		edgeId := util.NewI64Tup(from.ID(), to.ID())
+		topo.EdgeAttrs[edgeId] = util.Attributes{}
+		for k, v := range linkJSON.Attrs {
+			topo.EdgeAttrs[edgeId][k] = v
+		}
+		linkPorts[edgeId] = util.NewI64Tup(linkJSON.FromPort, linkJSON.ToPort)
+	}
+
+	edgeToLink := make(map[util.I64Tup]*Link)
+	iter := topo.Graph.Edges()
+	for iter.Next() {
+		from, to := iter.Edge().From().ID(), iter.Edge().To().ID()
+		ports, exists := linkPorts[util.NewI64Tup(from, to)]
+		if !exists {
+			// the graph returned the edge in the opposite direction
+			reversed := linkPorts[util.NewI64Tup(to, from)]
+			ports = util.NewI64Tup(reversed.Snd, reversed.Fst)
+		}
+		edgeToLink[util.NewI64Tup(from, to)] = NewLink(iter.Edge(), ports.Fst, ports.Snd)
+	}
+
+	switches, err := makeSwitchesFromTopology(topo.Graph, edgeToLink)
+	if err != nil {
+		return &Network{}, err
+	}
+	// keep the order of the switches in the JSON, which the encodings follow
+	swOrder := make(map[int64]int)
+	for i, swJSON := range netJSON.Switches {
+		swOrder[swJSON.NodeId] = i
+	}
+	slices.SortFunc(switches, func(a, b *Switch) int {
+		return cmp.Compare(swOrder[a.topoNode.ID()], swOrder[b.topoNode.ID()])
+	})
+
+	n := &Network{
+		topology:      topo,
+		hostPlacement: &RandomWithReplcPlacement{},
+		headerSchema:  headerSchema,
+		rand:          util.NewRand(util.SEED),
+		pathTrees:     make(map[int64]*util.ShortestPathTree),
+		shortestPaths: make(map[util.I64Tup][]*Switch),
+		switches:      switches,
+		nodeIdToSw:    mapNodeToSwitch(switches),
+		hosts:         []*Host{},
+		createdHosts:  []*Host{},
+		hostNodeIds:   make(map[int64]bool),
+	}
+
+	for _, hJSON := range netJSON.Hosts {
+		sw, exists := n.nodeIdToSw[hJSON.Switch]
+		if !exists {
+			return &Network{}, errors.New(fmt.Sprintf(
+				"Host %d is connected to unknown switch %d!",
+				hJSON.Id,
+				hJSON.Switch,
+			))
+		}
+		if hJSON.Id < 0 || slices.ContainsFunc(n.createdHosts, func(h *Host) bool { return h.id == hJSON.Id }) {
+			return &Network{}, errors.New(fmt.Sprintf("Host id %d is invalid or used more than once!", hJSON.Id))
+		}
+		if err := usePort(hJSON.Port); err != nil {
+			return &Network{}, err
+		}
+
+		newHost, err := NewHost(hJSON.Id, hJSON.Port, sw)
+		if err != nil {
+			return &Network{}, err
+		}
+		n.createdHosts = append(n.createdHosts, &newHost)
+		n.hostNodeIds[hJSON.Switch] = true
+		if hJSON.Connected {
+			sw.AddHost(&newHost)
+			n.hosts = append(n.hosts, &newHost)
+		}
+		n.hostId = max(n.hostId, hJSON.Id+1)
+	}
+
+	for port := range usedPorts {
+		n.portNr = max(n.portNr, port+1)
+	}
+
+	for _, swJSON := range netJSON.Switches {
+		sw := n.nodeIdToSw[swJSON.NodeId]
+		err := swJSON.FlowTable.fillFlowTable(sw.flowTable)
+		if err != nil {
+			return &Network{}, errors.New(fmt.Sprintf("Switch %d: %s", swJSON.NodeId, err))
+		}
+	}
+
+	for _, cJSON := range netJSON.Controllers {
+		switches := []*Switch{}
+		for _, nodeId := range cJSON.Switches {
+			sw, exists := n.nodeIdToSw[nodeId]
+			if !exists || sw.controller != nil {
+				return &Network{}, errors.New(fmt.Sprintf(
+					"Controller %d: switch %d does not exist or already has a controller!",
+					cJSON.Id,
+					nodeId,
+				))
+			}
+			switches = append(switches, sw)
+		}
+
+		n.controllers = append(n.controllers, NewController(cJSON.Id, switches))
+	}
+
+	for _, swJSON := range netJSON.Switches {
+		if swJSON.NewFlowTable == nil {
+			continue
+		}
+
+		sw := n.nodeIdToSw[swJSON.NodeId]
+		if sw.controller == nil {
+			return &Network{}, errors.New(fmt.Sprintf(
+				"Switch %d has a new flow table but no controller!",
+				swJSON.NodeId,
+			))
+		}
+
+		newFt := NewFlowTable()
+		err := swJSON.NewFlowTable.fillFlowTable(newFt)
+		if err != nil {
+			return &Network{}, errors.New(fmt.Sprintf("Switch %d (new flow table): %s", swJSON.NodeId, err))
+		}
+		sw.controller.newFlowTables[swJSON.NodeId] = newFt
+	}
+
+	return n, nil
+}
+
+func (ftJSON FlowTableJSON) fillFlowTable(ft *FlowTable) error {
+	for _, entryJSON := range ftJSON.Entries {
+		rule, err := entryJSON.toPriorityRule()
+		if err != nil {
+			return err
+		}
+		if rule.Priority != DEFAULT_PRIORITY || rule.Drops() ||
+			rule.Match.DestHostId == ANY_HOST || rule.Match.InPort == ANY_PORT {
+			return errors.New("Entries must have the default priority, no wildcards and outgoing ports!")
+		}
+
+		for _, outPort := range rule.OutPorts {
+			ft.AddEntry(rule.Match, outPort)
+		}
+	}
+
+	for _, ruleJSON := range ftJSON.Rules {
+		rule, err := ruleJSON.toPriorityRule()
+		if err != nil {
+			return err
+		}
+		ft.AddRule(rule)
+	}
+	return nil
+}
+
+func (ruleJSON FlowRuleJSON) toPriorityRule() (PriorityRule, error) {
+	if ruleJSON.Dst < ANY_HOST || ruleJSON.InPort < ANY_PORT {
+		return PriorityRule{}, errors.New(fmt.Sprintf(
+			"Invalid destination %d or incoming port %d!",
+			ruleJSON.Dst,
+			ruleJSON.InPort,
+		))
+	}
+
+	headers := NoHeaderValues()
+	for name, value := range ruleJSON.Headers {
+		field, err := ParseHeaderField(name)
+		if err != nil {
+			return PriorityRule{}, err
+		}
+		if value < 0 {
+			return PriorityRule{}, errors.New(fmt.Sprintf("Invalid value for header field '%s'!", name))
+		}
+		headers[field] = value
+	}
+
+	match := NewFlowMatch(ruleJSON.Dst, ruleJSON.InPort, headers)
+	if len(ruleJSON.OutPorts) == 0 {
+		return NewDropRule(ruleJSON.Priority, match), nil
+	}
+	return NewPriorityRule(ruleJSON.Priority, match, ruleJSON.OutPorts...), nil
+}
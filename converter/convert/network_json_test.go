@@ -0,0 +1,160 @@
+package convert_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/decode"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/encode"
+	behavior "utwente.nl/topology-to-dynetkat-coverter/convert/network_behavior"
+	"utwente.nl/topology-to-dynetkat-coverter/dynetkat"
+)
+
+func generateNetworks(t *testing.T) map[string]*convert.Network {
+	networks := make(map[string]*convert.Network)
+	for _, spec := range []string{"ring:6", "grid:3x3", "fattree:4"} {
+		network, err := dynetkat.Generate(
+			context.Background(),
+			dynetkat.Synthetic(spec),
+			dynetkat.Scenario{Difficulty: behavior.MEDIUM},
+			dynetkat.Options{Headers: "src,vlan=10"},
+		)
+		if err != nil {
+			t.Fatalf("%s: %s", spec, err)
+		}
+		networks[spec] = network.Unwrap()
+
+		// the network rebuilt from an optimised encoding has prioritised rules with wildcards
+		encoder := encode.NewLatexEncoderWithOptions(encode.LatexEncoderOptions{OptimizeFlowTables: true})
+		content, err := encoder.Encode(network.Unwrap())
+		if err != nil {
+			t.Fatal(err)
+		}
+		optimized, err := decode.ParseLatexNetwork(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		networks[spec+" (optimised)"] = optimized
+	}
+	return networks
+}
+
+func TestNetworkJSONRoundTrip(t *testing.T) {
+	for name, network := range generateNetworks(t) {
+		t.Run(name, func(t *testing.T) {
+			var written bytes.Buffer
+			if err := convert.WriteNetworkJSON(network, name, &written); err != nil {
+				t.Fatal(err)
+			}
+			read, readName, err := convert.ReadNetworkJSON(bytes.NewReader(written.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if readName != name {
+				t.Errorf("got name '%s', want '%s'", readName, name)
+			}
+
+			var rewritten bytes.Buffer
+			if err := convert.WriteNetworkJSON(read, name, &rewritten); err != nil {
+				t.Fatal(err)
+			}
+			if rewritten.String() != written.String() {
+				t.Errorf("the JSON changed after reading it:\n%s\nwant:\n%s", rewritten.String(), written.String())
+			}
+		})
+	}
+}
+
+// a network of two switches with a host each, where the controller of the first one updates it
+func smallNetworkJSON() convert.NetworkJSON {
+	return convert.NetworkJSON{
+		Version: convert.NETWORK_JSON_VERSION,
+		Switches: []convert.SwitchJSON{
+			{
+				NodeId: 0,
+				FlowTable: convert.FlowTableJSON{Entries: []convert.FlowRuleJSON{
+					{Dst: 1, InPort: 2, OutPorts: []int64{0}},
+				}},
+				NewFlowTable: &convert.FlowTableJSON{Entries: []convert.FlowRuleJSON{}},
+			},
+			{NodeId: 1, FlowTable: convert.FlowTableJSON{Entries: []convert.FlowRuleJSON{}}},
+		},
+		Links: []convert.LinkJSON{{From: 0, To: 1, FromPort: 0, ToPort: 1}},
+		Hosts: []convert.HostJSON{
+			{Id: 0, Switch: 0, Port: 2, Connected: true},
+			{Id: 1, Switch: 1, Port: 3, Connected: true},
+		},
+		Controllers: []convert.ControllerJSON{{Id: 0, Switches: []int64{0}}},
+	}
+}
+
+func TestNewNetworkFromJSON(t *testing.T) {
+	_, err := convert.NewNetworkFromJSON(smallNetworkJSON())
+	if err != nil {
+		t.Fatalf("the small network must be valid: %s", err)
+	}
+}
+
+func TestNewNetworkFromJSONRejectsInvalidNetworks(t *testing.T) {
+	cases := map[string]struct {
+		edit    func(netJSON *convert.NetworkJSON)
+		message string
+	}{
+		"bad version": {
+			func(netJSON *convert.NetworkJSON) { netJSON.Version = convert.NETWORK_JSON_VERSION + 1 },
+			"version",
+		},
+		"duplicate switch": {
+			func(netJSON *convert.NetworkJSON) { netJSON.Switches = append(netJSON.Switches, netJSON.Switches[1]) },
+			"Duplicate switch",
+		},
+		"duplicate link port": {
+			func(netJSON *convert.NetworkJSON) { netJSON.Links[0].ToPort = netJSON.Links[0].FromPort },
+			"Port 0",
+		},
+		"duplicate host port": {
+			func(netJSON *convert.NetworkJSON) { netJSON.Hosts[1].Port = 1 },
+			"Port 1",
+		},
+		"link to an unknown switch": {
+			func(netJSON *convert.NetworkJSON) { netJSON.Links[0].To = 7 },
+			"switches 0 and 7",
+		},
+		"host on an unknown switch": {
+			func(netJSON *convert.NetworkJSON) { netJSON.Hosts[1].Switch = 7 },
+			"unknown switch 7",
+		},
+		"controller of an unknown switch": {
+			func(netJSON *convert.NetworkJSON) { netJSON.Controllers[0].Switches = []int64{0, 7} },
+			"switch 7",
+		},
+		"new flow table without a controller": {
+			func(netJSON *convert.NetworkJSON) { netJSON.Controllers = []convert.ControllerJSON{} },
+			"no controller",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			netJSON := smallNetworkJSON()
+			c.edit(&netJSON)
+			_, err := convert.NewNetworkFromJSON(netJSON)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), c.message) {
+				t.Errorf("got error '%s', want one about '%s'", err, c.message)
+			}
+		})
+	}
+}
+
+func TestReadNetworkJSONRejectsUnknownFields(t *testing.T) {
+	_, _, err := convert.ReadNetworkJSON(strings.NewReader(`{"version": 1, "switchs": []}`))
+	if err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
@@ -0,0 +1,137 @@
+package convert
+
+import (
+	"cmp"
+	"strconv"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert/netkat"
+)
+
+const (
+	ANY_PORT         int64 = -1 // incoming port of the matches that apply to packets from every port
+	ANY_HOST         int64 = -1 // destination of the matches that apply to packets for every host
+	DEFAULT_PRIORITY       = 0  // priority of the flow rules added with FlowTable.AddEntry
+)
+
+/*
+A flow rule with a priority. Like in OpenFlow, a packet is handled by the matching rule with
+the highest priority only. The match may contain wildcards: ANY_HOST as destination, ANY_PORT
+as incoming port and NO_VALUE for the header fields. A rule without outgoing ports drops the packets.
+*/
+type PriorityRule struct {
+	Priority int
+	Match    FlowMatch
+	OutPorts []int64
+}
+
+func NewPriorityRule(priority int, match FlowMatch, outPorts ...int64) PriorityRule {
+	return PriorityRule{
+		Priority: priority,
+		Match:    match,
+		OutPorts: outPorts,
+	}
+}
+
+func NewDropRule(priority int, match FlowMatch) PriorityRule {
+	return NewPriorityRule(priority, match)
+}
+
+func (r PriorityRule) Drops() bool {
+	return len(r.OutPorts) == 0
+}
+
+// returns true if the match applies to the packets with the fields of the given (wildcard-free) match
+func (m FlowMatch) Matches(packet FlowMatch) bool {
+	return m.Overlaps(packet)
+}
+
+// returns true if some packet matches both matches
+func (m FlowMatch) Overlaps(other FlowMatch) bool {
+	fieldsOverlap := func(a, b, wildcard int64) bool {
+		return a == wildcard || b == wildcard || a == b
+	}
+
+	if !fieldsOverlap(m.DestHostId, other.DestHostId, ANY_HOST) ||
+		!fieldsOverlap(m.InPort, other.InPort, ANY_PORT) {
+		return false
+	}
+	for field := range m.Headers {
+		if !fieldsOverlap(m.Headers[field], other.Headers[field], NO_VALUE) {
+			return false
+		}
+	}
+	return true
+}
+
+// returns the tests of the fields of the rule's match that are not wildcards
+func (r PriorityRule) Guard() netkat.Predicate {
+	tests := []netkat.Predicate{}
+	for _, test := range r.Match.headerTests() {
+		tests = append(tests, netkat.NewTest(test.Fst, test.Snd))
+	}
+	if r.Match.InPort != ANY_PORT {
+		tests = append(tests, netkat.NewTest(PORT_FIELD_NAME, strconv.FormatInt(r.Match.InPort, 10)))
+	}
+	return netkat.Conj(tests...)
+}
+
+// returns the union of the assignments of the outgoing ports, 0 for a drop rule
+func (r PriorityRule) Action() netkat.Policy {
+	assigns := []netkat.Policy{}
+	for _, outPort := range r.OutPorts {
+		assigns = append(assigns, netkat.NewAssign(PORT_FIELD_NAME, strconv.FormatInt(outPort, 10)))
+	}
+	return netkat.Simplify(netkat.NewUnion(assigns...))
+}
+
+// orders rules from the highest to the lowest priority, and then by their matches
+func comparePriorityRules(a, b PriorityRule) int {
+	if res := cmp.Compare(b.Priority, a.Priority); res != 0 {
+		return res
+	}
+	return compareMatches(a.Match, b.Match)
+}
+
+/*
+Returns the NetKAT policies of a list of rules ordered from the highest to the lowest priority,
+one per rule that forwards packets. Each rule is encoded as a guarded choice: its policy is
+guarded by the negated matches of the overlapping rules before it in the list, e.g. a rule
+forwarding the packets for host 3 to port 2, below a rule dropping them at port 7, becomes
+
+	(dst = 3) · ¬(port = 7) · (port <- 2)
+
+Drop rules only appear as negated guards.
+*/
+func PriorityRulesToNetKATPolicies(rules []PriorityRule) []netkat.Policy {
+	policies := []netkat.Policy{}
+
+	for i, rule := range rules {
+		if rule.Drops() {
+			continue
+		}
+
+		guards := []netkat.Predicate{rule.Guard()}
+		for _, higherRule := range rules[:i] {
+			if higherRule.Match.Overlaps(rule.Match) {
+				guards = append(guards, netkat.Not(higherRule.Guard()))
+			}
+		}
+
+		policy := netkat.Simplify(netkat.NewSeq(netkat.Conj(guards...), rule.Action()))
+		if _, isZero := policy.(netkat.Zero); !isZero {
+			policies = append(policies, policy)
+		}
+	}
+
+	return policies
+}
+
+// returns the outgoing ports of the first rule of the list that matches the packet, none if no rule matches
+func lookupRules(rules []PriorityRule, packet FlowMatch) []int64 {
+	for _, rule := range rules {
+		if rule.Match.Matches(packet) {
+			return rule.OutPorts
+		}
+	}
+	return []int64{}
+}
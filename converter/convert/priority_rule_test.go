@@ -0,0 +1,79 @@
+package convert
+
+import (
+	"slices"
+	"testing"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert/netkat"
+)
+
+func vlanHeaders(vlan int64) HeaderValues {
+	headers := NoHeaderValues()
+	headers[VLAN_FIELD] = vlan
+	return headers
+}
+
+func TestLookupOrder(t *testing.T) {
+	ft := NewFlowTable()
+	ft.AddEntry(NewFlowMatch(3, 1, NoHeaderValues()), 2)
+	ft.AddEntry(NewFlowMatch(3, 7, NoHeaderValues()), 2)
+	ft.AddRule(NewDropRule(DEFAULT_PRIORITY+1, NewFlowMatch(3, 7, NoHeaderValues())))
+	ft.AddRule(NewPriorityRule(DEFAULT_PRIORITY+2, NewFlowMatch(3, ANY_PORT, vlanHeaders(10)), 5))
+	ft.AddRule(NewPriorityRule(DEFAULT_PRIORITY-1, NewFlowMatch(ANY_HOST, ANY_PORT, NoHeaderValues()), 9))
+
+	cases := []struct {
+		name   string
+		packet FlowMatch
+		want   []int64
+	}{
+		{"entry", NewFlowMatch(3, 1, vlanHeaders(20)), []int64{2}},
+		{"drop rule above an entry", NewFlowMatch(3, 7, vlanHeaders(20)), []int64{}},
+		{"header rule above a drop rule", NewFlowMatch(3, 7, vlanHeaders(10)), []int64{5}},
+		{"wildcard rule below the entries", NewFlowMatch(4, 1, vlanHeaders(10)), []int64{9}},
+	}
+	for _, c := range cases {
+		if got := ft.Lookup(c.packet); !slices.Equal(got, c.want) {
+			t.Errorf("%s: got ports %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	rules := ft.PriorityRules()
+	if !slices.IsSortedFunc(rules, func(a, b PriorityRule) int { return b.Priority - a.Priority }) {
+		t.Errorf("the rules are not ordered by priority: %v", rules)
+	}
+}
+
+func TestLookupOrderAtEqualPriority(t *testing.T) {
+	ft := NewFlowTable()
+	ft.AddEntry(NewFlowMatch(3, 1, NoHeaderValues()), 2)
+	ft.AddRule(NewPriorityRule(DEFAULT_PRIORITY, NewFlowMatch(3, ANY_PORT, NoHeaderValues()), 6))
+
+	// the rules added with AddRule come before the entries of the same priority
+	if got := ft.Lookup(NewFlowMatch(3, 1, NoHeaderValues())); !slices.Equal(got, []int64{6}) {
+		t.Errorf("got ports %v, want [6]", got)
+	}
+
+	// without prioritised rules, only the exact entries are looked up
+	ft = NewFlowTable()
+	ft.AddEntry(NewFlowMatch(3, 1, NoHeaderValues()), 2)
+	if got := ft.Lookup(NewFlowMatch(3, 1, vlanHeaders(10))); len(got) != 0 {
+		t.Errorf("got ports %v, want none", got)
+	}
+}
+
+func TestPriorityRulesToNetKATPolicies(t *testing.T) {
+	rules := []PriorityRule{
+		NewDropRule(DEFAULT_PRIORITY+1, NewFlowMatch(3, 7, NoHeaderValues())),
+		NewPriorityRule(DEFAULT_PRIORITY, NewFlowMatch(3, 7, NoHeaderValues()), 4),
+		NewPriorityRule(DEFAULT_PRIORITY, NewFlowMatch(3, ANY_PORT, NoHeaderValues()), 2),
+	}
+
+	// the drop rule only guards the later rules, and the fully shadowed rule is left out
+	policies := PriorityRulesToNetKATPolicies(rules)
+	if len(policies) != 1 {
+		t.Fatalf("got %d policies, want 1", len(policies))
+	}
+	if got, want := netkat.Format(policies[0], netkat.PLAIN_SYMBOLS), "(dst = 3);~(port = 7);(port <- 2)"; got != want {
+		t.Errorf("got '%s', want '%s'", got, want)
+	}
+}
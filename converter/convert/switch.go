@@ -2,6 +2,7 @@ package convert
 
 import (
 	"errors"
+	"slices"
 
 	"gonum.org/v1/gonum/graph"
 )
@@ -65,6 +66,66 @@ func (s *Switch) GetLinkPorts(otherNodeId int64) (int64, int64, error) {
 	return 0, 0, errors.New("Could not find link between switches!")
 }
 
+func (s *Switch) Links() []*Link {
+	return s.links
+}
+
+// Returns the port at the other end of the link whose end at this switch is the given port
+func (s *Switch) PeerPort(port int64) (int64, bool) {
+	for _, link := range s.links {
+		if link.ownPort(s.topoNode.ID()) == port {
+			return link.peerPort(s.topoNode.ID()), true
+		}
+	}
+	return 0, false
+}
+
+// Returns the updated flow table that the controller of the switch will install, if any
+func (s *Switch) NewFlowTable() (*FlowTable, bool) {
+	if s.controller == nil {
+		return nil, false
+	}
+	ft, exists := s.controller.newFlowTables[s.topoNode.ID()]
+	return ft, exists
+}
+
+// Returns the current flow table of the switch and, if it will receive an update, the new one
+func (s *Switch) FlowTables() []*FlowTable {
+	fts := []*FlowTable{s.flowTable}
+	if newFt, exists := s.NewFlowTable(); exists {
+		fts = append(fts, newFt)
+	}
+	return fts
+}
+
+/*
+Returns the sorted ports of the switch: the ends of its links, the ports of its hosts and
+the incoming ports of its flow rules, which include the ports of hosts created later on.
+*/
+func (s *Switch) Ports() []int64 {
+	ports := []int64{}
+	addPort := func(port int64) {
+		if port != ANY_PORT && !slices.Contains(ports, port) {
+			ports = append(ports, port)
+		}
+	}
+
+	for _, link := range s.links {
+		addPort(link.ownPort(s.topoNode.ID()))
+	}
+	for _, h := range s.hosts {
+		addPort(h.switchPort)
+	}
+	for _, ft := range s.FlowTables() {
+		for _, rule := range ft.PriorityRules() {
+			addPort(rule.Match.InPort)
+		}
+	}
+
+	slices.Sort(ports)
+	return ports
+}
+
 func (s *Switch) GetController() *Controller {
 	return s.controller
 }
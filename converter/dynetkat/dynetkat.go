@@ -0,0 +1,299 @@
+/*
+Package dynetkat is the library interface of the converter, for Go programs that generate and
+encode networks in-process instead of running the command-line tool:
+
+	net, err := dynetkat.Generate(
+		ctx,
+		dynetkat.File("../topologyzoo/sources/graphml/Abilene.graphml"),
+		dynetkat.Scenario{Difficulty: behavior.HARD},
+		dynetkat.Options{Seed: 7},
+	)
+	if err != nil {
+		return err
+	}
+	err = dynetkat.Encode(net, dynetkat.LATEX, os.Stdout)
+
+The same arguments always give the same network, and the command-line tool gives the same
+networks as this package with the default seed. Different networks can be generated and encoded
+concurrently. The functions, types and formats of this package are kept stable; the packages it
+builds on, e.g. convert and util, are not.
+*/
+package dynetkat
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"path/filepath"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/decode"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/metrics"
+	behavior "utwente.nl/topology-to-dynetkat-coverter/convert/network_behavior"
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+const (
+	DEFAULT_SEED      = util.SEED
+	DEFAULT_PLACEMENT = "random-replc"
+)
+
+// Where the topology of a network comes from, see File, Synthetic and FromTopology
+type TopologySource interface {
+	/*
+		Returns the name and the topology. Sources that build random topologies draw from r.
+		Sources return the error of ctx if it is canceled before they are done.
+	*/
+	Load(ctx context.Context, r *rand.Rand) (string, util.Topology, error)
+}
+
+type fileSource struct {
+	path string
+}
+
+type syntheticSource struct {
+	spec string
+}
+
+type topologySource struct {
+	name string
+	topo util.Topology
+}
+
+/*
+Loads a topology file, e.g. of the Topology Zoo, in any of the formats of
+util.SupportedTopologyExts. The topology is named after the file.
+*/
+func File(path string) TopologySource {
+	return fileSource{path: path}
+}
+
+// Generates a synthetic topology from a specification such as 'fattree:4' or 'waxman:50:0.4:0.2', see util.GenerateTopology
+func Synthetic(spec string) TopologySource {
+	return syntheticSource{spec: spec}
+}
+
+// Uses a topology that was already loaded or built
+func FromTopology(name string, topo util.Topology) TopologySource {
+	return topologySource{name: name, topo: topo}
+}
+
+func (s fileSource) Load(ctx context.Context, r *rand.Rand) (string, util.Topology, error) {
+	if err := ctx.Err(); err != nil {
+		return "", util.Topology{}, err
+	}
+	topo, err := util.GetTopology(s.path)
+	return filepath.Base(s.path), topo, err
+}
+
+func (s syntheticSource) Load(ctx context.Context, r *rand.Rand) (string, util.Topology, error) {
+	if err := ctx.Err(); err != nil {
+		return "", util.Topology{}, err
+	}
+	name, g, err := util.GenerateTopology(s.spec, r)
+	if err != nil {
+		return "", util.Topology{}, err
+	}
+	return name, util.NewTopology(g), nil
+}
+
+func (s topologySource) Load(ctx context.Context, r *rand.Rand) (string, util.Topology, error) {
+	return s.name, s.topo, nil
+}
+
+/*
+The behavior of a generated network. The zero value is the default scenario of the converter:
+hosts that are connected with each other, and controllers that update the switches in every
+update round to connect new outside hosts (see behavior.OutsideHostConn). A difficulty scales
+all the parameters together (see behavior.Difficulty.Profile), and the non-zero counts
+override those of the difficulty.
+*/
+type Scenario struct {
+	Difficulty   behavior.Difficulty // 0: the defaults of behavior.OutsideHostConn
+	Hosts        uint
+	OutsideHosts uint // per update round
+	Controllers  uint
+	UpdateRounds uint
+	// replaces the scenario above with another behavior, e.g. one defined outside the converter
+	Behavior behavior.Behavior
+}
+
+// How a network is generated. The zero value selects the defaults of the command-line tool.
+type Options struct {
+	Seed      int64  // seed of the random picks, 0: DEFAULT_SEED
+	Placement string // host placement, see convert.ParseHostPlacement, '': DEFAULT_PLACEMENT
+	// header fields matched by the flow rules, see convert.ParseHeaderSchema, '': those of the difficulty
+	Headers string
+	// how disconnected topologies are handled. SPLIT_COMPONENTS is not supported, since a network
+	// has one topology; split them with util.RepairTopology and generate a network per component.
+	Repair util.RepairStrategy
+}
+
+/*
+A generated or loaded network. A network is not safe for concurrent use, but different networks
+can be used concurrently.
+*/
+type Network struct {
+	name string
+	net  *convert.Network
+}
+
+// The sizes of a network
+type Summary struct {
+	Switches        int    `json:"switches"`
+	Links           int    `json:"links"`
+	Hosts           int    `json:"hosts"`
+	OutsideHosts    int    `json:"outsideHosts"`
+	Controllers     int    `json:"controllers"`
+	UpdatedSwitches int    `json:"updatedSwitches"`
+	HeaderFields    string `json:"headerFields"` // see convert.ParseHeaderSchema
+}
+
+/*
+Loads the topology from the source and generates its network for the scenario. The topology and
+the network draw from separate random generators with the same seed, so the network does not
+depend on how its topology was built. The context is checked before and after the topology is
+loaded, and while the flow rules of the hosts are built; a canceled context returns its error.
+*/
+func Generate(ctx context.Context, source TopologySource, scenario Scenario, opts Options) (*Network, error) {
+	if source == nil {
+		return nil, errors.New("Nil topology source!")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	seed := cmp.Or(opts.Seed, DEFAULT_SEED)
+
+	name, topo, err := source.Load(ctx, util.NewRand(seed))
+	if err != nil {
+		return nil, err
+	}
+	topo, err = repairTopology(name, topo, opts.Repair)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	hostPlacement, err := convert.ParseHostPlacement(cmp.Or(opts.Placement, DEFAULT_PLACEMENT))
+	if err != nil {
+		return nil, err
+	}
+	headerSchema, err := convert.ParseHeaderSchema(opts.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	b := scenario.Behavior
+	if b == nil {
+		outsideHostConn := behavior.OutsideHostConn{}
+		if scenario.Difficulty != 0 {
+			profile := scenario.Difficulty.Profile()
+			outsideHostConn = *profile.Behavior(uint(topo.Graph.Nodes().Len()))
+			if opts.Headers == "" {
+				headerSchema = profile.HeaderSchema()
+			}
+		}
+		outsideHostConn.HostsNr = cmp.Or(scenario.Hosts, outsideHostConn.HostsNr)
+		outsideHostConn.OutsideHostsNr = cmp.Or(scenario.OutsideHosts, outsideHostConn.OutsideHostsNr)
+		outsideHostConn.ControllersNr = cmp.Or(scenario.Controllers, outsideHostConn.ControllersNr)
+		outsideHostConn.UpdateRounds = cmp.Or(scenario.UpdateRounds, outsideHostConn.UpdateRounds)
+		b = &outsideHostConn
+	}
+
+	net, err := behavior.NewNetworkWithBehavior(
+		topo,
+		b,
+		convert.NetworkOptions{
+			HostPlacement: hostPlacement,
+			HeaderSchema:  headerSchema,
+			Rand:          util.NewRand(seed),
+			Context:       ctx,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Network{name: name, net: net}, nil
+}
+
+func repairTopology(name string, topo util.Topology, strategy util.RepairStrategy) (util.Topology, error) {
+	if strategy == util.SPLIT_COMPONENTS {
+		return util.Topology{}, errors.New("Splitting topologies is not supported, keep the largest component instead!")
+	}
+
+	if topo.Graph.Nodes().Len() > 0 {
+		topo = util.RepairTopology(name, topo, strategy)[name]
+	}
+	err := util.ValidateTopology(topo.Graph)
+	if err != nil {
+		return util.Topology{}, errors.New(fmt.Sprintf("%s: %s", name, err))
+	}
+	return topo, nil
+}
+
+// Reads a network saved in the NETWORK_JSON format, named after its metadata or else 'defaultName'
+func ReadNetwork(r io.Reader, defaultName string) (*Network, error) {
+	net, name, err := convert.ReadNetworkJSON(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Network{name: cmp.Or(name, defaultName), net: net}, nil
+}
+
+// Parses a DyNetKAT program in the LATEX or STANDALONE_LATEX format and rebuilds its network
+func ReadProgram(r io.Reader, name string) (*Network, error) {
+	program, err := decode.NewLatexParser().Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	net, err := program.ToNetwork()
+	if err != nil {
+		return nil, err
+	}
+	return &Network{name: name, net: net}, nil
+}
+
+// The name of the topology of the network
+func (n *Network) Name() string {
+	return n.name
+}
+
+func (n *Network) Topology() util.Topology {
+	return n.net.Topology()
+}
+
+func (n *Network) Summary() Summary {
+	topo := n.net.Topology()
+	updatedSwitches := 0
+	for _, c := range n.net.Controllers() {
+		updatedSwitches += len(c.NewFlowTables())
+	}
+
+	return Summary{
+		Switches:        len(n.net.Switches()),
+		Links:           topo.Graph.Edges().Len(),
+		Hosts:           len(n.net.Hosts()),
+		OutsideHosts:    len(n.net.CreatedHosts()) - len(n.net.Hosts()),
+		Controllers:     len(n.net.Controllers()),
+		UpdatedSwitches: updatedSwitches,
+		HeaderFields:    n.net.HeaderSchema().String(),
+	}
+}
+
+// Returns the size and complexity of the DyNetKAT encoding of the network with the given options
+func (n *Network) Metrics(opts EncodeOptions) (metrics.Metrics, error) {
+	return metrics.Compute(n.net, opts.latexOptions(n, false))
+}
+
+/*
+Returns the network of the convert package, e.g. to use an exporter of the export package.
+Unlike the rest of this package, its API may change.
+*/
+func (n *Network) Unwrap() *convert.Network {
+	return n.net
+}
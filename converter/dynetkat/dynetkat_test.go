@@ -0,0 +1,58 @@
+package dynetkat
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/encode"
+	behavior "utwente.nl/topology-to-dynetkat-coverter/convert/network_behavior"
+)
+
+// cancels the context once the network is built, before the hosts are connected
+type cancelingBehavior struct {
+	cancel context.CancelFunc
+}
+
+func (b *cancelingBehavior) ModifyNetwork(n *convert.Network) error {
+	b.cancel()
+	return (&behavior.OutsideHostConn{HostsNr: 4}).ModifyNetwork(n)
+}
+
+func TestGenerateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, source := range []TopologySource{Synthetic("ring:5"), File("missing.graphml")} {
+		_, err := Generate(ctx, source, Scenario{}, Options{})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v for a canceled context, want %v", err, context.Canceled)
+		}
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	_, err := Generate(ctx, Synthetic("ring:5"), Scenario{Behavior: &cancelingBehavior{cancel}}, Options{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v for a context canceled while building, want %v", err, context.Canceled)
+	}
+}
+
+func TestEncodeProactiveSwitch(t *testing.T) {
+	n, err := Generate(context.Background(), Synthetic("ring:5"), Scenario{}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, proactive := range []bool{false, true} {
+		var sb strings.Builder
+		err := EncodeWithOptions(n, LATEX, &sb, EncodeOptions{ProactiveSwitch: proactive})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(sb.String(), encode.HELP_CHANNEL_NAME); got != proactive {
+			t.Errorf("with ProactiveSwitch %t, got help channels: %t", proactive, got)
+		}
+	}
+}
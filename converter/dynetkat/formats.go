@@ -0,0 +1,127 @@
+package dynetkat
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert/encode"
+	"utwente.nl/topology-to-dynetkat-coverter/convert/export"
+)
+
+// the names of the formats networks can be encoded in
+const (
+	LATEX            = "latex"            // the DyNetKAT program in LaTeX
+	STANDALONE_LATEX = "latex-standalone" // a LaTeX document with the program and a drawing of the topology
+	OFCTL            = "ofctl"            // an ovs-ofctl script that installs the flow tables
+	OPENFLOW_JSON    = "ofjson"           // OpenFlow 1.3 flow-mods as JSON
+	MININET          = "mininet"          // a Mininet script that emulates the network
+	DOT              = "dot"              // a Graphviz drawing of the network
+	GEOJSON          = "geojson"          // the network on a map
+	NETWORK_JSON     = "network"          // the whole network as versioned JSON, see ReadNetwork
+	MARKDOWN_REPORT  = "report"           // a report for reviewers in Markdown
+	HTML_REPORT      = "report-html"      // the same report as an HTML page
+)
+
+// A format networks can be encoded in, with the extension and content type of its files
+type Format struct {
+	Name        string `json:"name"`
+	Extension   string `json:"extension"`
+	ContentType string `json:"contentType"`
+}
+
+var FORMATS = []Format{
+	{LATEX, ".txt", "text/plain; charset=utf-8"},
+	{STANDALONE_LATEX, ".tex", "application/x-tex"},
+	{OFCTL, ".sh", "text/plain; charset=utf-8"},
+	{OPENFLOW_JSON, ".json", "application/json"},
+	{MININET, ".py", "text/plain; charset=utf-8"},
+	{DOT, ".dot", "text/plain; charset=utf-8"},
+	{GEOJSON, ".geojson", "application/geo+json"},
+	{NETWORK_JSON, ".network.json", "application/json"},
+	{MARKDOWN_REPORT, ".report.md", "text/markdown; charset=utf-8"},
+	{HTML_REPORT, ".report.html", "text/html; charset=utf-8"},
+}
+
+// Options of the encodings, each used by the formats given before it. The zero value selects the defaults.
+type EncodeOptions struct {
+	// LATEX, STANDALONE_LATEX: add the topology as NetKAT link terms and the network term (policy;topology)*
+	Links bool
+	// LATEX, STANDALONE_LATEX: encode each flow table as one factored NetKAT policy
+	Compact bool
+	// LATEX, STANDALONE_LATEX: compress the flow tables into prioritised rules with port wildcards
+	Optimize bool
+	// LATEX, STANDALONE_LATEX: the switches ask their controller for help before they receive an update
+	ProactiveSwitch bool
+	// STANDALONE_LATEX: title of the document, '': 'DyNetKAT encoding of <name>'
+	Title string
+	// MININET: delay after which the script installs the updated flow tables, 0: not installed
+	UpdateDelay time.Duration
+	// DOT: overlay the forwarding paths towards the host with this id, nil: none
+	PathDest *int64
+}
+
+// Returns the format with the given name and whether it exists
+func LookupFormat(name string) (Format, bool) {
+	i := slices.IndexFunc(FORMATS, func(f Format) bool { return f.Name == name })
+	if i < 0 {
+		return Format{}, false
+	}
+	return FORMATS[i], true
+}
+
+// Writes the network to w in the format with the given name, with the default options
+func Encode(n *Network, format string, w io.Writer) error {
+	return EncodeWithOptions(n, format, w, EncodeOptions{})
+}
+
+// Writes the network to w in the format with the given name
+func EncodeWithOptions(n *Network, format string, w io.Writer, opts EncodeOptions) error {
+	var exp export.NetworkExporter
+	switch format {
+	case LATEX, STANDALONE_LATEX:
+		encoder := encode.NewLatexEncoderWithOptions(opts.latexOptions(n, format == STANDALONE_LATEX))
+		return encoder.EncodeTo(n.net, w)
+	case OFCTL:
+		exp = &export.OfctlExporter{}
+	case OPENFLOW_JSON:
+		exp = &export.OpenFlowJSONExporter{}
+	case MININET:
+		exp = &export.MininetExporter{UpdateDelay: opts.UpdateDelay}
+	case DOT:
+		dotExp := export.NewDOTExporter()
+		if opts.PathDest != nil {
+			dotExp.PathDest = *opts.PathDest
+		}
+		exp = dotExp
+	case GEOJSON:
+		exp = &export.GeoJSONExporter{}
+	case NETWORK_JSON:
+		exp = &export.NetworkJSONExporter{Name: n.name}
+	case MARKDOWN_REPORT:
+		exp = &export.ReportExporter{Name: n.name}
+	case HTML_REPORT:
+		exp = &export.ReportExporter{Name: n.name, Format: export.HTML_REPORT}
+	default:
+		return errors.New(fmt.Sprintf("Unknown format '%s'!", format))
+	}
+	return exp.Export(n.net, w)
+}
+
+func (opts EncodeOptions) latexOptions(n *Network, standalone bool) encode.LatexEncoderOptions {
+	title := opts.Title
+	if title == "" {
+		title = "DyNetKAT encoding of " + n.name
+	}
+
+	return encode.LatexEncoderOptions{
+		CompactPolicies:    opts.Compact,
+		LinkTerms:          opts.Links,
+		OptimizeFlowTables: opts.Optimize,
+		ProactiveSwitch:    opts.ProactiveSwitch,
+		Standalone:         standalone,
+		Title:              title,
+	}
+}
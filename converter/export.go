@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"utwente.nl/topology-to-dynetkat-coverter/dynetkat"
+)
+
+// Writes the generated network of a topology in the format of another tool
+func runExport(args []string) {
+	fs := flag.NewFlagSet(EXPORT_CMD, flag.ExitOnError)
+	nf := addNetworkFlags(fs)
+	formats := []string{}
+	for _, format := range dynetkat.FORMATS {
+		formats = append(formats, format.Name)
+	}
+	format := fs.String("format", dynetkat.OFCTL, "export format: "+strings.Join(formats, ", "))
+	outPath := fs.String("out", "", "file to write to (default: <output dir>/<topology><extension>)")
+	updateDelay := fs.Duration(
+		"update-delay",
+		0,
+		"mininet: delay after which the script installs the updated flow tables, e.g. '10s' (0: not installed)",
+	)
+	pathDest := fs.Int64("path-dest", -1, "dot: overlay the forwarding paths towards the host with this id (-1: none)")
+	fs.Parse(args)
+
+	f, exists := dynetkat.LookupFormat(*format)
+	if !exists {
+		log.Fatalf("Unknown export format '%s'. Available formats: %s\n", *format, strings.Join(formats, ", "))
+	}
+
+	opts := dynetkat.EncodeOptions{UpdateDelay: *updateDelay}
+	if *pathDest >= 0 {
+		opts.PathDest = pathDest
+	}
+
+	network := nf.build()
+	log.Printf("Exporting topology with id %s as '%s'...\n", network.Name(), *format)
+
+	if *outPath == "" {
+		*outPath = outputPath(network.Name(), f.Extension)
+	}
+	err := encodeToFile(network, f.Name, opts, *outPath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	log.Println("Done!")
+}
+
+// Returns the default path of the files written for the topology, <output dir>/<topology><extension>
+func outputPath(networkId, extension string) string {
+	name := strings.TrimSuffix(networkId, filepath.Ext(networkId))
+	return filepath.Join(OUTPUT_DIR, name+extension)
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
-	"utwente.nl/topology-to-dynetkat-coverter/convert/encode"
-	behavior "utwente.nl/topology-to-dynetkat-coverter/convert/network_behavior"
+	"utwente.nl/topology-to-dynetkat-coverter/dynetkat"
 	"utwente.nl/topology-to-dynetkat-coverter/util"
 )
 
@@ -14,38 +18,91 @@ const (
 	HOSTS_NR   = 5
 	// NETWORK_ID = "Atmnet.graphml" // 21 nodes
 	NETWORK_ID = "Arpanet196912.graphml" // 4 nodes
+
+	ENCODE_CMD = "encode"
+	STATS_CMD  = "stats"
+	EXPORT_CMD = "export"
+	REPORT_CMD = "report"
+	BATCH_CMD  = "batch"
+	SERVE_CMD  = "serve"
 )
 
 func main() {
-	graphMLs, err := util.GetGraphMLs(DIR)
-	if err != nil {
-		log.Fatalf("Failed to load graphs from directory: %s\n%s", DIR, err.Error())
+	// the encode command is the default one, so that it can be invoked only with flags
+	command, args := ENCODE_CMD, os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
 	}
 
-	gs := util.GraphMLsToGraphs(graphMLs)
-	validTopos := util.ValidateTopologies(gs)
-
-	log.Printf("Generating DyNetKAT encoding for topology with id: %s...\n", NETWORK_ID)
-	topo, exists := validTopos[NETWORK_ID]
-
-	if !exists {
-		log.Fatalf("Topology with name '%s' is either invalid or does not exist\n", NETWORK_ID)
+	switch command {
+	case ENCODE_CMD:
+		runEncode(args)
+	case STATS_CMD:
+		runStats(args)
+	case EXPORT_CMD:
+		runExport(args)
+	case REPORT_CMD:
+		runReport(args)
+	case BATCH_CMD:
+		runBatch(args)
+	case SERVE_CMD:
+		runServe(args)
+	default:
+		log.Fatalf(
+			"Unknown command '%s'. Available commands: %s\n",
+			command,
+			strings.Join([]string{ENCODE_CMD, STATS_CMD, EXPORT_CMD, REPORT_CMD, BATCH_CMD, SERVE_CMD}, ", "),
+		)
 	}
+}
 
-	network, err := behavior.NewNetworkWithBehavior(
-		topo,
-		&behavior.OutsideHostConn{},
+func runEncode(args []string) {
+	fs := flag.NewFlagSet(ENCODE_CMD, flag.ExitOnError)
+	nf := addNetworkFlags(fs)
+	compact := fs.Bool("compact", false, "encode each flow table as one factored NetKAT policy")
+	links := fs.Bool("links", false, "add the topology as NetKAT link terms and the network term (policy;topology)*")
+	optimize := fs.Bool("optimize", false, "compress the flow tables into prioritised rules with port wildcards")
+	proactive := fs.Bool("proactive", false, "let the switches ask their controller for help before they receive an update")
+	standalone := fs.Bool(
+		"standalone",
+		false,
+		"write a complete LaTeX document (output.tex) with a drawing of the topology and tables of the hosts and controllers",
 	)
+	fs.Parse(args)
+
+	network := nf.build()
+	log.Printf("Generating DyNetKAT encoding for topology with id: %s...\n", network.Name())
+
+	opts := dynetkat.EncodeOptions{Links: *links, Compact: *compact, Optimize: *optimize, ProactiveSwitch: *proactive}
+	format, fileName := dynetkat.LATEX, "output.txt"
+	if *standalone {
+		format, fileName = dynetkat.STANDALONE_LATEX, "output.tex"
+	}
+	err := encodeToFile(network, format, opts, filepath.Join(OUTPUT_DIR, fileName))
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	encoder := encode.NewLatexEncoder(false)
-	fmtNet, err := encoder.Encode(network)
+	m, err := network.Metrics(opts)
 	if err != nil {
 		log.Fatalln(err)
 	}
-
-	util.WriteToNewFile(OUTPUT_DIR, "output.txt", fmtNet)
+	log.Printf("Encoded %s\n", m)
 	log.Println("Done!")
 }
+
+// Writes the network in the format to a new file, creating its directory if needed
+func encodeToFile(network *dynetkat.Network, format string, opts dynetkat.EncodeOptions, path string) error {
+	f, err := util.CreateNewFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	err = dynetkat.EncodeWithOptions(network, format, w, opts)
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	behavior "utwente.nl/topology-to-dynetkat-coverter/convert/network_behavior"
+	"utwente.nl/topology-to-dynetkat-coverter/dynetkat"
+)
+
+// flags shared by all commands that generate a network from a topology
+type networkFlags struct {
+	topologyFlags *topologyFlags
+	networkId     *string
+	placement     *string
+	headers       *string
+	difficulty    *string
+	networkFile   *string
+}
+
+func addNetworkFlags(fs *flag.FlagSet) *networkFlags {
+	return &networkFlags{
+		topologyFlags: addTopologyFlags(fs),
+		networkId:     fs.String("topology", NETWORK_ID, "name of the topology to encode"),
+		placement:     addPlacementFlag(fs),
+		headers: fs.String(
+			"headers",
+			"",
+			"optional header fields matched by the flow rules, e.g. 'src,ethType=0x800,vlan=10,ipProto=6,tcpDst=80'",
+		),
+		difficulty: fs.String(
+			"difficulty",
+			"",
+			"scale the hosts, outside hosts, controllers, update rounds and header fields with a difficulty "+
+				"profile: 'easy', 'medium', 'hard', 'extreme' or a level from 1 (-headers overrides its header fields)",
+		),
+		networkFile: fs.String(
+			"network",
+			"",
+			"load a network instead of generating one: a .json file saved with 'export -format network' "+
+				"or a DyNetKAT program written by 'encode'",
+		),
+	}
+}
+
+func addPlacementFlag(fs *flag.FlagSet) *string {
+	return fs.String(
+		"placement",
+		dynetkat.DEFAULT_PLACEMENT,
+		"host placement: 'random-replc', 'random' (without replacement), 'edge' (least-degree switches), "+
+			"'external' (nodes with Internal=0), 'far' (max hop distance) or 'labels:<l1>,<l2>,...'",
+	)
+}
+
+/*
+Loads the topology selected by the flags and generates its network, or loads the saved network
+if one is given. The network is named after the topology.
+*/
+func (nf *networkFlags) build() *dynetkat.Network {
+	if *nf.networkFile != "" {
+		return loadNetwork(*nf.networkFile)
+	}
+
+	validTopos, genName := nf.topologyFlags.load()
+	networkId := *nf.networkId
+	if genName != "" {
+		networkId = genName
+	}
+
+	topo, exists := validTopos[networkId]
+	if !exists {
+		log.Fatalf("Topology with name '%s' is either invalid or does not exist\n", networkId)
+	}
+
+	scenario := dynetkat.Scenario{}
+	if *nf.difficulty != "" {
+		difficulty, err := behavior.ParseDifficulty(*nf.difficulty)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		scenario.Difficulty = difficulty
+	}
+
+	network, err := dynetkat.Generate(
+		context.Background(),
+		dynetkat.FromTopology(networkId, topo),
+		scenario,
+		dynetkat.Options{Placement: *nf.placement, Headers: *nf.headers},
+	)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return network
+}
+
+/*
+Loads a network saved in its JSON representation or, for other extensions, parses the DyNetKAT
+program of a network. Its name defaults to the file name.
+*/
+func loadNetwork(path string) *dynetkat.Network {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+
+	var network *dynetkat.Network
+	if filepath.Ext(path) == ".json" {
+		network, err = dynetkat.ReadNetwork(f, filepath.Base(path))
+	} else {
+		network, err = dynetkat.ReadProgram(f, filepath.Base(path))
+	}
+	if err != nil {
+		log.Fatalf("Failed to load network from %s.\n%s", path, err.Error())
+	}
+	return network
+}
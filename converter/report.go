@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"utwente.nl/topology-to-dynetkat-coverter/convert/export"
+	"utwente.nl/topology-to-dynetkat-coverter/dynetkat"
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+/*
+Writes the DyNetKAT encoding of a network, its exports in the given formats and a report that
+describes the network and links to these files, all in the output directory.
+*/
+func runReport(args []string) {
+	fs := flag.NewFlagSet(REPORT_CMD, flag.ExitOnError)
+	nf := addNetworkFlags(fs)
+	format := fs.String("format", export.MARKDOWN_REPORT, "report format: 'md' or 'html'")
+	exports := fs.String(
+		"exports",
+		"network,ofctl,dot",
+		"comma-separated export formats written next to the report (see the export command)",
+	)
+	links := fs.Bool("links", false, "add the topology as NetKAT link terms to the encoding")
+	fs.Parse(args)
+
+	formats := []string{}
+	if *exports != "" {
+		formats = strings.Split(*exports, ",")
+	}
+	for _, f := range formats {
+		if _, exists := dynetkat.LookupFormat(f); !exists || strings.HasPrefix(f, dynetkat.MARKDOWN_REPORT) {
+			log.Fatalf("Unknown export format '%s'!\n", f)
+		}
+	}
+
+	network := nf.build()
+	log.Printf("Writing report for topology with id: %s...\n", network.Name())
+
+	files := []export.ReportFile{}
+	addFile := func(title, path string) {
+		files = append(files, export.ReportFile{Title: title, Path: filepath.Base(path)})
+	}
+
+	for _, standalone := range []bool{false, true} {
+		encoding, title, path := dynetkat.LATEX, "DyNetKAT encoding (LaTeX)", outputPath(network.Name(), ".dnk.txt")
+		if standalone {
+			encoding, title = dynetkat.STANDALONE_LATEX, "DyNetKAT encoding (standalone LaTeX document)"
+			path = outputPath(network.Name(), ".tex")
+		}
+		err := encodeToFile(network, encoding, dynetkat.EncodeOptions{Links: *links}, path)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		addFile(title, path)
+	}
+
+	for _, f := range formats {
+		exportFormat, _ := dynetkat.LookupFormat(f)
+		path := outputPath(network.Name(), exportFormat.Extension)
+		err := encodeToFile(network, f, dynetkat.EncodeOptions{}, path)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		addFile("Export: "+f, path)
+	}
+
+	extension := ".report.md"
+	if *format == export.HTML_REPORT {
+		extension = ".report.html"
+	}
+	reportPath := outputPath(network.Name(), extension)
+	err := writeReport(
+		&export.ReportExporter{Name: network.Name(), Format: *format, Files: files},
+		network,
+		reportPath,
+	)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	log.Printf("Done! The report is at %s\n", reportPath)
+}
+
+// Writes the report to a new file, since the reports that link to other files are not a format of the library
+func writeReport(exp *export.ReportExporter, network *dynetkat.Network, path string) error {
+	f, err := util.CreateNewFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return exp.Export(network.Unwrap(), f)
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	behavior "utwente.nl/topology-to-dynetkat-coverter/convert/network_behavior"
+	"utwente.nl/topology-to-dynetkat-coverter/dynetkat"
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+const OUTSIDE_HOSTS_BEHAVIOR = "outside-hosts"
+
+// upper bounds of the requests of the serve command, so that one request cannot exhaust the server
+const (
+	MAX_REQUEST_BODY_SIZE     = 1 << 16 // bytes
+	MAX_REQUEST_GENERATE_LEN  = 64      // characters of a synthetic topology spec
+	MAX_REQUEST_GENERATE_SIZE = 1024    // nodes of a synthetic topology
+	MAX_REQUEST_HOSTS         = 256
+	MAX_REQUEST_OUTSIDE_HOSTS = 64 // per update round
+	MAX_REQUEST_CONTROLLERS   = 64
+	MAX_REQUEST_UPDATE_ROUNDS = 16
+)
+
+/*
+The parameters of a network generated by the serve command, given as the JSON body of a POST
+request or as the query parameters of a GET request. They have the meaning of the flags of the
+encode command, and the zero values select the same defaults. The sizes are bounded by the
+MAX_REQUEST_* constants, also when they are scaled by the difficulty.
+*/
+type generateRequest struct {
+	Topology     string `json:"topology"`
+	Generate     string `json:"generate,omitempty"` // a synthetic topology, e.g. 'ring:10', instead of a loaded one
+	Seed         int64  `json:"seed,omitempty"`     // 0: dynetkat.DEFAULT_SEED, as in the other commands
+	Behavior     string `json:"behavior,omitempty"`
+	Difficulty   string `json:"difficulty,omitempty"`
+	Hosts        uint   `json:"hosts,omitempty"`
+	OutsideHosts uint   `json:"outsideHosts,omitempty"` // per update round
+	Controllers  uint   `json:"controllers,omitempty"`
+	UpdateRounds uint   `json:"updateRounds,omitempty"`
+	Placement    string `json:"placement,omitempty"`
+	Headers      string `json:"headers,omitempty"`
+	Format       string `json:"format,omitempty"` // default: dynetkat.LATEX
+	Links        bool   `json:"links,omitempty"`
+	Compact      bool   `json:"compact,omitempty"`
+	Optimize     bool   `json:"optimize,omitempty"`
+	Proactive    bool   `json:"proactive,omitempty"`
+}
+
+type server struct {
+	topos  map[string]util.Topology
+	stats  []util.TopologyStats // sorted by name
+	repair util.RepairStrategy
+}
+
+/*
+Serves the converter over a local HTTP/JSON API, so that it can be called by other tools:
+
+	GET  /api/topologies              statistics of the loaded topologies (?sort=<column>&desc=true)
+	GET  /api/topologies/{name}       statistics of one topology
+	GET  /api/formats                 formats the networks can be downloaded in
+	GET  /api/networks?topology=...   generates a network and downloads it (see generateRequest)
+	POST /api/networks                the same, with the parameters in a JSON body
+
+Errors are returned as {"error": "..."}.
+*/
+func runServe(args []string) {
+	fs := flag.NewFlagSet(SERVE_CMD, flag.ExitOnError)
+	tf := addTopologyFlags(fs)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	fs.Parse(args)
+
+	repair, err := util.ParseRepairStrategy(*tf.repair)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// exits if the topologies cannot be loaded, a generated topology is also a key of 'topos'
+	topos, _ := tf.loadRaw()
+
+	// the statistics describe the topologies before any repair, the networks are generated from
+	// the validated ones
+	s := &server{topos: tf.validate(topos), stats: []util.TopologyStats{}, repair: repair}
+	for name, topo := range topos {
+		s.stats = append(s.stats, util.ComputeTopologyStats(name, topo))
+	}
+	util.SortTopologyStats(s.stats, "name", false)
+
+	log.Printf("Serving %d topologies at http://%s/api/\n", len(s.topos), *addr)
+	log.Fatalln(http.ListenAndServe(*addr, s.routes()))
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/topologies", s.handleTopologies)
+	mux.HandleFunc("GET /api/topologies/{name}", s.handleTopology)
+	mux.HandleFunc("GET /api/formats", s.handleFormats)
+	mux.HandleFunc("GET /api/networks", s.handleNetwork)
+	mux.HandleFunc("POST /api/networks", s.handleNetwork)
+	return mux
+}
+
+func (s *server) handleTopologies(w http.ResponseWriter, r *http.Request) {
+	stats := slices.Clone(s.stats)
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy != "" {
+		err := util.SortTopologyStats(stats, sortBy, r.URL.Query().Get("desc") == "true")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *server) handleTopology(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	i := slices.IndexFunc(s.stats, func(stats util.TopologyStats) bool { return stats.Name == name })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, errors.New(fmt.Sprintf("Unknown topology '%s'!", name)))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.stats[i])
+}
+
+func (s *server) handleFormats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, dynetkat.FORMATS)
+}
+
+/*
+Generates the requested network and writes it in the requested format, as an attachment named
+after the topology. The download is streamed while the network is encoded: failures before the
+first byte is written are reported with an error status, later failures abort the download, so
+that it does not end truncated.
+*/
+func (s *server) handleNetwork(w http.ResponseWriter, r *http.Request) {
+	req := generateRequest{}
+	var err error
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, MAX_REQUEST_BODY_SIZE))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&req)
+	} else {
+		req, err = parseGenerateQuery(r.URL.Query())
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeError(w, http.StatusRequestEntityTooLarge, errors.New(fmt.Sprintf(
+			"The request body is larger than %d bytes!", MAX_REQUEST_BODY_SIZE)))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New(fmt.Sprintf("Invalid request: %s", err)))
+		return
+	}
+
+	format, exists := dynetkat.LookupFormat(cmp.Or(req.Format, dynetkat.LATEX))
+	if !exists {
+		writeError(w, http.StatusBadRequest, errors.New(fmt.Sprintf("Unknown format '%s'!", req.Format)))
+		return
+	}
+
+	network, status, err := s.generate(r.Context(), req)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	download := &downloadWriter{
+		w:           w,
+		contentType: format.ContentType,
+		fileName:    strings.TrimSuffix(network.Name(), filepath.Ext(network.Name())) + format.Extension,
+	}
+	err = dynetkat.EncodeWithOptions(
+		network,
+		format.Name,
+		download,
+		dynetkat.EncodeOptions{Links: req.Links, Compact: req.Compact, Optimize: req.Optimize, ProactiveSwitch: req.Proactive},
+	)
+	switch {
+	case err == nil:
+		download.start()
+	case !download.started:
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		log.Printf("Aborted the download of '%s': %s\n", download.fileName, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// Writes a download, sending its headers with the first write
+type downloadWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	fileName    string
+	started     bool
+}
+
+func (d *downloadWriter) start() {
+	if d.started {
+		return
+	}
+	d.started = true
+	d.w.Header().Set("Content-Type", d.contentType)
+	d.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", d.fileName))
+	d.w.WriteHeader(http.StatusOK)
+}
+
+func (d *downloadWriter) Write(p []byte) (int, error) {
+	d.start()
+	return d.w.Write(p)
+}
+
+// generates the network of the request, returning the HTTP status of failures
+func (s *server) generate(ctx context.Context, req generateRequest) (*dynetkat.Network, int, error) {
+	if req.Behavior != "" && req.Behavior != OUTSIDE_HOSTS_BEHAVIOR {
+		return nil, http.StatusBadRequest, errors.New(fmt.Sprintf("Unknown behavior '%s'!", req.Behavior))
+	}
+
+	scenario := dynetkat.Scenario{
+		Hosts:        req.Hosts,
+		OutsideHosts: req.OutsideHosts,
+		Controllers:  req.Controllers,
+		UpdateRounds: req.UpdateRounds,
+	}
+	if req.Difficulty != "" {
+		difficulty, err := behavior.ParseDifficulty(req.Difficulty)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		scenario.Difficulty = difficulty
+	}
+	if err := checkScenarioBounds(scenario); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	var source dynetkat.TopologySource
+	switch topo, exists := s.topos[req.Topology]; {
+	case req.Generate != "":
+		if err := checkGenerateBounds(req.Generate); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		source = dynetkat.Synthetic(req.Generate)
+	case exists:
+		source = dynetkat.FromTopology(req.Topology, topo)
+	default:
+		return nil, http.StatusNotFound, errors.New(
+			fmt.Sprintf("Topology with name '%s' is either invalid or does not exist", req.Topology),
+		)
+	}
+
+	opts := dynetkat.Options{Seed: req.Seed, Placement: req.Placement, Headers: req.Headers}
+	// the loaded topologies are already split, the generated ones are kept whole
+	if s.repair != util.SPLIT_COMPONENTS {
+		opts.Repair = s.repair
+	}
+	network, err := dynetkat.Generate(ctx, source, scenario, opts)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return network, http.StatusOK, nil
+}
+
+// rejects the scenarios with more hosts, controllers or update rounds than a request may generate
+func checkScenarioBounds(scenario dynetkat.Scenario) error {
+	// the sizes that are not given are those of the difficulty, see dynetkat.Generate
+	if scenario.Difficulty != 0 {
+		profile := scenario.Difficulty.Profile()
+		scenario.Hosts = cmp.Or(scenario.Hosts, profile.HostsNr)
+		scenario.OutsideHosts = cmp.Or(scenario.OutsideHosts, profile.OutsideHostsNr)
+		scenario.Controllers = cmp.Or(scenario.Controllers, profile.ControllersNr)
+		scenario.UpdateRounds = cmp.Or(scenario.UpdateRounds, profile.UpdateRounds)
+	}
+
+	bounds := []struct {
+		name       string
+		value, max uint
+	}{
+		{"hosts", scenario.Hosts, MAX_REQUEST_HOSTS},
+		{"outside hosts per update round", scenario.OutsideHosts, MAX_REQUEST_OUTSIDE_HOSTS},
+		{"controllers", scenario.Controllers, MAX_REQUEST_CONTROLLERS},
+		{"update rounds", scenario.UpdateRounds, MAX_REQUEST_UPDATE_ROUNDS},
+	}
+	for _, bound := range bounds {
+		if bound.value > bound.max {
+			return errors.New(fmt.Sprintf(
+				"At most %d %s can be requested, got %d!", bound.max, bound.name, bound.value))
+		}
+	}
+	return nil
+}
+
+// rejects the synthetic topology specs that are too long or describe too large topologies
+func checkGenerateBounds(spec string) error {
+	if len(spec) > MAX_REQUEST_GENERATE_LEN {
+		return errors.New(fmt.Sprintf("The topology spec is longer than %d characters!", MAX_REQUEST_GENERATE_LEN))
+	}
+	nodesNr, err := util.GeneratedNodesNr(spec)
+	if err != nil {
+		return err
+	}
+	if nodesNr > MAX_REQUEST_GENERATE_SIZE {
+		return errors.New(fmt.Sprintf(
+			"At most %d topology nodes can be generated, got %d!", MAX_REQUEST_GENERATE_SIZE, nodesNr))
+	}
+	return nil
+}
+
+// parses the query parameters of a GET request, which have the names of the JSON fields
+func parseGenerateQuery(query url.Values) (generateRequest, error) {
+	req := generateRequest{
+		Topology:   query.Get("topology"),
+		Generate:   query.Get("generate"),
+		Behavior:   query.Get("behavior"),
+		Difficulty: query.Get("difficulty"),
+		Placement:  query.Get("placement"),
+		Headers:    query.Get("headers"),
+		Format:     query.Get("format"),
+	}
+
+	if query.Has("seed") {
+		seed, err := strconv.ParseInt(query.Get("seed"), 10, 64)
+		if err != nil {
+			return req, errors.New(fmt.Sprintf("Invalid seed '%s'!", query.Get("seed")))
+		}
+		req.Seed = seed
+	}
+
+	uints := map[string]*uint{
+		"hosts":        &req.Hosts,
+		"outsideHosts": &req.OutsideHosts,
+		"controllers":  &req.Controllers,
+		"updateRounds": &req.UpdateRounds,
+	}
+	for name, field := range uints {
+		if !query.Has(name) {
+			continue
+		}
+		value, err := strconv.ParseUint(query.Get(name), 10, 32)
+		if err != nil {
+			return req, errors.New(fmt.Sprintf("Invalid %s '%s'!", name, query.Get(name)))
+		}
+		*field = uint(value)
+	}
+
+	bools := map[string]*bool{
+		"links": &req.Links, "compact": &req.Compact, "optimize": &req.Optimize, "proactive": &req.Proactive,
+	}
+	for name, field := range bools {
+		if !query.Has(name) {
+			continue
+		}
+		value, err := strconv.ParseBool(query.Get(name))
+		if err != nil {
+			return req, errors.New(fmt.Sprintf("Invalid %s '%s'!", name, query.Get(name)))
+		}
+		*field = value
+	}
+
+	return req, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(v)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
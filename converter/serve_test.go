@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+func testServer(t *testing.T) http.Handler {
+	topo, err := util.EdgeListToTopology(strings.NewReader("a b\nb c\nc d\nd a\n"), "square.edges")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &server{
+		topos:  map[string]util.Topology{"square.edges": topo},
+		stats:  []util.TopologyStats{util.ComputeTopologyStats("square.edges", topo)},
+		repair: util.KEEP_LARGEST_COMPONENT,
+	}
+	return s.routes()
+}
+
+func serve(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func TestServeStatusCodes(t *testing.T) {
+	handler := testServer(t)
+	cases := []struct {
+		method, target, body string
+		status               int
+	}{
+		{"GET", "/api/topologies", "", http.StatusOK},
+		{"GET", "/api/topologies?sort=nodes&desc=true", "", http.StatusOK},
+		{"GET", "/api/topologies?sort=size", "", http.StatusBadRequest},
+		{"GET", "/api/topologies/square.edges", "", http.StatusOK},
+		{"GET", "/api/topologies/cube.edges", "", http.StatusNotFound},
+		{"GET", "/api/formats", "", http.StatusOK},
+		{"DELETE", "/api/networks", "", http.StatusMethodNotAllowed},
+
+		{"GET", "/api/networks?topology=square.edges&hosts=2", "", http.StatusOK},
+		{"GET", "/api/networks?generate=ring:5&format=dot&difficulty=easy", "", http.StatusOK},
+		{"GET", "/api/networks?topology=cube.edges", "", http.StatusNotFound},
+		{"GET", "/api/networks?topology=square.edges&format=pdf", "", http.StatusBadRequest},
+		{"GET", "/api/networks?topology=square.edges&hosts=two", "", http.StatusBadRequest},
+		{"GET", "/api/networks?topology=square.edges&behavior=chaos", "", http.StatusBadRequest},
+		{"GET", "/api/networks?generate=cube:3", "", http.StatusBadRequest},
+
+		{"POST", "/api/networks", `{"generate": "grid:2x3", "hosts": 3, "format": "network"}`, http.StatusOK},
+		{"POST", "/api/networks", `{"topology": "square.edges", "color": "red"}`, http.StatusBadRequest},
+		{"POST", "/api/networks", `{"topology": `, http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		rec := serve(handler, c.method, c.target, c.body)
+		if rec.Code != c.status {
+			t.Errorf("%s %s %s: got status %d, want %d: %s", c.method, c.target, c.body, rec.Code, c.status, rec.Body)
+		}
+	}
+}
+
+func TestServeRejectsLargeRequests(t *testing.T) {
+	handler := testServer(t)
+	targets := []string{
+		"/api/networks?topology=square.edges&hosts=100000",
+		"/api/networks?topology=square.edges&updateRounds=1000",
+		"/api/networks?topology=square.edges&outsideHosts=1000",
+		"/api/networks?topology=square.edges&controllers=1000",
+		// the difficulty scales the hosts and the other sizes that are not given
+		"/api/networks?topology=square.edges&difficulty=100000",
+		"/api/networks?generate=grid:1000x1000",
+		"/api/networks?generate=ring:5" + strings.Repeat("0", MAX_REQUEST_GENERATE_LEN),
+	}
+	for _, target := range targets {
+		rec := serve(handler, "GET", target, "")
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "!") {
+			t.Errorf("%s: got status %d, want %d: %s", target, rec.Code, http.StatusBadRequest, rec.Body)
+		}
+	}
+
+	body := `{"topology": "` + strings.Repeat("a", MAX_REQUEST_BODY_SIZE) + `"}`
+	if rec := serve(handler, "POST", "/api/networks", body); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got status %d for a large body, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestServeNetworkDownload(t *testing.T) {
+	rec := serve(testServer(t), "GET", "/api/networks?topology=square.edges&format=network&hosts=2", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="square.network.json"` {
+		t.Errorf("got Content-Disposition '%s'", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("got Content-Type '%s'", got)
+	}
+	var network struct {
+		Hosts []struct {
+			Connected bool `json:"connected"`
+		} `json:"hosts"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &network); err != nil {
+		t.Fatal(err)
+	}
+	// the outside hosts are only connected after an update
+	connectedNr := 0
+	for _, h := range network.Hosts {
+		if h.Connected {
+			connectedNr++
+		}
+	}
+	if connectedNr != 2 {
+		t.Errorf("got %d connected hosts, want 2", connectedNr)
+	}
+}
+
+func TestServeErrorsAreJSON(t *testing.T) {
+	rec := serve(testServer(t), "GET", "/api/topologies/cube.edges", "")
+
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || !strings.Contains(body["error"], "cube.edges") {
+		t.Errorf("got error body %s, %v", rec.Body, err)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+const (
+	CSV_FORMAT      = "csv"
+	MARKDOWN_FORMAT = "md"
+)
+
+// Writes a catalogue with the statistics of all the loaded topologies, as they are before any repair
+func runStats(args []string) {
+	fs := flag.NewFlagSet(STATS_CMD, flag.ExitOnError)
+	tf := addTopologyFlags(fs)
+	format := fs.String("format", CSV_FORMAT, "catalogue format: 'csv' or 'md'")
+	sortBy := fs.String(
+		"sort",
+		"name",
+		"column to sort by: "+strings.Join(util.STATS_COLUMNS, ", "),
+	)
+	descending := fs.Bool("desc", false, "sort in descending order")
+	outPath := fs.String("out", "", "file to write the catalogue to (default: standard output)")
+	fs.Parse(args)
+
+	// the statistics are computed before any repair, so that disconnected topologies are reported
+	topos, _ := tf.loadRaw()
+
+	stats := []util.TopologyStats{}
+	for name, topo := range topos {
+		stats = append(stats, util.ComputeTopologyStats(name, topo))
+	}
+
+	err := util.SortTopologyStats(stats, *sortBy, *descending)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case CSV_FORMAT:
+		err = util.WriteStatsCSV(w, stats)
+	case MARKDOWN_FORMAT:
+		err = util.WriteStatsMarkdown(w, stats)
+	default:
+		log.Fatalf("Unknown catalogue format '%s'!\n", *format)
+	}
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+
+	"utwente.nl/topology-to-dynetkat-coverter/util"
+)
+
+// flags shared by all commands that load topologies
+type topologyFlags struct {
+	dir     *string
+	genSpec *string
+	repair  *string
+}
+
+func addTopologyFlags(fs *flag.FlagSet) *topologyFlags {
+	return &topologyFlags{
+		dir: fs.String(
+			"dir",
+			DIR,
+			"directory containing the topologies (supported extensions: "+
+				strings.Join(util.SupportedTopologyExts(), ", ")+")",
+		),
+		genSpec: fs.String(
+			"generate",
+			"",
+			"generate a synthetic topology instead of loading one, e.g. 'fattree:4', 'ring:10', "+
+				"'grid:3x4', 'torus:3x3', 'waxman:50:0.4:0.2', 'ba:100:2'",
+		),
+		repair: fs.String(
+			"repair",
+			"reject",
+			"how to handle disconnected topologies: 'reject', 'largest' (keep the largest component) "+
+				"or 'split' (keep every component as 'Name#c<i>')",
+		),
+	}
+}
+
+/*
+Loads (or generates) and validates the topologies selected by the flags.
+If a topology was generated, its name is also returned.
+*/
+func (tf *topologyFlags) load() (map[string]util.Topology, string) {
+	topos, genName := tf.loadRaw()
+	return tf.validate(topos), genName
+}
+
+/*
+Loads (or generates) the topologies selected by the flags, without validating or repairing them.
+If a topology was generated, its name is also returned.
+*/
+func (tf *topologyFlags) loadRaw() (map[string]util.Topology, string) {
+	if *tf.genSpec != "" {
+		genName, g, err := util.GenerateTopology(*tf.genSpec, util.NewRand(util.SEED))
+		if err != nil {
+			log.Fatalf("Failed to generate topology '%s'.\n%s", *tf.genSpec, err.Error())
+		}
+		return map[string]util.Topology{genName: util.NewTopology(g)}, genName
+	}
+
+	topos, err := util.GetTopologies(*tf.dir)
+	if err != nil {
+		log.Fatalf("Failed to load graphs from directory: %s\n%s", *tf.dir, err.Error())
+	}
+	if len(topos) == 0 {
+		log.Fatalf("No topologies found in directory: %s\n", *tf.dir)
+	}
+	return topos, ""
+}
+
+// Validates the topologies, repairing the disconnected ones with the strategy selected by the flags
+func (tf *topologyFlags) validate(topos map[string]util.Topology) map[string]util.Topology {
+	repairStrategy, err := util.ParseRepairStrategy(*tf.repair)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return util.ValidateTopologiesWithRepair(topos, repairStrategy)
+}
@@ -0,0 +1,370 @@
+package util
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	LATITUDE_ATTR  = "Latitude"
+	LONGITUDE_ATTR = "Longitude"
+	X_ATTR         = "x"
+	Y_ATTR         = "y"
+	CAPACITY_ATTR  = "capacity"
+
+	SNDLIB_HEADER          = "?SNDlib native format"
+	SNDLIB_XML_NAMESPACE   = "http://sndlib.zib.de/network"
+	SNDLIB_GEO_COORDINATES = "geographical"
+)
+
+/*
+Matches a router line of a Rocketfuel '.cch' map:
+
+	uid @loc [+] [bb] (num_neigh) [&ext] -> <nuid-1> <nuid-2> ... {-euid} ... =name[!] rn
+*/
+var rocketfuelRouterRegex = regexp.MustCompile(
+	`^(\d+)\s+@(\S+)\s+(\+\s+)?(bb\s+)?\((\d+)\)\s+(&\d+\s+)?->((?:\s*<\d+>)*)((?:\s*\{-?\d+\})*)\s*(=(\S+))?`,
+)
+
+var rocketfuelNeighbourRegex = regexp.MustCompile(`<(\d+)>`)
+
+/*
+Parses a router-level Rocketfuel ISP map ('.cch' file). Only the routers inside the ISP
+become nodes, links to external routers are dropped. Node attributes are the router's
+location, DNS name and whether it is a backbone router.
+*/
+func RocketfuelToTopology(r io.Reader, name string) (Topology, error) {
+	b := newTopologyBuilder(name)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	neighbours := make(map[string][]string)
+	uids := []string{}
+	lineNr := 0
+
+	for scanner.Scan() {
+		lineNr++
+		line := strings.TrimSpace(scanner.Text())
+		// external routers have negative ids
+		if line == "" || line[0] == '#' || line[0] == '-' {
+			continue
+		}
+
+		match := rocketfuelRouterRegex.FindStringSubmatch(line)
+		if match == nil {
+			return Topology{}, errors.New(fmt.Sprintf("%s:%d: Malformed Rocketfuel router line!", name, lineNr))
+		}
+
+		uid := match[1]
+		attrs := Attributes{
+			"location": strings.ReplaceAll(match[2], "+", " "),
+			"backbone": boolToAttr(match[4] != ""),
+		}
+		if match[10] != "" {
+			attrs["name"] = strings.TrimSuffix(match[10], "!")
+		}
+		b.setNodeAttrs(uid, attrs)
+
+		uids = append(uids, uid)
+		for _, neighbour := range rocketfuelNeighbourRegex.FindAllStringSubmatch(match[7], -1) {
+			neighbours[uid] = append(neighbours[uid], neighbour[1])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Topology{}, err
+	}
+
+	for _, uid := range uids {
+		for _, neighbour := range neighbours[uid] {
+			b.edge(uid, neighbour, Attributes{})
+		}
+	}
+
+	return b.build(), nil
+}
+
+/*
+Parses a POP-level Rocketfuel map with link weights or latencies ('weights.intra' or
+'latencies.intra' files). Every line has the form 'pop1 pop2 value'. The value is kept
+as the 'latency' edge attribute if the file name mentions latencies, as 'weight' otherwise.
+*/
+func RocketfuelWeightsToTopology(r io.Reader, name string) (Topology, error) {
+	valueAttr := "weight"
+	if strings.Contains(strings.ToLower(name), "latenc") {
+		valueAttr = "latency"
+	}
+
+	b := newTopologyBuilder(name)
+	scanner := bufio.NewScanner(r)
+	lineNr := 0
+
+	for scanner.Scan() {
+		lineNr++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0][0] == '#' {
+			continue
+		}
+
+		if len(fields) != 3 {
+			return Topology{}, errors.New(fmt.Sprintf("%s:%d: Expected 'pop1 pop2 value'!", name, lineNr))
+		}
+
+		from, to := strings.ReplaceAll(fields[0], "+", " "), strings.ReplaceAll(fields[1], "+", " ")
+		b.edge(from, to, Attributes{valueAttr: fields[2]})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Topology{}, err
+	}
+
+	return b.build(), nil
+}
+
+/*
+Parses an SNDlib instance in the native text format. Node coordinates are kept as
+longitude/latitude attributes, META entries as graph attributes and link capacities as
+edge attributes. The capacity of a link is its pre-installed capacity or, if there is none,
+its largest installable module. Parallel links are merged and their capacities added up.
+*/
+func SNDlibToTopology(r io.Reader, name string) (Topology, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Topology{}, err
+	}
+
+	content := string(data)
+	if !strings.HasPrefix(strings.TrimSpace(content), SNDLIB_HEADER) {
+		return Topology{}, errors.New(fmt.Sprintf("%s: Missing '%s' header!", name, SNDLIB_HEADER))
+	}
+
+	b := newTopologyBuilder(name)
+	for _, line := range sndlibSection(content, "META") {
+		key, value, found := strings.Cut(line, "=")
+		if found {
+			b.topo.Attrs[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	for _, line := range sndlibSection(content, "NODES") {
+		fields := sndlibFields(line)
+		if len(fields) != 3 {
+			return Topology{}, errors.New(fmt.Sprintf("%s: Malformed SNDlib node '%s'!", name, line))
+		}
+		b.setNodeAttrs(fields[0], Attributes{LONGITUDE_ATTR: fields[1], LATITUDE_ATTR: fields[2]})
+	}
+
+	links := []sndlibLink{}
+	for _, line := range sndlibSection(content, "LINKS") {
+		// <link_id> ( <source> <target> ) <pre_cap> <pre_cap_cost> <routing_cost> <setup_cost> ( {<module_cap> <module_cost>}* )
+		fields := sndlibFields(line)
+		if len(fields) < 7 {
+			return Topology{}, errors.New(fmt.Sprintf("%s: Malformed SNDlib link '%s'!", name, line))
+		}
+
+		link := sndlibLink{id: fields[0], source: fields[1], target: fields[2], routingCost: fields[5]}
+		link.capacity, err = strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return Topology{}, errors.New(fmt.Sprintf("%s: Malformed SNDlib link '%s'!", name, line))
+		}
+		for i := 7; i < len(fields); i += 2 {
+			moduleCap, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return Topology{}, errors.New(fmt.Sprintf("%s: Malformed SNDlib link '%s'!", name, line))
+			}
+			link.moduleCapacities = append(link.moduleCapacities, moduleCap)
+		}
+		links = append(links, link)
+	}
+
+	addSNDlibLinks(b, links)
+	return b.build(), nil
+}
+
+type sndlibLink struct {
+	id               string
+	source           string
+	target           string
+	routingCost      string
+	capacity         float64
+	moduleCapacities []float64
+}
+
+func addSNDlibLinks(b *topologyBuilder, links []sndlibLink) {
+	capacities := make(map[StrTup]float64)
+	for _, link := range links {
+		capacity := link.capacity
+		if capacity <= 0 {
+			for _, moduleCap := range link.moduleCapacities {
+				capacity = max(capacity, moduleCap)
+			}
+		}
+
+		pair := NewStrTup(link.source, link.target)
+		if _, exists := capacities[pair]; !exists {
+			pair = NewStrTup(link.target, link.source)
+		}
+		capacities[pair] += capacity
+
+		attrs := Attributes{
+			"id":          link.id,
+			CAPACITY_ATTR: strconv.FormatFloat(capacities[pair], 'f', -1, 64),
+		}
+		if link.routingCost != "" {
+			attrs["routingCost"] = link.routingCost
+		}
+		b.edge(link.source, link.target, attrs)
+	}
+}
+
+// returns the non-empty, non-comment lines between 'SECTION (' and the matching ')'
+func sndlibSection(content, section string) []string {
+	lines := []string{}
+	inSection := false
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case !inSection:
+			inSection = strings.HasPrefix(line, section) &&
+				strings.TrimSpace(strings.TrimPrefix(line, section)) == "("
+		case line == ")":
+			return lines
+		case line != "" && line[0] != '#':
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// splits an SNDlib line into fields, dropping the parentheses
+func sndlibFields(line string) []string {
+	return strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(line))
+}
+
+type sndlibXML struct {
+	Meta struct {
+		Entries []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"meta"`
+	Nodes struct {
+		CoordinatesType string `xml:"coordinatesType,attr"`
+		Nodes           []struct {
+			ID string  `xml:"id,attr"`
+			X  *string `xml:"coordinates>x"`
+			Y  *string `xml:"coordinates>y"`
+		} `xml:"node"`
+	} `xml:"networkStructure>nodes"`
+	Links []struct {
+		ID                 string    `xml:"id,attr"`
+		Source             string    `xml:"source"`
+		Target             string    `xml:"target"`
+		RoutingCost        string    `xml:"routingCost"`
+		PreInstalledModule *float64  `xml:"preInstalledModule>capacity"`
+		ModuleCapacities   []float64 `xml:"additionalModules>addModule>capacity"`
+	} `xml:"networkStructure>links>link"`
+}
+
+// Checks whether the beginning of a file is the header of an SNDlib native network
+func IsSNDlibNative(head []byte) bool {
+	return strings.HasPrefix(strings.TrimSpace(string(head)), SNDLIB_HEADER)
+}
+
+// Checks whether the beginning of a file is the root element of an SNDlib XML network
+func IsSNDlibXML(head []byte) bool {
+	decoder := xml.NewDecoder(strings.NewReader(string(head)))
+	decoder.CharsetReader = latin1CharsetReader
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if root, isElement := token.(xml.StartElement); isElement {
+			return root.Name.Local == "network" && root.Name.Space == SNDLIB_XML_NAMESPACE
+		}
+	}
+}
+
+/*
+Parses an SNDlib instance in the XML format. Geographical coordinates are kept as
+longitude/latitude attributes, pixel coordinates as x/y attributes. Capacities are handled
+as in SNDlibToTopology.
+*/
+func SNDlibXMLToTopology(r io.Reader, name string) (Topology, error) {
+	network := sndlibXML{}
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = latin1CharsetReader
+	err := decoder.Decode(&network)
+	if err != nil {
+		return Topology{}, errors.New(fmt.Sprintf("%s: %s", name, err.Error()))
+	}
+
+	b := newTopologyBuilder(name)
+	for _, meta := range network.Meta.Entries {
+		b.topo.Attrs[meta.XMLName.Local] = strings.TrimSpace(meta.Value)
+	}
+
+	xAttr, yAttr := X_ATTR, Y_ATTR
+	if network.Nodes.CoordinatesType == SNDLIB_GEO_COORDINATES {
+		xAttr, yAttr = LONGITUDE_ATTR, LATITUDE_ATTR
+	}
+	for _, node := range network.Nodes.Nodes {
+		attrs := Attributes{}
+		if node.X != nil && node.Y != nil {
+			attrs[xAttr], attrs[yAttr] = strings.TrimSpace(*node.X), strings.TrimSpace(*node.Y)
+		}
+		b.setNodeAttrs(node.ID, attrs)
+	}
+
+	links := []sndlibLink{}
+	for _, xmlLink := range network.Links {
+		link := sndlibLink{
+			id:               xmlLink.ID,
+			source:           xmlLink.Source,
+			target:           xmlLink.Target,
+			routingCost:      strings.TrimSpace(xmlLink.RoutingCost),
+			moduleCapacities: xmlLink.ModuleCapacities,
+		}
+		if xmlLink.PreInstalledModule != nil {
+			link.capacity = *xmlLink.PreInstalledModule
+		}
+		links = append(links, link)
+	}
+
+	addSNDlibLinks(b, links)
+	return b.build(), nil
+}
+
+// SNDlib XML files are usually declared as ISO-8859-1, which the xml package does not decode itself
+func latin1CharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "us-ascii":
+		return input, nil
+	case "iso-8859-1", "latin1":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return input, err
+		}
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return strings.NewReader(string(runes)), nil
+	}
+	return input, errors.New(fmt.Sprintf("Unsupported XML charset: %s", charset))
+}
+
+func boolToAttr(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,164 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const (
+	sndlibNativeFixture = `?SNDlib native format; type: network; version: 1.0
+# network triangle
+
+META (
+ granularity = 6month
+)
+
+NODES (
+ a ( 1.50 2.50 )
+ b ( 3.00 4.00 )
+ c ( 5.00 6.00 )
+)
+
+LINKS (
+ L1 ( a b ) 10.00 0.00 1.00 0.00 ( )
+ L2 ( b c ) 0.00 0.00 1.00 0.00 ( 40.00 1.00 2.50 3.00 )
+ L3 ( c a ) 0.00 0.00 1.00 0.00 ( )
+)
+`
+	sndlibXMLFixture = `<?xml version="1.0" encoding="ISO-8859-1"?>
+<network xmlns="http://sndlib.zib.de/network" version="1.0">
+ <meta><granularity>6month</granularity></meta>
+ <networkStructure>
+  <nodes coordinatesType="geographical">
+   <node id="a"><coordinates><x>1.50</x><y>2.50</y></coordinates></node>
+   <node id="b"><coordinates><x>3.00</x><y>4.00</y></coordinates></node>
+   <node id="c"><coordinates><x>5.00</x><y>6.00</y></coordinates></node>
+  </nodes>
+  <links>
+   <link id="L1"><source>a</source><target>b</target><preInstalledModule><capacity>10</capacity></preInstalledModule></link>
+   <link id="L2"><source>b</source><target>c</target></link>
+   <link id="L3"><source>c</source><target>a</target></link>
+  </links>
+ </networkStructure>
+</network>
+`
+)
+
+func TestSNDlibFormats(t *testing.T) {
+	fixtures := map[string]struct {
+		content string
+		parser  TopologyParser
+	}{
+		"native": {sndlibNativeFixture, SNDlibToTopology},
+		"xml":    {sndlibXMLFixture, SNDlibXMLToTopology},
+	}
+
+	for name, fixture := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			topo, err := fixture.parser(strings.NewReader(fixture.content), name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTriangle(t, topo)
+
+			if topo.Attrs["granularity"] != "6month" {
+				t.Errorf("got graph attributes %v", topo.Attrs)
+			}
+			lon, lat, valid := topo.Coordinates(nodeWithLabel(topo, "a"))
+			if !valid || lon != 1.5 || lat != 2.5 {
+				t.Errorf("got coordinates (%v, %v, %t), want (1.5, 2.5, true)", lon, lat, valid)
+			}
+		})
+	}
+}
+
+func TestSNDlibLinkCapacityFallsBackToModules(t *testing.T) {
+	topo, err := SNDlibToTopology(strings.NewReader(sndlibNativeFixture), "native")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, c := nodeWithLabel(topo, "b"), nodeWithLabel(topo, "c")
+	if capacity, _ := topo.EdgeAttr(b, c, CAPACITY_ATTR); capacity != "40" {
+		t.Errorf("got capacity '%s', want the largest module '40'", capacity)
+	}
+}
+
+func TestRocketfuelFormats(t *testing.T) {
+	cch := `1 @Seattle,+WA + bb (2) -> <2> <3> =r1.sea
+2 @Portland,+OR (2) -> <1> <3> {-10} =r2.pdx
+3 @Boise,+ID (2) -> <1> <2>
+-10 @Outside (1) -> <2>
+`
+	topo, err := RocketfuelToTopology(strings.NewReader(cch), "1755.cch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topo.Graph.Nodes().Len() != 3 || topo.Graph.Edges().Len() != 3 {
+		t.Fatalf("got %d nodes and %d edges, want 3 and 3", topo.Graph.Nodes().Len(), topo.Graph.Edges().Len())
+	}
+	seattle := nodeWithLabel(topo, "1")
+	if location, _ := topo.NodeAttr(seattle, "location"); location != "Seattle, WA" {
+		t.Errorf("got location '%s'", location)
+	}
+	if backbone, _ := topo.NodeAttr(seattle, "backbone"); backbone != boolToAttr(true) {
+		t.Errorf("router 1 must be a backbone router, got '%s'", backbone)
+	}
+
+	latencies := "Seattle,+WA Portland,+OR 3\nPortland,+OR Boise,+ID 5\n"
+	topo, err = RocketfuelWeightsToTopology(strings.NewReader(latencies), "latencies.intra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seattle, portland := nodeWithLabel(topo, "Seattle, WA"), nodeWithLabel(topo, "Portland, OR")
+	if latency, _ := topo.EdgeAttr(seattle, portland, "latency"); latency != "3" {
+		t.Errorf("got latency '%s', want '3'", latency)
+	}
+
+	_, err = RocketfuelToTopology(strings.NewReader("not a router line\n"), "bad.cch")
+	if err == nil {
+		t.Error("expected an error for a malformed router line")
+	}
+}
+
+func TestSNDlibSniffers(t *testing.T) {
+	if !IsSNDlibNative([]byte("\n"+sndlibNativeFixture)) || IsSNDlibNative([]byte("hello")) {
+		t.Error("the native sniffer must only recognise the SNDlib header")
+	}
+	if !IsSNDlibXML([]byte(sndlibXMLFixture)) || IsSNDlibXML([]byte(`<?xml version="1.0"?><network/>`)) {
+		t.Error("the XML sniffer must only recognise the SNDlib namespace")
+	}
+}
+
+// files with a generic extension are only loaded if their content is recognised
+func TestGetTopologiesSkipsUnrelatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"README.txt":  "hello",
+		"pom.xml":     `<?xml version="1.0"?><project/>`,
+		"network.txt": sndlibNativeFixture,
+		"network.xml": sndlibXMLFixture,
+		"output.tex":  "\\begin{document}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	topos, err := GetTopologies(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for name := range topos {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"network.txt", "network.xml"}) {
+		t.Errorf("got topologies %v", names)
+	}
+}
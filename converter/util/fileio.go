@@ -1,24 +1,17 @@
 package util
 
-import "os"
+import (
+	"os"
+	"path/filepath"
+)
 
 const FILE_PERM = 0755
 
-func WriteToNewFile(dir, fileName, data string) error {
-	_, err := os.Stat(dir)
-	if err != nil && !os.IsNotExist(err) {
-		return err
+// Creates (or truncates) the file in the given directory, creating the directory if needed
+func CreateNewFile(dir, fileName string) (*os.File, error) {
+	err := os.MkdirAll(dir, FILE_PERM)
+	if err != nil {
+		return nil, err
 	}
-
-	if os.IsNotExist(err) {
-		err = os.Mkdir(dir, FILE_PERM)
-	}
-
-	filePath := dir + fileName
-	if dir[len(dir)-1] != '/' {
-		filePath = dir + "/" + fileName
-	}
-
-	os.WriteFile(filePath, []byte(data), FILE_PERM)
-	return nil
+	return os.Create(filepath.Join(dir, fileName))
 }
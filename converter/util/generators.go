@@ -0,0 +1,386 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+const GENERATOR_SPEC_SEP = ":"
+
+// adds 'nodesNr' new nodes to the graph and returns them in creation order
+func addNodes(g *Graph, nodesNr int) []graph.Node {
+	nodes := []graph.Node{}
+	for range nodesNr {
+		newNode := g.NewNode()
+		g.AddNode(newNode)
+		nodes = append(nodes, newNode)
+	}
+	return nodes
+}
+
+func connect(g *Graph, from, to graph.Node) {
+	if from.ID() == to.ID() || g.HasEdgeBetween(from.ID(), to.ID()) {
+		return
+	}
+	g.SetEdge(g.NewEdge(from, to))
+}
+
+/*
+Generates a k-ary fat-tree containing only the switches: (k/2)^2 core switches
+and k pods of k/2 aggregation and k/2 edge switches each. 'k' must be even.
+*/
+func FatTree(k uint) (Graph, error) {
+	if k < 2 || k%2 != 0 {
+		return *simple.NewUndirectedGraph(), errors.New("Fat-tree arity must be an even number >= 2!")
+	}
+
+	g := *simple.NewUndirectedGraph()
+	half := int(k / 2)
+	core := addNodes(&g, half*half)
+
+	for range k {
+		aggs := addNodes(&g, half)
+		edges := addNodes(&g, half)
+
+		for i, agg := range aggs {
+			for _, edge := range edges {
+				connect(&g, agg, edge)
+			}
+			// aggregation switch i of every pod connects to the i-th group of core switches
+			for j := range half {
+				connect(&g, agg, core[i*half+j])
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// Generates a path of 'nodesNr' nodes
+func Line(nodesNr uint) (Graph, error) {
+	if nodesNr < 1 {
+		return *simple.NewUndirectedGraph(), errors.New("A line must have at least 1 node!")
+	}
+
+	g := *simple.NewUndirectedGraph()
+	nodes := addNodes(&g, int(nodesNr))
+	for i := range len(nodes) - 1 {
+		connect(&g, nodes[i], nodes[i+1])
+	}
+
+	return g, nil
+}
+
+// Generates a cycle of 'nodesNr' nodes
+func Ring(nodesNr uint) (Graph, error) {
+	if nodesNr < 3 {
+		return *simple.NewUndirectedGraph(), errors.New("A ring must have at least 3 nodes!")
+	}
+
+	g := *simple.NewUndirectedGraph()
+	nodes := addNodes(&g, int(nodesNr))
+	for i := range nodes {
+		connect(&g, nodes[i], nodes[(i+1)%len(nodes)])
+	}
+
+	return g, nil
+}
+
+// Generates a star made of one center node and 'leavesNr' leaves
+func Star(leavesNr uint) (Graph, error) {
+	if leavesNr < 1 {
+		return *simple.NewUndirectedGraph(), errors.New("A star must have at least 1 leaf!")
+	}
+
+	g := *simple.NewUndirectedGraph()
+	center := addNodes(&g, 1)[0]
+	for _, leaf := range addNodes(&g, int(leavesNr)) {
+		connect(&g, center, leaf)
+	}
+
+	return g, nil
+}
+
+/*
+Generates a 'rows' x 'cols' 2D grid. If 'torus' is true, the nodes on opposite borders
+are also connected, in which case both dimensions must be at least 3.
+*/
+func Grid(rows, cols uint, torus bool) (Graph, error) {
+	if rows < 1 || cols < 1 {
+		return *simple.NewUndirectedGraph(), errors.New("Grid dimensions must be at least 1!")
+	}
+
+	if torus && (rows < 3 || cols < 3) {
+		return *simple.NewUndirectedGraph(), errors.New("Torus dimensions must be at least 3!")
+	}
+
+	g := *simple.NewUndirectedGraph()
+	r, c := int(rows), int(cols)
+	nodes := addNodes(&g, r*c)
+	at := func(i, j int) graph.Node {
+		return nodes[(i%r)*c+(j%c)]
+	}
+
+	for i := range r {
+		for j := range c {
+			if j+1 < c || torus {
+				connect(&g, at(i, j), at(i, j+1))
+			}
+			if i+1 < r || torus {
+				connect(&g, at(i, j), at(i+1, j))
+			}
+		}
+	}
+
+	return g, nil
+}
+
+/*
+Generates a Waxman random graph: 'nodesNr' nodes are placed uniformly at random in the
+unit square and every pair (u, v) is connected with probability
+beta * exp(-d(u, v) / (alpha * L)), where L is the maximum possible distance in the unit
+square, its diagonal sqrt(2), rather than the largest distance between the placed nodes.
+The resulting graph is not guaranteed to be connected.
+*/
+func Waxman(r *rand.Rand, nodesNr uint, alpha, beta float64) (Graph, error) {
+	if alpha <= 0 || alpha > 1 || beta <= 0 || beta > 1 {
+		return *simple.NewUndirectedGraph(), errors.New("Waxman parameters must be in (0, 1]!")
+	}
+
+	g := *simple.NewUndirectedGraph()
+	nodes := addNodes(&g, int(nodesNr))
+	xs, ys := make([]float64, len(nodes)), make([]float64, len(nodes))
+	for i := range nodes {
+		xs[i], ys[i] = r.Float64(), r.Float64()
+	}
+
+	maxDist := math.Sqrt2
+	for i := range nodes {
+		for j := i + 1; j < len(nodes); j++ {
+			dist := math.Hypot(xs[i]-xs[j], ys[i]-ys[j])
+			if r.Float64() < beta*math.Exp(-dist/(alpha*maxDist)) {
+				connect(&g, nodes[i], nodes[j])
+			}
+		}
+	}
+
+	return g, nil
+}
+
+/*
+Generates a Barabási–Albert preferential attachment graph. It starts from a complete graph
+of 'm'+1 nodes and then attaches every new node to 'm' distinct existing nodes, picked with
+a probability proportional to their degree.
+*/
+func BarabasiAlbert(r *rand.Rand, nodesNr, m uint) (Graph, error) {
+	if m < 1 || nodesNr <= m {
+		return *simple.NewUndirectedGraph(), errors.New(
+			"Barabási–Albert graphs need m >= 1 and more than m nodes!",
+		)
+	}
+
+	g := *simple.NewUndirectedGraph()
+	nodes := addNodes(&g, int(m)+1)
+	// every node appears in this array once for each of its incident edges
+	degreeList := []graph.Node{}
+	for i := range nodes {
+		for j := i + 1; j < len(nodes); j++ {
+			connect(&g, nodes[i], nodes[j])
+			degreeList = append(degreeList, nodes[i], nodes[j])
+		}
+	}
+
+	for range int(nodesNr) - len(nodes) {
+		newNode := addNodes(&g, 1)[0]
+		targets := make(map[int64]graph.Node)
+		for len(targets) < int(m) {
+			target := degreeList[r.Intn(len(degreeList))]
+			targets[target.ID()] = target
+		}
+
+		// connect in id order to keep the results reproducible
+		targetIds := []int64{}
+		for targetId := range targets {
+			targetIds = append(targetIds, targetId)
+		}
+		for _, targetId := range sortAndRemoveDuplicates(targetIds) {
+			connect(&g, newNode, targets[targetId])
+			degreeList = append(degreeList, newNode, targets[targetId])
+		}
+	}
+
+	return g, nil
+}
+
+/*
+Generates a topology from a specification of the form 'kind:param1:param2...'.
+Supported specifications:
+
+	fattree:<k>
+	line:<nodes>
+	ring:<nodes>
+	star:<leaves>
+	grid:<rows>x<cols>
+	torus:<rows>x<cols>
+	waxman:<nodes>:<alpha>:<beta>
+	ba:<nodes>:<m>
+
+Returns a name that identifies the generated topology together with the topology. The random
+generators draw from 'r', so the same generator state gives the same topology.
+*/
+func GenerateTopology(spec string, r *rand.Rand) (string, Graph, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(spec)), GENERATOR_SPEC_SEP)
+	kind, params := parts[0], parts[1:]
+
+	expectParams := func(nr int) error {
+		if len(params) != nr {
+			return errors.New(fmt.Sprintf(
+				"Generator '%s' expects %d parameter(s), got %d!", kind, nr, len(params),
+			))
+		}
+		return nil
+	}
+
+	var g Graph
+	var name string
+	var err error
+
+	switch kind {
+	case "fattree", "line", "ring", "star":
+		if err = expectParams(1); err != nil {
+			break
+		}
+		var n uint
+		if n, err = parseUint(params[0]); err != nil {
+			break
+		}
+		name, g, err = generateSimple(kind, n)
+	case "grid", "torus":
+		if err = expectParams(1); err != nil {
+			break
+		}
+		var rows, cols uint
+		if rows, cols, err = parseDimensions(params[0]); err != nil {
+			break
+		}
+		name = fmt.Sprintf("%s%dx%d", capitalize(kind), rows, cols)
+		g, err = Grid(rows, cols, kind == "torus")
+	case "waxman":
+		if err = expectParams(3); err != nil {
+			break
+		}
+		var n uint
+		var alpha, beta float64
+		if n, err = parseUint(params[0]); err != nil {
+			break
+		}
+		if alpha, err = strconv.ParseFloat(params[1], 64); err != nil {
+			break
+		}
+		if beta, err = strconv.ParseFloat(params[2], 64); err != nil {
+			break
+		}
+		name = fmt.Sprintf("Waxman%d_%s_%s", n, params[1], params[2])
+		g, err = Waxman(r, n, alpha, beta)
+	case "ba":
+		if err = expectParams(2); err != nil {
+			break
+		}
+		var n, m uint
+		if n, err = parseUint(params[0]); err != nil {
+			break
+		}
+		if m, err = parseUint(params[1]); err != nil {
+			break
+		}
+		name = fmt.Sprintf("BarabasiAlbert%d_%d", n, m)
+		g, err = BarabasiAlbert(r, n, m)
+	default:
+		err = errors.New(fmt.Sprintf("Unknown topology generator '%s'!", kind))
+	}
+
+	if err != nil {
+		return "", *simple.NewUndirectedGraph(), err
+	}
+	return name, g, nil
+}
+
+/*
+Returns the number of nodes of the topology that GenerateTopology generates for the spec,
+without generating it, e.g. to reject the specs of topologies that are too large.
+*/
+func GeneratedNodesNr(spec string) (uint, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(spec)), GENERATOR_SPEC_SEP)
+	kind := parts[0]
+	if len(parts) < 2 {
+		return 0, errors.New(fmt.Sprintf("Generator '%s' expects a size parameter!", kind))
+	}
+
+	switch kind {
+	case "grid", "torus":
+		rows, cols, err := parseDimensions(parts[1])
+		return rows * cols, err
+	case "fattree", "line", "ring", "star", "waxman", "ba":
+		n, err := parseUint(parts[1])
+		switch kind {
+		case "fattree":
+			// (k/2)^2 core switches and k pods of k switches
+			return n*n/4 + n*n, err
+		case "star":
+			return n + 1, err
+		}
+		return n, err
+	}
+	return 0, errors.New(fmt.Sprintf("Unknown topology generator '%s'!", kind))
+}
+
+func generateSimple(kind string, n uint) (string, Graph, error) {
+	generators := map[string]func(uint) (Graph, error){
+		"fattree": FatTree,
+		"line":    Line,
+		"ring":    Ring,
+		"star":    Star,
+	}
+	names := map[string]string{
+		"fattree": "FatTree",
+		"line":    "Line",
+		"ring":    "Ring",
+		"star":    "Star",
+	}
+
+	g, err := generators[kind](n)
+	return fmt.Sprintf("%s%d", names[kind], n), g, err
+}
+
+func parseUint(str string) (uint, error) {
+	n, err := strconv.ParseUint(str, 10, 32)
+	return uint(n), err
+}
+
+func parseDimensions(str string) (uint, uint, error) {
+	dims := strings.Split(str, "x")
+	if len(dims) != 2 {
+		return 0, 0, errors.New("Dimensions must have the form <rows>x<cols>!")
+	}
+
+	rows, err := parseUint(dims[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	cols, err := parseUint(dims[1])
+	return rows, cols, err
+}
+
+func capitalize(str string) string {
+	if str == "" {
+		return str
+	}
+	return strings.ToUpper(str[:1]) + str[1:]
+}
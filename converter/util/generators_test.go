@@ -0,0 +1,136 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestGeneratorSizes(t *testing.T) {
+	cases := []struct {
+		spec          string
+		name          string
+		nodes, edges  int
+		checkDegreeOf int64 // a node whose degree is checked, -1: none
+		degree        int
+	}{
+		// (k/2)^2 core switches and k pods of k switches, k^3/2 links
+		{"fattree:4", "FatTree4", 20, 32, 0, 4},
+		{"fattree:6", "FatTree6", 45, 108, -1, 0},
+		{"line:5", "Line5", 5, 4, 0, 1},
+		{"ring:6", "Ring6", 6, 6, 3, 2},
+		{"star:5", "Star5", 6, 5, 0, 5},
+		{"grid:3x4", "Grid3x4", 12, 17, 0, 2},
+		{"torus:3x4", "Torus3x4", 12, 24, 0, 4},
+	}
+
+	for _, c := range cases {
+		t.Run(c.spec, func(t *testing.T) {
+			name, g, err := GenerateTopology(c.spec, NewRand(SEED))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != c.name {
+				t.Errorf("got name '%s', want '%s'", name, c.name)
+			}
+			if g.Nodes().Len() != c.nodes || g.Edges().Len() != c.edges {
+				t.Errorf(
+					"got %d nodes and %d edges, want %d and %d",
+					g.Nodes().Len(), g.Edges().Len(), c.nodes, c.edges,
+				)
+			}
+			if c.checkDegreeOf >= 0 && g.From(c.checkDegreeOf).Len() != c.degree {
+				t.Errorf("node %d has degree %d, want %d", c.checkDegreeOf, g.From(c.checkDegreeOf).Len(), c.degree)
+			}
+			if nodesNr, err := GeneratedNodesNr(c.spec); err != nil || nodesNr != uint(c.nodes) {
+				t.Errorf("got %d generated nodes, %v, want %d", nodesNr, err, c.nodes)
+			}
+		})
+	}
+}
+
+func TestGeneratedNodesNr(t *testing.T) {
+	for spec, want := range map[string]uint{"waxman:40:0.4:0.2": 40, "ba:40:3": 40, "Grid:100x200": 20000} {
+		if nodesNr, err := GeneratedNodesNr(spec); err != nil || nodesNr != want {
+			t.Errorf("%s: got %d, %v, want %d", spec, nodesNr, err, want)
+		}
+	}
+	for _, spec := range []string{"ring", "grid:3", "ring:x", "cube:3"} {
+		if _, err := GeneratedNodesNr(spec); err == nil {
+			t.Errorf("expected an error for '%s'", spec)
+		}
+	}
+}
+
+func TestBarabasiAlbert(t *testing.T) {
+	var nodes, m uint = 30, 2
+	g, err := BarabasiAlbert(NewRand(SEED), nodes, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a complete graph of m+1 nodes, then m links per added node
+	wantEdges := int(m*(m+1)/2 + (nodes-m-1)*m)
+	if g.Nodes().Len() != int(nodes) || g.Edges().Len() != wantEdges {
+		t.Errorf("got %d nodes and %d edges, want %d and %d", g.Nodes().Len(), g.Edges().Len(), nodes, wantEdges)
+	}
+	if err := ValidateTopology(g); err != nil {
+		t.Errorf("a Barabási–Albert graph must be connected: %s", err)
+	}
+}
+
+func TestRandomGeneratorsAreReproducible(t *testing.T) {
+	for _, spec := range []string{"waxman:40:0.4:0.2", "ba:40:3"} {
+		_, first, err := GenerateTopology(spec, NewRand(SEED))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, second, err := GenerateTopology(spec, NewRand(SEED))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if first.Nodes().Len() != 40 {
+			t.Errorf("%s: got %d nodes, want 40", spec, first.Nodes().Len())
+		}
+		if GraphCmp(first, second) != 0 {
+			t.Errorf("%s: the same seed gave different graphs", spec)
+		}
+	}
+}
+
+func TestWaxmanDensity(t *testing.T) {
+	sparse, err := Waxman(NewRand(SEED), 50, 0.1, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dense, err := Waxman(NewRand(SEED), 50, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// with alpha = beta = 1 every pair is linked with a probability of at least exp(-1)
+	if dense.Edges().Len() <= sparse.Edges().Len() || dense.Edges().Len() < 50*49/2/3 {
+		t.Errorf("got %d sparse and %d dense edges", sparse.Edges().Len(), dense.Edges().Len())
+	}
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	specs := []string{
+		"fattree:3",
+		"ring:2",
+		"star:0",
+		"grid:0x3",
+		"torus:2x3",
+		"waxman:10:0:0.5",
+		"waxman:10:0.5",
+		"ba:3:3",
+		"ring:x",
+		"cube:3",
+	}
+
+	for _, spec := range specs {
+		_, _, err := GenerateTopology(spec, NewRand(SEED))
+		if err == nil {
+			t.Errorf("expected an error for '%s'", spec)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package util
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"log"
+	"math/rand"
+	"slices"
 
 	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/simple"
@@ -15,11 +18,11 @@ type (
 )
 
 // return the valid topologies in the given array
-func ValidateTopologies(tops map[string]Graph) map[string]Graph {
-	validTops := make(map[string]Graph)
+func ValidateTopologies(tops map[string]Topology) map[string]Topology {
+	validTops := make(map[string]Topology)
 
 	for name, top := range tops {
-		err := ValidateTopology(top)
+		err := ValidateTopology(top.Graph)
 		if err != nil {
 			log.Printf("%s: %s Skipping...", name, err)
 			continue
@@ -56,6 +59,7 @@ func isConnected(top Graph) error {
 	return nil
 }
 
+// Returns the nodes of the graph sorted by id, since the graph iterates over them in random order
 func GetNodesArrayFromIter(g Graph) []graph.Node {
 	iter := g.Nodes()
 	switches := []graph.Node{}
@@ -64,9 +68,34 @@ func GetNodesArrayFromIter(g Graph) []graph.Node {
 		switches = append(switches, iter.Node())
 	}
 
+	slices.SortFunc(switches, func(a, b graph.Node) int { return cmp.Compare(a.ID(), b.ID()) })
 	return switches
 }
 
+/*
+Returns the edges of the graph sorted by the ids of their ends, like GetNodesArrayFromIter. The
+edges are oriented from their end with the lowest id, since the graph also orients them at random.
+*/
+func GetEdgesArrayFromIter(g Graph) []graph.Edge {
+	iter := g.Edges()
+	edges := []graph.Edge{}
+
+	for iter.Next() {
+		edge := iter.Edge()
+		if edge.From().ID() > edge.To().ID() {
+			edge = edge.ReversedEdge()
+		}
+		edges = append(edges, edge)
+	}
+
+	slices.SortFunc(edges, compareEdges)
+	return edges
+}
+
+func compareEdges(a, b graph.Edge) int {
+	return cmp.Or(cmp.Compare(a.From().ID(), b.From().ID()), cmp.Compare(a.To().ID(), b.To().ID()))
+}
+
 /*
 returns -1 if  a < b
 returns 0 if a = b
@@ -90,6 +119,7 @@ func GraphCmp(a Graph, b Graph) int {
 	return 1
 }
 
+// Returns the edges of the node, oriented from it and sorted by neighbour, looking up its neighbours in the graph
 func GetIncidentEdges(g Graph, n graph.Node) ([]graph.Edge, error) {
 	if n == nil {
 		return []graph.Edge{}, errors.New("Node is nil!")
@@ -100,12 +130,79 @@ func GetIncidentEdges(g Graph, n graph.Node) ([]graph.Edge, error) {
 	}
 
 	incidentEdges := []graph.Edge{}
-	iter := g.Edges()
+	iter := g.From(n.ID())
 
 	for iter.Next() {
-		if iter.Edge().To().ID() == n.ID() || iter.Edge().From().ID() == n.ID() {
-			incidentEdges = append(incidentEdges, iter.Edge())
-		}
+		incidentEdges = append(incidentEdges, g.Edge(n.ID(), iter.Node().ID()))
 	}
+	slices.SortFunc(incidentEdges, compareEdges)
 	return incidentEdges, nil
 }
+
+/*
+The shortest paths from one node to all the nodes reachable from it. Every predecessor that is
+on a shortest path is kept, so that one of several co-equal paths can be picked at random.
+*/
+type ShortestPathTree struct {
+	From  int64
+	preds map[int64][]int64 // maps a node id to the ids of its predecessors, sorted
+}
+
+// Computes the shortest paths with a breadth-first search, since all the links have the same cost
+func NewShortestPathTree(g Graph, fromId int64) *ShortestPathTree {
+	dists := map[int64]int{fromId: 0}
+	preds := make(map[int64][]int64)
+	queue := []int64{fromId}
+
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+
+		iter := g.From(curr)
+		for iter.Next() {
+			next := iter.Node().ID()
+			dist, visited := dists[next]
+			switch {
+			case !visited:
+				dists[next] = dists[curr] + 1
+				preds[next] = []int64{curr}
+				queue = append(queue, next)
+			case dist == dists[curr]+1:
+				preds[next] = append(preds[next], curr)
+			}
+		}
+	}
+
+	// the predecessors are sorted to pick the same paths with the same seed
+	for _, nodePreds := range preds {
+		slices.Sort(nodePreds)
+	}
+	return &ShortestPathTree{From: fromId, preds: preds}
+}
+
+/*
+Returns the ids of the nodes on a shortest path from the root of the tree to the given node,
+both included. Among co-equal paths, one is picked at random. Returns nil if the node cannot
+be reached.
+*/
+func (t *ShortestPathTree) PathTo(r *rand.Rand, toId int64) []int64 {
+	if toId == t.From {
+		return []int64{toId}
+	}
+	if _, reachable := t.preds[toId]; !reachable {
+		return nil
+	}
+
+	path := []int64{toId}
+	for curr := toId; curr != t.From; {
+		preds := t.preds[curr]
+		curr = preds[0]
+		if len(preds) > 1 {
+			curr = preds[r.Intn(len(preds))]
+		}
+		path = append(path, curr)
+	}
+
+	slices.Reverse(path)
+	return path
+}
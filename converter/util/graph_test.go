@@ -0,0 +1,91 @@
+package util
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/graph/path"
+)
+
+/*
+The paths of the shortest path trees must be shortest paths like those of the all-pairs
+Dijkstra search used before, for every pair of nodes, and unreachable nodes have no path.
+*/
+func TestShortestPathTreeMatchesDijkstra(t *testing.T) {
+	graphs := make(map[string]Graph)
+	for _, spec := range []string{"fattree:4", "torus:3x4", "ring:7", "waxman:20:0.4:0.2", "ba:20:2"} {
+		_, g, err := GenerateTopology(spec, NewRand(SEED))
+		if err != nil {
+			t.Fatal(err)
+		}
+		graphs[spec] = g
+	}
+	disconnected, err := EdgeListToTopology(strings.NewReader(disconnectedEdgeList), "Disconnected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	graphs["disconnected"] = disconnected.Graph
+
+	for name, g := range graphs {
+		t.Run(name, func(t *testing.T) {
+			allPaths := path.DijkstraAllPaths(&g)
+			r := NewRand(SEED)
+			for _, from := range GetNodesArrayFromIter(g) {
+				tree := NewShortestPathTree(g, from.ID())
+				for _, to := range GetNodesArrayFromIter(g) {
+					checkShortestPath(t, tree.PathTo(r, to.ID()), allPaths, from.ID(), to.ID())
+				}
+			}
+		})
+	}
+}
+
+func checkShortestPath(t *testing.T, treePath []int64, allPaths path.AllShortest, fromId, toId int64) {
+	t.Helper()
+	shortestPaths, _ := allPaths.AllBetween(fromId, toId)
+	if len(shortestPaths) == 0 {
+		if treePath != nil {
+			t.Errorf("got path %v from %d to the unreachable node %d", treePath, fromId, toId)
+		}
+		return
+	}
+
+	for _, p := range shortestPaths {
+		ids := []int64{}
+		for _, node := range p {
+			ids = append(ids, node.ID())
+		}
+		if slices.Equal(ids, treePath) {
+			return
+		}
+	}
+	t.Errorf("the path %v from %d to %d is not a shortest path, e.g. %v", treePath, fromId, toId, shortestPaths[0])
+}
+
+func TestShortestPathTreePicksCoEqualPaths(t *testing.T) {
+	_, g, err := GenerateTopology("grid:3x3", NewRand(SEED))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// there are 6 shortest paths between opposite corners of the grid
+	tree := NewShortestPathTree(g, 0)
+	picked := make(map[string]bool)
+	r := NewRand(SEED)
+	for range 100 {
+		picked[fmt.Sprint(tree.PathTo(r, 8))] = true
+	}
+	if len(picked) < 2 {
+		t.Errorf("only the paths %v were picked", picked)
+	}
+
+	// the same seed picks the same paths
+	first, second := NewRand(SEED), NewRand(SEED)
+	for range 10 {
+		if a, b := tree.PathTo(first, 8), tree.PathTo(second, 8); !slices.Equal(a, b) {
+			t.Fatalf("got paths %v and %v with the same seed", a, b)
+		}
+	}
+}
@@ -3,53 +3,43 @@ package util
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/yaricom/goGraphML/graphml"
-	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/simple"
 )
 
 const GRAPHML_EXT = ".graphml"
 
-func getPathsFromDir(dirPath string) ([]string, error) {
-	files, err := os.ReadDir(dirPath)
-	if err != nil {
-		return []string{}, err
-	}
-
-	paths := []string{}
-	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), GRAPHML_EXT) {
-			paths = append(paths, dirPath+file.Name())
-		}
-	}
-
-	return paths, nil
-}
-
+// Decodes all the GraphML files of the given directory, skipping the ones that cannot be decoded
 func GetGraphMLs(dirPath string) ([]graphml.GraphML, error) {
-	paths, err := getPathsFromDir(dirPath)
+	files, err := os.ReadDir(dirPath)
 	if err != nil {
 		log.Printf("Failed to read file paths!\n%s", err.Error())
 		return []graphml.GraphML{}, err
 	}
 
 	graphs := []graphml.GraphML{}
-	for _, path := range paths {
-		r, err := os.Open(path)
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), GRAPHML_EXT) {
+			continue
+		}
+
+		r, err := os.Open(filepath.Join(dirPath, file.Name()))
 		if err != nil {
-			log.Printf("Failed to open %s! Skipping...", path)
+			log.Printf("Failed to open %s! Skipping...", file.Name())
 			continue
 		}
 
-		fName := r.Name()[strings.LastIndex(r.Name(), "/")+1:]
-		g := *graphml.NewGraphML(fName)
+		g := *graphml.NewGraphML(file.Name())
 		err = g.Decode(r)
+		r.Close()
 		if err != nil {
-			log.Printf("Something went wrong while decoding %s.\n%s", fName, err.Error())
+			log.Printf("Something went wrong while decoding %s.\n%s", file.Name(), err.Error())
 			continue
 		}
 
@@ -59,38 +49,16 @@ func GetGraphMLs(dirPath string) ([]graphml.GraphML, error) {
 	return graphs, nil
 }
 
+// Returns the graph of the GraphML instance, see GraphMLToTopology
 func GraphMLToGraph(gml graphml.GraphML) (Graph, error) {
-	if len(gml.Graphs) != 1 {
-		return *simple.NewUndirectedGraph(), errors.New(
-			"GraphML instance must contain exactly 1 graph!",
-		)
-	}
-
-	gmlGraph := gml.Graphs[0]
-	g := *simple.NewUndirectedGraph()
-
-	gmlNodeToGNode := map[string]graph.Node{}
-	for _, gmlNode := range gmlGraph.Nodes {
-		newNode := g.NewNode()
-		gmlNodeToGNode[gmlNode.ID] = newNode
-		g.AddNode(newNode)
-	}
-
-	for _, edge := range gmlGraph.Edges {
-		from, to := edge.SourceNode().ID, edge.TargetNode().ID
-
-		if from == to {
-			log.Printf("%s: Skipping self-loop\n", gml.Description)
-			continue
-		}
-
-		fromNode, toNode := gmlNodeToGNode[from], gmlNodeToGNode[to]
-		g.SetEdge(g.NewEdge(fromNode, toNode))
+	topo, err := GraphMLToTopology(gml)
+	if err != nil {
+		return *simple.NewUndirectedGraph(), err
 	}
-
-	return g, nil
+	return topo.Graph, nil
 }
 
+// Returns the graphs of the GraphML instances, named after their descriptions, see GraphMLToGraph
 func GraphMLsToGraphs(gmls []graphml.GraphML) map[string]Graph {
 	gs := make(map[string]Graph)
 	id := 0
@@ -117,3 +85,52 @@ func GraphMLsToGraphs(gmls []graphml.GraphML) map[string]Graph {
 	}
 	return gs
 }
+
+// Reads a GraphML document with one graph into a topology, see GraphMLToTopology
+func GraphMLReaderToTopology(r io.Reader, name string) (Topology, error) {
+	gml := *graphml.NewGraphML(name)
+	err := gml.Decode(r)
+	if err != nil {
+		return Topology{}, err
+	}
+
+	return GraphMLToTopology(gml)
+}
+
+/*
+Converts the GraphML instance to a topology, keeping the data of the graph, nodes and edges
+as attributes named after their GraphML keys.
+*/
+func GraphMLToTopology(gml graphml.GraphML) (Topology, error) {
+	if len(gml.Graphs) != 1 {
+		return Topology{}, errors.New("GraphML instance must contain exactly 1 graph!")
+	}
+
+	keyIdToName := make(map[string]string)
+	for _, key := range gml.Keys {
+		keyIdToName[key.ID] = key.Name
+	}
+	dataToAttrs := func(data []*graphml.Data) Attributes {
+		attrs := make(Attributes)
+		for _, d := range data {
+			if name, exists := keyIdToName[d.Key]; exists {
+				attrs[name] = strings.TrimSpace(d.Value)
+			}
+		}
+		return attrs
+	}
+
+	gmlGraph := gml.Graphs[0]
+	b := newTopologyBuilder(gml.Description)
+	b.topo.Attrs = dataToAttrs(gmlGraph.Data)
+
+	for _, gmlNode := range gmlGraph.Nodes {
+		b.setNodeAttrs(gmlNode.ID, dataToAttrs(gmlNode.Data))
+	}
+
+	for _, edge := range gmlGraph.Edges {
+		b.edge(edge.Source, edge.Target, dataToAttrs(edge.Data))
+	}
+
+	return b.build(), nil
+}
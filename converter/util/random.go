@@ -10,10 +10,12 @@ import (
 
 const SEED int64 = 3
 
-var randGen rand.Rand
-
-func init() {
-	randGen = *rand.New(rand.NewSource(SEED))
+/*
+Returns a new random generator with the given seed. Every network and synthetic topology gets
+its own generator, so they can be generated concurrently and each of them is reproducible.
+*/
+func NewRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
 }
 
 /*
@@ -21,7 +23,7 @@ Picks at random 'picksNr' WITHOUT replacement from 'arr' and returns the result.
 Ignores duplicate elements.
 It stable sorts the elements of the array to ensure reproducible results.
 */
-func RandomFromArray[OrdArr ~[]E, E cmp.Ordered](arr OrdArr, picksNr uint) (OrdArr, error) {
+func RandomFromArray[OrdArr ~[]E, E cmp.Ordered](r *rand.Rand, arr OrdArr, picksNr uint) (OrdArr, error) {
 	arr = sortAndRemoveDuplicates(arr)
 	if int(picksNr) > len(arr) {
 		return OrdArr{}, errors.New(
@@ -30,7 +32,7 @@ func RandomFromArray[OrdArr ~[]E, E cmp.Ordered](arr OrdArr, picksNr uint) (OrdA
 	}
 
 	picks := OrdArr{}
-	randIndecies := randGen.Perm(len(arr))[:picksNr]
+	randIndecies := r.Perm(len(arr))[:picksNr]
 
 	for _, randIndex := range randIndecies {
 		randValue := arr[randIndex]
@@ -55,13 +57,13 @@ func sortAndRemoveDuplicates[OrdArr ~[]E, E cmp.Ordered](arr OrdArr) OrdArr {
 Picks at random 'picksNr' elements with replacement from 'arr' and returns the result.
 It stable sorts the elements of the array to ensure reproducible results.
 */
-func RandomFromArrayWithReplc[OrdArr ~[]E, E cmp.Ordered](arr OrdArr, picksNr uint) OrdArr {
+func RandomFromArrayWithReplc[OrdArr ~[]E, E cmp.Ordered](r *rand.Rand, arr OrdArr, picksNr uint) OrdArr {
 	arr = sortAndRemoveDuplicates(arr)
 
 	picks := OrdArr{}
 	arrLen := len(arr)
 	for range picksNr {
-		randIndex := randGen.Intn(arrLen)
+		randIndex := r.Intn(arrLen)
 		randValue := arr[randIndex]
 
 		picks = append(picks, randValue)
@@ -0,0 +1,187 @@
+package util
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+	"gonum.org/v1/gonum/graph/topo"
+)
+
+// Strategy for handling disconnected topologies
+type RepairStrategy int
+
+const (
+	REJECT_DISCONNECTED    RepairStrategy = iota // drop the whole topology
+	KEEP_LARGEST_COMPONENT                       // keep only the component with the most nodes
+	SPLIT_COMPONENTS                             // keep every component as its own topology
+)
+
+const COMPONENT_NAME_SEP = "#c"
+
+var repairStrategyNames = map[string]RepairStrategy{
+	"reject":  REJECT_DISCONNECTED,
+	"largest": KEEP_LARGEST_COMPONENT,
+	"split":   SPLIT_COMPONENTS,
+}
+
+func ParseRepairStrategy(str string) (RepairStrategy, error) {
+	strategy, exists := repairStrategyNames[strings.ToLower(str)]
+	if !exists {
+		return REJECT_DISCONNECTED, errors.New(fmt.Sprintf("Unknown repair strategy '%s'!", str))
+	}
+	return strategy, nil
+}
+
+/*
+Same as ValidateTopologies, but disconnected topologies are repaired with the given strategy
+instead of being dropped. With SPLIT_COMPONENTS, component i of topology 'Name' is returned
+as 'Name#ci', where components are ordered from the largest to the smallest.
+*/
+func ValidateTopologiesWithRepair(
+	tops map[string]Topology,
+	strategy RepairStrategy,
+) map[string]Topology {
+	if strategy == REJECT_DISCONNECTED {
+		return ValidateTopologies(tops)
+	}
+
+	repairedTops := make(map[string]Topology)
+	for name, top := range tops {
+		if top.Graph.Nodes().Len() == 0 {
+			// let the validation report it
+			repairedTops[name] = top
+			continue
+		}
+
+		for newName, newTop := range RepairTopology(name, top, strategy) {
+			repairedTops[newName] = newTop
+		}
+	}
+
+	return ValidateTopologies(repairedTops)
+}
+
+/*
+Splits the given topology into connected components and keeps them according to the strategy,
+logging the nodes that were removed. Connected topologies are returned unchanged.
+*/
+func RepairTopology(name string, top Topology, strategy RepairStrategy) map[string]Topology {
+	components := sortedComponents(top.Graph)
+	if len(components) <= 1 || strategy == REJECT_DISCONNECTED {
+		return map[string]Topology{name: top}
+	}
+
+	switch strategy {
+	case KEEP_LARGEST_COMPONENT:
+		removedNodes := []string{}
+		for _, component := range components[1:] {
+			removedNodes = append(removedNodes, nodeLabels(top, component)...)
+		}
+		log.Printf(
+			"%s: Kept the largest of %d components (%d nodes). Removed %d node(s): %s",
+			name,
+			len(components),
+			len(components[0]),
+			len(removedNodes),
+			strings.Join(removedNodes, ", "),
+		)
+		return map[string]Topology{name: SubTopology(top, components[0])}
+	default:
+		log.Printf(
+			"%s: Split into %d components of %s nodes.",
+			name,
+			len(components),
+			componentSizes(components),
+		)
+		newTops := make(map[string]Topology)
+		for i, component := range components {
+			newTops[fmt.Sprintf("%s%s%d", name, COMPONENT_NAME_SEP, i)] = SubTopology(top, component)
+		}
+		return newTops
+	}
+}
+
+/*
+Returns the topology induced by the given nodes. Node ids are preserved, so the node
+and edge attributes of the original topology still apply.
+*/
+func SubTopology(top Topology, nodes []graph.Node) Topology {
+	g := *simple.NewUndirectedGraph()
+	for _, node := range nodes {
+		g.AddNode(node)
+	}
+
+	newTop := NewTopology(g)
+	for k, v := range top.Attrs {
+		newTop.Attrs[k] = v
+	}
+
+	for _, node := range nodes {
+		if attrs, exists := top.NodeAttrs[node.ID()]; exists {
+			newTop.NodeAttrs[node.ID()] = attrs
+		}
+	}
+
+	iter := top.Graph.Edges()
+	for iter.Next() {
+		from, to := iter.Edge().From().ID(), iter.Edge().To().ID()
+		if newTop.Graph.Node(from) == nil || newTop.Graph.Node(to) == nil {
+			continue
+		}
+
+		newTop.Graph.SetEdge(iter.Edge())
+		edgeId := NewI64Tup(from, to)
+		if attrs, exists := top.EdgeAttrs[edgeId]; exists {
+			newTop.EdgeAttrs[edgeId] = attrs
+		} else if attrs, exists := top.EdgeAttrs[NewI64Tup(to, from)]; exists {
+			newTop.EdgeAttrs[NewI64Tup(to, from)] = attrs
+		}
+	}
+
+	return newTop
+}
+
+// returns the connected components ordered by decreasing size, then by their smallest node id
+func sortedComponents(g Graph) [][]graph.Node {
+	components := topo.ConnectedComponents(&g)
+	for _, component := range components {
+		slices.SortFunc(component, func(a, b graph.Node) int {
+			return cmp.Compare(a.ID(), b.ID())
+		})
+	}
+
+	slices.SortFunc(components, func(a, b []graph.Node) int {
+		if len(a) != len(b) {
+			return cmp.Compare(len(b), len(a))
+		}
+		return cmp.Compare(a[0].ID(), b[0].ID())
+	})
+
+	return components
+}
+
+func nodeLabels(top Topology, nodes []graph.Node) []string {
+	labels := []string{}
+	for _, node := range nodes {
+		label, exists := top.NodeAttr(node.ID(), LABEL_ATTR)
+		if !exists {
+			label = fmt.Sprintf("%d", node.ID())
+		}
+		labels = append(labels, label)
+	}
+	return labels
+}
+
+func componentSizes(components [][]graph.Node) string {
+	sizes := []string{}
+	for _, component := range components {
+		sizes = append(sizes, fmt.Sprintf("%d", len(component)))
+	}
+	return strings.Join(sizes, "/")
+}
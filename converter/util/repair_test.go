@@ -0,0 +1,80 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+// a triangle with a capacity on a - b, and two separate links
+const disconnectedEdgeList = `a b capacity=10
+b c
+c a
+d e
+f g
+`
+
+func disconnectedTopologies(t *testing.T) map[string]Topology {
+	disconnected, err := EdgeListToTopology(strings.NewReader(disconnectedEdgeList), "Disconnected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	connected, err := EdgeListToTopology(strings.NewReader("a b\nb c\n"), "Connected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]Topology{"Disconnected": disconnected, "Connected": connected}
+}
+
+func TestRepairReject(t *testing.T) {
+	topos := ValidateTopologiesWithRepair(disconnectedTopologies(t), REJECT_DISCONNECTED)
+	if _, exists := topos["Disconnected"]; exists || len(topos) != 1 {
+		t.Errorf("only the connected topology must be kept, got %d topologies", len(topos))
+	}
+}
+
+func TestRepairKeepLargest(t *testing.T) {
+	topos := ValidateTopologiesWithRepair(disconnectedTopologies(t), KEEP_LARGEST_COMPONENT)
+	if len(topos) != 2 {
+		t.Fatalf("got %d topologies, want 2", len(topos))
+	}
+
+	largest := topos["Disconnected"]
+	checkTriangle(t, largest)
+	if nodeWithLabel(largest, "d") >= 0 {
+		t.Error("the nodes of the smaller components must be removed")
+	}
+}
+
+func TestRepairSplit(t *testing.T) {
+	topos := ValidateTopologiesWithRepair(disconnectedTopologies(t), SPLIT_COMPONENTS)
+	if len(topos) != 4 {
+		t.Fatalf("got %d topologies, want 4", len(topos))
+	}
+
+	checkTriangle(t, topos["Disconnected#c0"])
+	// components of the same size are ordered by their smallest node id
+	for name, label := range map[string]string{"Disconnected#c1": "d", "Disconnected#c2": "f"} {
+		topo, exists := topos[name]
+		if !exists {
+			t.Fatalf("missing component %s", name)
+		}
+		if topo.Graph.Nodes().Len() != 2 || nodeWithLabel(topo, label) < 0 {
+			t.Errorf("%s: got %d nodes, want 2 including '%s'", name, topo.Graph.Nodes().Len(), label)
+		}
+	}
+	if _, exists := topos["Connected"]; !exists {
+		t.Error("connected topologies must keep their name")
+	}
+}
+
+func TestParseRepairStrategy(t *testing.T) {
+	for name, want := range repairStrategyNames {
+		got, err := ParseRepairStrategy(strings.ToUpper(name))
+		if err != nil || got != want {
+			t.Errorf("%s: got %v, %v", name, got, err)
+		}
+	}
+	if _, err := ParseRepairStrategy("merge"); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
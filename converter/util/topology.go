@@ -0,0 +1,259 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+const LABEL_ATTR = "label"
+
+type Attributes map[string]string
+
+/*
+A topology graph together with the attributes of the whole graph, of its nodes
+(keyed by node id) and of its edges (keyed by the ids of the edge ends, in any order).
+*/
+type Topology struct {
+	Graph     Graph
+	Attrs     Attributes
+	NodeAttrs map[int64]Attributes
+	EdgeAttrs map[I64Tup]Attributes
+}
+
+// Parses a topology from the given reader. 'name' identifies the source in error messages.
+type TopologyParser func(r io.Reader, name string) (Topology, error)
+
+// maps a file extension to the parser used for the files having that extension
+var topologyParsers = map[string]TopologyParser{
+	GRAPHML_EXT: GraphMLReaderToTopology,
+	".gml":      GMLToTopology,
+	".dot":      DOTToTopology,
+	".gv":       DOTToTopology,
+	".edges":    EdgeListToTopology,
+	".edgelist": EdgeListToTopology,
+	".json":     JSONToTopology,
+	".cch":      RocketfuelToTopology,
+	".intra":    RocketfuelWeightsToTopology,
+	".txt":      SNDlibToTopology,
+	".xml":      SNDlibXMLToTopology,
+}
+
+/*
+Recognises the files of the formats whose extensions are also used by unrelated files, e.g.
+a README.txt, from their first SNIFF_LEN bytes. GetTopologies skips the files of these
+extensions that are not recognised.
+*/
+var topologySniffers = map[string]func(head []byte) bool{
+	".txt": IsSNDlibNative,
+	".xml": IsSNDlibXML,
+}
+
+const SNIFF_LEN = 4096
+
+func NewTopology(g Graph) Topology {
+	return Topology{
+		Graph:     g,
+		Attrs:     make(Attributes),
+		NodeAttrs: make(map[int64]Attributes),
+		EdgeAttrs: make(map[I64Tup]Attributes),
+	}
+}
+
+// Returns the value of the given attribute of node 'nodeId' and whether it exists
+func (t Topology) NodeAttr(nodeId int64, key string) (string, bool) {
+	value, exists := t.NodeAttrs[nodeId][key]
+	return value, exists
+}
+
+// Returns the value of the given attribute of the edge between 'fromId' and 'toId'
+// and whether it exists
+func (t Topology) EdgeAttr(fromId, toId int64, key string) (string, bool) {
+	if value, exists := t.EdgeAttrs[NewI64Tup(fromId, toId)][key]; exists {
+		return value, true
+	}
+	value, exists := t.EdgeAttrs[NewI64Tup(toId, fromId)][key]
+	return value, exists
+}
+
+// Returns the geographic coordinates of the node, from its Longitude and Latitude attributes,
+// and whether the node has valid coordinates
+func (t Topology) Coordinates(nodeId int64) (float64, float64, bool) {
+	lonStr, lonExists := t.NodeAttr(nodeId, LONGITUDE_ATTR)
+	latStr, latExists := t.NodeAttr(nodeId, LATITUDE_ATTR)
+	if !lonExists || !latExists {
+		return 0, 0, false
+	}
+
+	lon, lonErr := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if lonErr != nil || latErr != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+	return lon, lat, true
+}
+
+// Returns the extensions of all the topology files that can be loaded
+func SupportedTopologyExts() []string {
+	exts := []string{}
+	for ext := range topologyParsers {
+		exts = append(exts, ext)
+	}
+	slices.Sort(exts)
+	return exts
+}
+
+/*
+Loads all the topology files from the given directory, picking the parser based on the file
+extension. Files that cannot be parsed are skipped, and so are the files with a generic
+extension whose content is not of the format of their parser (see topologySniffers).
+The topologies are mapped to their file name.
+*/
+func GetTopologies(dirPath string) (map[string]Topology, error) {
+	files, err := os.ReadDir(dirPath)
+	if err != nil {
+		log.Printf("Failed to read file paths!\n%s", err.Error())
+		return make(map[string]Topology), err
+	}
+
+	topos := make(map[string]Topology)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		parser, exists := topologyParsers[ext]
+		if !exists {
+			continue
+		}
+
+		path := filepath.Join(dirPath, file.Name())
+		if sniff, isGeneric := topologySniffers[ext]; isGeneric && !sniffFile(path, sniff) {
+			continue
+		}
+
+		topo, err := loadTopology(path, parser)
+		if err != nil {
+			log.Printf("Something went wrong while parsing %s. Skipping...\n%s", file.Name(), err.Error())
+			continue
+		}
+
+		topos[file.Name()] = topo
+	}
+
+	return topos, nil
+}
+
+// Loads a single topology file, picking the parser based on the file extension
+func GetTopology(path string) (Topology, error) {
+	parser, exists := topologyParsers[strings.ToLower(filepath.Ext(path))]
+	if !exists {
+		return Topology{}, errors.New(fmt.Sprintf("Unsupported topology file extension: %s", path))
+	}
+
+	return loadTopology(path, parser)
+}
+
+/*
+Checks whether the beginning of the file is recognised by 'sniff'. Files that cannot be read
+are treated as recognised, so that loading them reports the error instead of skipping them.
+*/
+func sniffFile(path string, sniff func(head []byte) bool) bool {
+	r, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer r.Close()
+
+	head := make([]byte, SNIFF_LEN)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return true
+	}
+	return sniff(head[:n])
+}
+
+func loadTopology(path string, parser TopologyParser) (Topology, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		return Topology{}, err
+	}
+	defer r.Close()
+
+	return parser(r, filepath.Base(path))
+}
+
+/*
+Builds a topology from nodes identified by arbitrary string keys. Since the topology graph
+is simple, self-loops are skipped and the attributes of duplicate edges are merged.
+*/
+type topologyBuilder struct {
+	name      string
+	topo      Topology
+	keyToNode map[string]graph.Node
+}
+
+func newTopologyBuilder(name string) *topologyBuilder {
+	return &topologyBuilder{
+		name:      name,
+		topo:      NewTopology(*simple.NewUndirectedGraph()),
+		keyToNode: make(map[string]graph.Node),
+	}
+}
+
+// returns the node with the given key, creating it if needed.
+// The key is also used as the node's label, unless a label attribute is set later on.
+func (b *topologyBuilder) node(key string) graph.Node {
+	if node, exists := b.keyToNode[key]; exists {
+		return node
+	}
+
+	newNode := b.topo.Graph.NewNode()
+	b.topo.Graph.AddNode(newNode)
+	b.keyToNode[key] = newNode
+	b.topo.NodeAttrs[newNode.ID()] = Attributes{LABEL_ATTR: key}
+	return newNode
+}
+
+func (b *topologyBuilder) setNodeAttrs(key string, attrs Attributes) {
+	node := b.node(key)
+	for k, v := range attrs {
+		b.topo.NodeAttrs[node.ID()][k] = v
+	}
+}
+
+func (b *topologyBuilder) edge(fromKey, toKey string, attrs Attributes) {
+	if fromKey == toKey {
+		log.Printf("%s: Skipping self-loop\n", b.name)
+		return
+	}
+
+	from, to := b.node(fromKey), b.node(toKey)
+	edgeId := NewI64Tup(from.ID(), to.ID())
+	if b.topo.Graph.HasEdgeBetween(from.ID(), to.ID()) {
+		if _, exists := b.topo.EdgeAttrs[edgeId]; !exists {
+			edgeId = NewI64Tup(to.ID(), from.ID())
+		}
+	} else {
+		b.topo.Graph.SetEdge(b.topo.Graph.NewEdge(from, to))
+		b.topo.EdgeAttrs[edgeId] = make(Attributes)
+	}
+
+	for k, v := range attrs {
+		b.topo.EdgeAttrs[edgeId][k] = v
+	}
+}
+
+func (b *topologyBuilder) build() Topology {
+	return b.topo
+}
@@ -0,0 +1,612 @@
+package util
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+/*
+A GML value is either a scalar (number or string) or a nested list of key-value pairs.
+*/
+type gmlPair struct {
+	key    string
+	scalar string
+	list   []gmlPair
+	isList bool
+}
+
+/*
+Parses a topology from a GML file, as published by the Topology Zoo. The scalar properties
+of the graph, nodes and edges become attributes. Nested lists (e.g. 'graphics') are flattened
+into attributes with dot-separated names.
+*/
+func GMLToTopology(r io.Reader, name string) (Topology, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Topology{}, err
+	}
+
+	tokens, err := tokenizeGML(string(data))
+	if err != nil {
+		return Topology{}, errors.New(fmt.Sprintf("%s: %s", name, err.Error()))
+	}
+
+	pos := 0
+	pairs, err := parseGMLList(tokens, &pos, false)
+	if err != nil {
+		return Topology{}, errors.New(fmt.Sprintf("%s: %s", name, err.Error()))
+	}
+
+	var gmlGraph *gmlPair
+	for i := range pairs {
+		if pairs[i].key == "graph" && pairs[i].isList {
+			if gmlGraph != nil {
+				return Topology{}, errors.New("GML file must contain exactly 1 graph!")
+			}
+			gmlGraph = &pairs[i]
+		}
+	}
+	if gmlGraph == nil {
+		return Topology{}, errors.New("GML file must contain exactly 1 graph!")
+	}
+
+	b := newTopologyBuilder(name)
+	edges := []gmlPair{}
+	for _, pair := range gmlGraph.list {
+		switch {
+		case pair.key == "node" && pair.isList:
+			attrs := flattenGMLList(pair.list, "")
+			id, exists := attrs["id"]
+			if !exists {
+				return Topology{}, errors.New(fmt.Sprintf("%s: GML node without id!", name))
+			}
+			delete(attrs, "id")
+			b.setNodeAttrs(id, attrs)
+		case pair.key == "edge" && pair.isList:
+			// edges are added after all nodes, so that node ids are assigned in file order
+			edges = append(edges, pair)
+		case !pair.isList:
+			b.topo.Attrs[pair.key] = pair.scalar
+		}
+	}
+
+	for _, edge := range edges {
+		attrs := flattenGMLList(edge.list, "")
+		source, sourceExists := attrs["source"]
+		target, targetExists := attrs["target"]
+		if !sourceExists || !targetExists {
+			return Topology{}, errors.New(fmt.Sprintf("%s: GML edge without source or target!", name))
+		}
+		delete(attrs, "source")
+		delete(attrs, "target")
+		b.edge(source, target, attrs)
+	}
+
+	return b.build(), nil
+}
+
+func tokenizeGML(str string) ([]string, error) {
+	tokens := []string{}
+	i := 0
+	for i < len(str) {
+		c := str[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '#':
+			for i < len(str) && str[i] != '\n' {
+				i++
+			}
+		case c == '[' || c == ']':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			end := strings.IndexByte(str[i+1:], '"')
+			if end < 0 {
+				return []string{}, errors.New("Unterminated GML string!")
+			}
+			// quotes are kept to tell strings apart from keys and brackets
+			tokens = append(tokens, str[i:i+end+2])
+			i += end + 2
+		default:
+			start := i
+			for i < len(str) && !unicode.IsSpace(rune(str[i])) && str[i] != '[' && str[i] != ']' {
+				i++
+			}
+			tokens = append(tokens, str[start:i])
+		}
+	}
+	return tokens, nil
+}
+
+func parseGMLList(tokens []string, pos *int, nested bool) ([]gmlPair, error) {
+	pairs := []gmlPair{}
+	for *pos < len(tokens) {
+		key := tokens[*pos]
+		if key == "]" {
+			if !nested {
+				return pairs, errors.New("Unexpected ']' in GML file!")
+			}
+			*pos++
+			return pairs, nil
+		}
+
+		*pos++
+		if *pos >= len(tokens) {
+			return pairs, errors.New(fmt.Sprintf("GML key '%s' has no value!", key))
+		}
+
+		value := tokens[*pos]
+		*pos++
+		if value == "[" {
+			list, err := parseGMLList(tokens, pos, true)
+			if err != nil {
+				return pairs, err
+			}
+			pairs = append(pairs, gmlPair{key: key, list: list, isList: true})
+			continue
+		}
+
+		pairs = append(pairs, gmlPair{key: key, scalar: strings.Trim(value, "\"")})
+	}
+
+	if nested {
+		return pairs, errors.New("Unterminated GML list!")
+	}
+	return pairs, nil
+}
+
+func flattenGMLList(pairs []gmlPair, prefix string) Attributes {
+	attrs := make(Attributes)
+	for _, pair := range pairs {
+		if !pair.isList {
+			attrs[prefix+pair.key] = pair.scalar
+			continue
+		}
+		for k, v := range flattenGMLList(pair.list, prefix+pair.key+".") {
+			attrs[k] = v
+		}
+	}
+	return attrs
+}
+
+type dotParser struct {
+	tokens []string
+	pos    int
+	b      *topologyBuilder
+}
+
+/*
+Parses a topology from a Graphviz DOT file. Directed graphs are read as undirected.
+Node, edge and graph attributes (including the defaults set with 'node [...]' and
+'edge [...]' statements) become topology attributes. Subgraphs are flattened, an edge
+to a subgraph connects to all of the subgraph's nodes and node ports are ignored.
+*/
+func DOTToTopology(r io.Reader, name string) (Topology, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Topology{}, err
+	}
+
+	tokens, err := tokenizeDOT(string(data))
+	if err != nil {
+		return Topology{}, errors.New(fmt.Sprintf("%s: %s", name, err.Error()))
+	}
+
+	p := &dotParser{tokens: tokens, b: newTopologyBuilder(name)}
+	err = p.parseGraph()
+	if err != nil {
+		return Topology{}, errors.New(fmt.Sprintf("%s: %s", name, err.Error()))
+	}
+
+	return p.b.build(), nil
+}
+
+func tokenizeDOT(str string) ([]string, error) {
+	tokens := []string{}
+	i := 0
+	for i < len(str) {
+		c := str[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case strings.HasPrefix(str[i:], "//") || (c == '#' && (i == 0 || str[i-1] == '\n')):
+			for i < len(str) && str[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(str[i:], "/*"):
+			end := strings.Index(str[i+2:], "*/")
+			if end < 0 {
+				return []string{}, errors.New("Unterminated DOT comment!")
+			}
+			i += end + 4
+		case strings.HasPrefix(str[i:], "--") || strings.HasPrefix(str[i:], "->"):
+			tokens = append(tokens, str[i:i+2])
+			i += 2
+		case strings.ContainsRune("{}[];,=:", rune(c)):
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			var sb strings.Builder
+			i++
+			for i < len(str) && str[i] != '"' {
+				if str[i] == '\\' && i+1 < len(str) && str[i+1] == '"' {
+					i++
+				}
+				sb.WriteByte(str[i])
+				i++
+			}
+			if i >= len(str) {
+				return []string{}, errors.New("Unterminated DOT string!")
+			}
+			// the leading quote marks the token as an identifier
+			tokens = append(tokens, "\""+sb.String())
+			i++
+		case c == '<':
+			depth, start := 0, i
+			for i < len(str) {
+				if str[i] == '<' {
+					depth++
+				} else if str[i] == '>' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+				i++
+			}
+			if i >= len(str) {
+				return []string{}, errors.New("Unterminated DOT HTML string!")
+			}
+			tokens = append(tokens, "\""+str[start+1:i])
+			i++
+		default:
+			start := i
+			if c == '-' {
+				i++ // negative numeral
+			}
+			for i < len(str) && isDOTIdChar(str[i]) {
+				i++
+			}
+			if start == i {
+				return []string{}, errors.New(fmt.Sprintf("Unexpected character '%c' in DOT file!", c))
+			}
+			tokens = append(tokens, str[start:i])
+		}
+	}
+	return tokens, nil
+}
+
+func isDOTIdChar(c byte) bool {
+	return c == '_' || c == '.' || c >= 0x80 || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+func (p *dotParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *dotParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *dotParser) expect(token string) error {
+	if next := p.next(); next != token {
+		return errors.New(fmt.Sprintf("Expected '%s' but found '%s'!", token, next))
+	}
+	return nil
+}
+
+func isDOTId(token string) bool {
+	return token != "" && !strings.Contains("{}[];,=:", token) && token != "--" && token != "->"
+}
+
+func dotId(token string) string {
+	return strings.TrimPrefix(token, "\"")
+}
+
+func (p *dotParser) parseGraph() error {
+	if strings.ToLower(p.peek()) == "strict" {
+		p.next()
+	}
+
+	kind := strings.ToLower(p.next())
+	if kind != "graph" && kind != "digraph" {
+		return errors.New("DOT file must start with 'graph' or 'digraph'!")
+	}
+
+	if p.peek() != "{" {
+		p.b.topo.Attrs["name"] = dotId(p.next())
+	}
+
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+
+	_, err := p.parseStmtList(make(Attributes), make(Attributes))
+	return err
+}
+
+/*
+Parses statements until the closing brace of the current (sub)graph and returns
+the keys of the nodes referenced in it.
+*/
+func (p *dotParser) parseStmtList(nodeDefaults, edgeDefaults Attributes) ([]string, error) {
+	nodeDefaults, edgeDefaults = copyAttrs(nodeDefaults), copyAttrs(edgeDefaults)
+	nodeKeys := []string{}
+
+	for {
+		token := p.peek()
+		switch {
+		case token == "":
+			return nodeKeys, errors.New("Unexpected end of DOT file!")
+		case token == "}":
+			p.next()
+			return nodeKeys, nil
+		case token == ";" || token == ",":
+			p.next()
+		case strings.ToLower(token) == "graph" || strings.ToLower(token) == "node" ||
+			strings.ToLower(token) == "edge":
+			p.next()
+			attrs, err := p.parseAttrLists()
+			if err != nil {
+				return nodeKeys, err
+			}
+			target := nodeDefaults
+			switch strings.ToLower(token) {
+			case "graph":
+				target = p.b.topo.Attrs
+			case "edge":
+				target = edgeDefaults
+			}
+			for k, v := range attrs {
+				target[k] = v
+			}
+		case isDOTId(token) && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1] == "=":
+			p.pos += 2
+			p.b.topo.Attrs[dotId(token)] = dotId(p.next())
+		default:
+			keys, err := p.parseNodeOrEdgeStmt(nodeDefaults, edgeDefaults)
+			if err != nil {
+				return nodeKeys, err
+			}
+			nodeKeys = append(nodeKeys, keys...)
+		}
+	}
+}
+
+func (p *dotParser) parseNodeOrEdgeStmt(nodeDefaults, edgeDefaults Attributes) ([]string, error) {
+	groups := [][]string{}
+	for {
+		group, err := p.parseEndpoint(nodeDefaults, edgeDefaults)
+		if err != nil {
+			return []string{}, err
+		}
+		groups = append(groups, group)
+
+		if p.peek() != "--" && p.peek() != "->" {
+			break
+		}
+		p.next()
+	}
+
+	attrs, err := p.parseAttrLists()
+	if err != nil {
+		return []string{}, err
+	}
+
+	nodeKeys := []string{}
+	for _, group := range groups {
+		nodeKeys = append(nodeKeys, group...)
+	}
+
+	if len(groups) == 1 {
+		for _, key := range groups[0] {
+			p.b.setNodeAttrs(key, attrs)
+		}
+		return nodeKeys, nil
+	}
+
+	edgeAttrs := copyAttrs(edgeDefaults)
+	for k, v := range attrs {
+		edgeAttrs[k] = v
+	}
+	for i := range len(groups) - 1 {
+		for _, from := range groups[i] {
+			for _, to := range groups[i+1] {
+				p.b.edge(from, to, edgeAttrs)
+			}
+		}
+	}
+
+	return nodeKeys, nil
+}
+
+func (p *dotParser) parseEndpoint(nodeDefaults, edgeDefaults Attributes) ([]string, error) {
+	token := p.peek()
+	if strings.ToLower(token) == "subgraph" || token == "{" {
+		if strings.ToLower(p.next()) == "subgraph" && p.peek() != "{" {
+			p.next() // subgraph name
+		}
+		if token != "{" {
+			if err := p.expect("{"); err != nil {
+				return []string{}, err
+			}
+		}
+		return p.parseStmtList(nodeDefaults, edgeDefaults)
+	}
+
+	if !isDOTId(token) {
+		return []string{}, errors.New(fmt.Sprintf("Unexpected token '%s' in DOT file!", token))
+	}
+
+	key := dotId(p.next())
+	// skip node ports
+	for p.peek() == ":" {
+		p.next()
+		p.next()
+	}
+
+	if _, exists := p.b.keyToNode[key]; !exists {
+		p.b.setNodeAttrs(key, nodeDefaults)
+	}
+	return []string{key}, nil
+}
+
+func (p *dotParser) parseAttrLists() (Attributes, error) {
+	attrs := make(Attributes)
+	for p.peek() == "[" {
+		p.next()
+		for p.peek() != "]" {
+			if p.peek() == ";" || p.peek() == "," {
+				p.next()
+				continue
+			}
+
+			key := p.next()
+			if !isDOTId(key) {
+				return attrs, errors.New(fmt.Sprintf("Unexpected token '%s' in DOT attribute list!", key))
+			}
+			if err := p.expect("="); err != nil {
+				return attrs, err
+			}
+			attrs[dotId(key)] = dotId(p.next())
+		}
+		p.next()
+	}
+	return attrs, nil
+}
+
+func copyAttrs(attrs Attributes) Attributes {
+	newAttrs := make(Attributes, len(attrs))
+	for k, v := range attrs {
+		newAttrs[k] = v
+	}
+	return newAttrs
+}
+
+/*
+Parses a topology from a plain edge list. Every line contains the two ends of an edge,
+separated by whitespace or commas, optionally followed by a weight and by 'key=value' attributes.
+Empty lines and lines starting with '#' or '%' are ignored.
+*/
+func EdgeListToTopology(r io.Reader, name string) (Topology, error) {
+	b := newTopologyBuilder(name)
+	scanner := bufio.NewScanner(r)
+	lineNr := 0
+
+	for scanner.Scan() {
+		lineNr++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '%' {
+			continue
+		}
+
+		fields := strings.FieldsFunc(line, func(c rune) bool {
+			return unicode.IsSpace(c) || c == ','
+		})
+		if len(fields) < 2 {
+			return Topology{}, errors.New(fmt.Sprintf("%s:%d: Expected at least 2 nodes!", name, lineNr))
+		}
+
+		attrs := make(Attributes)
+		for _, field := range fields[2:] {
+			key, value, isAttr := strings.Cut(field, "=")
+			if !isAttr {
+				key, value = "weight", field
+			}
+			attrs[key] = value
+		}
+		b.edge(fields[0], fields[1], attrs)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Topology{}, err
+	}
+
+	return b.build(), nil
+}
+
+type jsonNodeLink struct {
+	Graph map[string]any   `json:"graph"`
+	Nodes []map[string]any `json:"nodes"`
+	Links []map[string]any `json:"links"`
+	Edges []map[string]any `json:"edges"`
+}
+
+/*
+Parses a topology from the JSON node-link format (as written by NetworkX), e.g.:
+
+	{"graph": {...}, "nodes": [{"id": "a", ...}], "links": [{"source": "a", "target": "b", ...}]}
+
+Edges may also be listed under "edges". All other node, edge and graph properties become attributes.
+*/
+func JSONToTopology(r io.Reader, name string) (Topology, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	nodeLink := jsonNodeLink{}
+	err := decoder.Decode(&nodeLink)
+	if err != nil {
+		return Topology{}, errors.New(fmt.Sprintf("%s: %s", name, err.Error()))
+	}
+
+	b := newTopologyBuilder(name)
+	b.topo.Attrs = jsonToAttrs(nodeLink.Graph)
+
+	for _, node := range nodeLink.Nodes {
+		attrs := jsonToAttrs(node)
+		id, exists := attrs["id"]
+		if !exists {
+			return Topology{}, errors.New(fmt.Sprintf("%s: JSON node without id!", name))
+		}
+		delete(attrs, "id")
+		b.setNodeAttrs(id, attrs)
+	}
+
+	for _, link := range append(nodeLink.Links, nodeLink.Edges...) {
+		attrs := jsonToAttrs(link)
+		source, sourceExists := attrs["source"]
+		target, targetExists := attrs["target"]
+		if !sourceExists || !targetExists {
+			return Topology{}, errors.New(fmt.Sprintf("%s: JSON link without source or target!", name))
+		}
+		delete(attrs, "source")
+		delete(attrs, "target")
+		b.edge(source, target, attrs)
+	}
+
+	return b.build(), nil
+}
+
+func jsonToAttrs(obj map[string]any) Attributes {
+	attrs := make(Attributes)
+	for k, v := range obj {
+		switch value := v.(type) {
+		case nil:
+			continue
+		case string:
+			attrs[k] = value
+		case json.Number:
+			attrs[k] = value.String()
+		case bool:
+			attrs[k] = fmt.Sprintf("%t", value)
+		default:
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				continue
+			}
+			attrs[k] = string(encoded)
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,120 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+// every fixture describes the triangle a - b - c, with a capacity of 10 on the link a - b
+var topologyFixtures = map[string]string{
+	GRAPHML_EXT: `<?xml version="1.0" encoding="utf-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key attr.name="Network" attr.type="string" for="graph" id="d0" />
+  <key attr.name="label" attr.type="string" for="node" id="d1" />
+  <key attr.name="capacity" attr.type="string" for="edge" id="d2" />
+  <graph edgedefault="undirected">
+    <data key="d0">Triangle</data>
+    <node id="0"><data key="d1">a</data></node>
+    <node id="1"><data key="d1">b</data></node>
+    <node id="2"><data key="d1">c</data></node>
+    <edge source="0" target="1"><data key="d2">10</data></edge>
+    <edge source="1" target="2" />
+    <edge source="2" target="0" />
+  </graph>
+</graphml>`,
+	".gml": `graph [
+  Network "Triangle"
+  node [ id 0 label "a" graphics [ x 1.0 y 2.0 ] ]
+  node [ id 1 label "b" ]
+  node [ id 2 label "c" ]
+  edge [ source 0 target 1 capacity 10 ]
+  edge [ source 1 target 2 ]
+  edge [ source 2 target 0 ]
+]`,
+	".dot": `graph Triangle {
+  Network = "Triangle";
+  a -- b [capacity=10];
+  b -- c -- a;
+}`,
+	".edges": `# a triangle
+a b capacity=10
+b,c
+c a`,
+	".json": `{
+  "graph": {"Network": "Triangle"},
+  "nodes": [{"id": "a"}, {"id": "b"}, {"id": "c"}],
+  "links": [{"source": "a", "target": "b", "capacity": 10}, {"source": "b", "target": "c"}],
+  "edges": [{"source": "c", "target": "a"}]
+}`,
+}
+
+// returns the id of the node with the given label, or -1
+func nodeWithLabel(topo Topology, label string) int64 {
+	for id, attrs := range topo.NodeAttrs {
+		if attrs[LABEL_ATTR] == label {
+			return id
+		}
+	}
+	return -1
+}
+
+func TestTopologyFormats(t *testing.T) {
+	for ext, fixture := range topologyFixtures {
+		t.Run(ext, func(t *testing.T) {
+			topo, err := topologyParsers[ext](strings.NewReader(fixture), "triangle"+ext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTriangle(t, topo)
+
+			b, c := nodeWithLabel(topo, "b"), nodeWithLabel(topo, "c")
+			if _, exists := topo.EdgeAttr(b, c, "capacity"); exists {
+				t.Errorf("the link b - c must not have a capacity")
+			}
+		})
+	}
+}
+
+func checkTriangle(t *testing.T, topo Topology) {
+	t.Helper()
+	if topo.Graph.Nodes().Len() != 3 || topo.Graph.Edges().Len() != 3 {
+		t.Fatalf("got %d nodes and %d edges, want 3 and 3", topo.Graph.Nodes().Len(), topo.Graph.Edges().Len())
+	}
+
+	a, b, c := nodeWithLabel(topo, "a"), nodeWithLabel(topo, "b"), nodeWithLabel(topo, "c")
+	if a < 0 || b < 0 || c < 0 {
+		t.Fatalf("missing node labels: %v", topo.NodeAttrs)
+	}
+	if capacity, _ := topo.EdgeAttr(b, a, "capacity"); capacity != "10" {
+		t.Errorf("got capacity '%s' for the link a - b, want '10'", capacity)
+	}
+}
+
+func TestGMLNestedListsAreFlattened(t *testing.T) {
+	topo, err := GMLToTopology(strings.NewReader(topologyFixtures[".gml"]), "triangle.gml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topo.Attrs["Network"] != "Triangle" {
+		t.Errorf("got graph attributes %v", topo.Attrs)
+	}
+	if x, _ := topo.NodeAttr(nodeWithLabel(topo, "a"), "graphics.x"); x != "1.0" {
+		t.Errorf("got graphics.x '%s', want '1.0'", x)
+	}
+}
+
+func TestTopologyFormatErrors(t *testing.T) {
+	cases := map[string]string{
+		".gml":   `graph [ node [ label "a" ] ]`,
+		".dot":   `graph { a -- }`,
+		".edges": "a\n",
+		".json":  `{"nodes": [{"label": "a"}]}`,
+	}
+
+	for ext, content := range cases {
+		_, err := topologyParsers[ext](strings.NewReader(content), "invalid"+ext)
+		if err == nil {
+			t.Errorf("%s: expected an error", ext)
+		}
+	}
+}
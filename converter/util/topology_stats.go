@@ -0,0 +1,249 @@
+package util
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/graph/topo"
+)
+
+// GraphML metadata reported in the catalogue
+var STATS_METADATA_KEYS = []string{"Network", "GeoLocation", "Type", "DateYear"}
+
+var STATS_COLUMNS = []string{
+	"name",
+	"nodes",
+	"edges",
+	"components",
+	"diameter",
+	"avgDegree",
+	"bridges",
+	"articulationPoints",
+	"degreeDistribution",
+	"network",
+	"geoLocation",
+	"type",
+	"dateYear",
+}
+
+type TopologyStats struct {
+	Name               string      `json:"name"`
+	Nodes              int         `json:"nodes"`
+	Edges              int         `json:"edges"`
+	Components         int         `json:"components"`
+	Diameter           int         `json:"diameter"` // largest hop distance between two connected nodes
+	AvgDegree          float64     `json:"avgDegree"`
+	DegreeDistribution map[int]int `json:"degreeDistribution"` // maps a degree to the number of nodes having it
+	Bridges            int         `json:"bridges"`
+	ArticulationPoints int         `json:"articulationPoints"`
+	Metadata           Attributes  `json:"metadata,omitempty"`
+}
+
+func ComputeTopologyStats(name string, top Topology) TopologyStats {
+	g := top.Graph
+	stats := TopologyStats{
+		Name:               name,
+		Nodes:              g.Nodes().Len(),
+		Edges:              g.Edges().Len(),
+		Components:         len(topo.ConnectedComponents(&g)),
+		DegreeDistribution: make(map[int]int),
+		Metadata:           make(Attributes),
+	}
+
+	for _, key := range STATS_METADATA_KEYS {
+		stats.Metadata[key] = top.Attrs[key]
+	}
+
+	nodes := GetNodesArrayFromIter(g)
+	for _, node := range nodes {
+		stats.DegreeDistribution[g.From(node.ID()).Len()]++
+	}
+	if stats.Nodes > 0 {
+		stats.AvgDegree = 2 * float64(stats.Edges) / float64(stats.Nodes)
+	}
+
+	for _, node := range nodes {
+		for _, dist := range HopDistances(g, node.ID()) {
+			stats.Diameter = max(stats.Diameter, dist)
+		}
+	}
+
+	bridges, articulationPoints := bridgesAndArticulationPoints(g)
+	stats.Bridges, stats.ArticulationPoints = len(bridges), len(articulationPoints)
+
+	return stats
+}
+
+// Returns the hop distance from the given node to every node reachable from it
+func HopDistances(g Graph, fromId int64) map[int64]int {
+	dists := map[int64]int{fromId: 0}
+	queue := []int64{fromId}
+
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+
+		iter := g.From(curr)
+		for iter.Next() {
+			next := iter.Node().ID()
+			if _, visited := dists[next]; visited {
+				continue
+			}
+			dists[next] = dists[curr] + 1
+			queue = append(queue, next)
+		}
+	}
+
+	return dists
+}
+
+/*
+Finds the bridges and articulation points of the graph with Tarjan's low-link algorithm.
+Bridges are returned as tuples of node ids.
+*/
+func bridgesAndArticulationPoints(g Graph) ([]I64Tup, []int64) {
+	discovery, low := make(map[int64]int), make(map[int64]int)
+	bridges, articulationPoints := []I64Tup{}, make(map[int64]bool)
+	time := 0
+
+	var visit func(nodeId, parentId int64, isRoot bool)
+	visit = func(nodeId, parentId int64, isRoot bool) {
+		discovery[nodeId], low[nodeId] = time, time
+		time++
+		children := 0
+
+		iter := g.From(nodeId)
+		for iter.Next() {
+			next := iter.Node().ID()
+			if _, visited := discovery[next]; visited {
+				if isRoot || next != parentId {
+					low[nodeId] = min(low[nodeId], discovery[next])
+				}
+				continue
+			}
+
+			children++
+			visit(next, nodeId, false)
+			low[nodeId] = min(low[nodeId], low[next])
+
+			if low[next] > discovery[nodeId] {
+				bridges = append(bridges, NewI64Tup(nodeId, next))
+			}
+			if !isRoot && low[next] >= discovery[nodeId] {
+				articulationPoints[nodeId] = true
+			}
+		}
+
+		if isRoot && children > 1 {
+			articulationPoints[nodeId] = true
+		}
+	}
+
+	for _, node := range GetNodesArrayFromIter(g) {
+		if _, visited := discovery[node.ID()]; !visited {
+			visit(node.ID(), node.ID(), true)
+		}
+	}
+
+	return bridges, slices.Sorted(maps.Keys(articulationPoints))
+}
+
+// Returns the values of the catalogue row, in the order of STATS_COLUMNS
+func (s TopologyStats) Row() []string {
+	degrees := slices.Sorted(maps.Keys(s.DegreeDistribution))
+	distribution := []string{}
+	for _, degree := range degrees {
+		distribution = append(distribution, fmt.Sprintf("%d:%d", degree, s.DegreeDistribution[degree]))
+	}
+
+	return []string{
+		s.Name,
+		strconv.Itoa(s.Nodes),
+		strconv.Itoa(s.Edges),
+		strconv.Itoa(s.Components),
+		strconv.Itoa(s.Diameter),
+		strconv.FormatFloat(s.AvgDegree, 'f', 2, 64),
+		strconv.Itoa(s.Bridges),
+		strconv.Itoa(s.ArticulationPoints),
+		strings.Join(distribution, " "),
+		s.Metadata["Network"],
+		s.Metadata["GeoLocation"],
+		s.Metadata["Type"],
+		s.Metadata["DateYear"],
+	}
+}
+
+/*
+Sorts the statistics by the given column (one of STATS_COLUMNS). Numeric columns are
+compared as numbers, the others as strings. Ties are broken by name.
+*/
+func SortTopologyStats(stats []TopologyStats, column string, descending bool) error {
+	colIndex := slices.Index(STATS_COLUMNS, column)
+	if colIndex < 0 {
+		return errors.New(fmt.Sprintf("Unknown column '%s'!", column))
+	}
+
+	slices.SortStableFunc(stats, func(a, b TopologyStats) int {
+		aVal, bVal := a.Row()[colIndex], b.Row()[colIndex]
+		res := 0
+		aNum, aErr := strconv.ParseFloat(aVal, 64)
+		bNum, bErr := strconv.ParseFloat(bVal, 64)
+		if aErr == nil && bErr == nil {
+			res = cmp.Compare(aNum, bNum)
+		} else {
+			res = cmp.Compare(aVal, bVal)
+		}
+
+		if descending {
+			res = -res
+		}
+		if res == 0 {
+			return cmp.Compare(a.Name, b.Name)
+		}
+		return res
+	})
+
+	return nil
+}
+
+func WriteStatsCSV(w io.Writer, stats []TopologyStats) error {
+	csvWriter := csv.NewWriter(w)
+	err := csvWriter.Write(STATS_COLUMNS)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range stats {
+		err = csvWriter.Write(s.Row())
+		if err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func WriteStatsMarkdown(w io.Writer, stats []TopologyStats) error {
+	var sb strings.Builder
+	sb.WriteString("| " + strings.Join(STATS_COLUMNS, " | ") + " |\n")
+	sb.WriteString(strings.Repeat("| --- ", len(STATS_COLUMNS)) + "|\n")
+
+	for _, s := range stats {
+		row := s.Row()
+		for i := range row {
+			row[i] = strings.ReplaceAll(row[i], "|", "\\|")
+		}
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+// two triangles joined by the bridge c - d, with the pendant node g on f
+const bridgedTrianglesEdgeList = `a b
+b c
+c a
+c d
+d e
+e f
+f d
+f g
+`
+
+func TestComputeTopologyStats(t *testing.T) {
+	topo, err := EdgeListToTopology(strings.NewReader(bridgedTrianglesEdgeList), "Bridged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	topo.Attrs["Network"] = "Bridged triangles"
+
+	stats := ComputeTopologyStats("Bridged", topo)
+	if stats.Nodes != 7 || stats.Edges != 8 || stats.Components != 1 {
+		t.Errorf("got %d nodes, %d edges and %d components, want 7, 8 and 1", stats.Nodes, stats.Edges, stats.Components)
+	}
+	// a - c - d - f - g
+	if stats.Diameter != 4 {
+		t.Errorf("got diameter %d, want 4", stats.Diameter)
+	}
+	// c - d and f - g
+	if stats.Bridges != 2 {
+		t.Errorf("got %d bridges, want 2", stats.Bridges)
+	}
+	// c, d and f
+	if stats.ArticulationPoints != 3 {
+		t.Errorf("got %d articulation points, want 3", stats.ArticulationPoints)
+	}
+	if want := map[int]int{1: 1, 2: 3, 3: 3}; !maps.Equal(stats.DegreeDistribution, want) {
+		t.Errorf("got degree distribution %v, want %v", stats.DegreeDistribution, want)
+	}
+	if row := stats.Row(); row[5] != "2.29" || row[8] != "1:1 2:3 3:3" || row[9] != "Bridged triangles" {
+		t.Errorf("got row %v", row)
+	}
+}
+
+func TestComputeTopologyStatsOfDisconnectedTopology(t *testing.T) {
+	topo, err := EdgeListToTopology(strings.NewReader(disconnectedEdgeList), "Disconnected")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := ComputeTopologyStats("Disconnected", topo)
+	// the diameter only counts the distances between connected nodes
+	if stats.Components != 3 || stats.Diameter != 1 || stats.Bridges != 2 || stats.ArticulationPoints != 0 {
+		t.Errorf("got %+v", stats)
+	}
+}
+
+func TestSortTopologyStats(t *testing.T) {
+	stats := []TopologyStats{
+		{Name: "b", Nodes: 10},
+		{Name: "a", Nodes: 9},
+		{Name: "c", Nodes: 10},
+	}
+
+	// numeric columns are not compared as strings, ties are broken by name
+	if err := SortTopologyStats(stats, "nodes", true); err != nil {
+		t.Fatal(err)
+	}
+	if stats[0].Name != "b" || stats[1].Name != "c" || stats[2].Name != "a" {
+		t.Errorf("got order %s, %s, %s, want b, c, a", stats[0].Name, stats[1].Name, stats[2].Name)
+	}
+
+	if err := SortTopologyStats(stats, "size", false); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+}